@@ -0,0 +1,190 @@
+// Copyright 2021 VILT Group
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package appcmds
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/viltgroup/bucket-restore/internal/brestore"
+	"github.com/viltgroup/bucket-restore/internal/brestore/timeline"
+)
+
+// chartWidth is the maximum number of characters of a bar in the activity chart.
+const chartWidth = 60
+
+// timeLayout is the layout used to print points in time that can be given back to the --time flag.
+const timeLayout = "2006-01-02 15:04:05 -07:00"
+
+var (
+	activitySinceFlag *string
+	activityUntilFlag *string
+	activityWidthFlag *time.Duration
+	activityOutput    *string
+)
+
+var activityExamples = "" +
+	"  Show the writes, overwrites and deletes per minute in a bucket during an hour:\n" +
+	"    brestore activity --bucket s3://mybucket --since \"2021-02-21 09:00:00 +01:00\" " +
+	"--until \"2021-02-21 10:00:00 +01:00\" --bucket-width 1m\n\n" +
+	"  Show the activity per hour inside a path since a point in time, in JSON format:\n" +
+	"    brestore activity --bucket gs://mybucket/path --since \"2021-02-21 00:00:00 +01:00\" --bucket-width 1h -o json"
+
+func init() {
+	activitySinceFlag = activityCmd.Flags().String("since", "",
+		"start of the period to show. If not given, the period starts at the oldest change. "+
+			"Accepts the same formats as --time.")
+	activityUntilFlag = activityCmd.Flags().String("until", "",
+		"end of the period to show. If not given, the current time is used. Accepts the same formats as --time.")
+	activityWidthFlag = activityCmd.Flags().Duration("bucket-width", time.Minute,
+		"width of each interval of the histogram. e.g: --bucket-width 30s, --bucket-width 5m, --bucket-width 1h")
+	activityOutput = activityCmd.Flags().StringP("output", "o", textOutput,
+		"output format. One of 'text' or 'json'.")
+
+	rootCmd.AddCommand(activityCmd)
+}
+
+var activityCmd = &cobra.Command{
+	Use:   "activity",
+	Short: "Shows a histogram of the changes made to the objects in a bucket",
+	Long: "" +
+		"Description:\n" +
+		"  Shows how many objects were written, overwritten and deleted in each interval of a period of time. " +
+		"This helps finding when a mass change started, so that a point in time just before it can be given " +
+		"to the rollback command. No changes are made to the bucket.",
+	Example:      activityExamples,
+	RunE:         activityEntryPoint,
+	SilenceUsage: true,
+}
+
+func activityEntryPoint(cmd *cobra.Command, args []string) error {
+	if *sourceBucketFlag == "" {
+		return fmt.Errorf("No bucket specified. Specify the bucket to which this action should be applied with -b <bucket_url>.")
+	}
+
+	if *activityWidthFlag <= 0 {
+		return fmt.Errorf("the --bucket-width must be greater than zero")
+	}
+
+	if err := checkOutputFormat(*activityOutput); err != nil {
+		return err
+	}
+
+	binfo, err := brestore.ParseBucketURL(*sourceBucketFlag)
+	if err != nil {
+		return fmt.Errorf("could not parse bucket information from url: %v", err)
+	}
+
+	var since time.Time
+	if *activitySinceFlag != "" {
+		since, err = brestore.ParseTimestamp(*activitySinceFlag)
+		if err != nil {
+			return fmt.Errorf("could not parse --since timestamp: %v", err)
+		}
+	}
+
+	until := time.Now()
+	if *activityUntilFlag != "" {
+		until, err = brestore.ParseTimestamp(*activityUntilFlag)
+		if err != nil {
+			return fmt.Errorf("could not parse --until timestamp: %v", err)
+		}
+	}
+
+	events, err := eventsOf(binfo)
+	if err != nil {
+		return fmt.Errorf("error performing activity command: %v", err)
+	}
+
+	events.SortByTimeAsc()
+	if since.IsZero() {
+		if len(events) == 0 {
+			return printNoActivity()
+		}
+		since = events[0].Time
+	}
+
+	if !since.Before(until) {
+		return fmt.Errorf("the start of the period must be before its end")
+	}
+
+	if timeline.BinCount(since.Truncate(*activityWidthFlag), until, *activityWidthFlag) > timeline.MaxBins {
+		return fmt.Errorf("the period has more than %d intervals of %v. Use a larger --bucket-width or a "+
+			"shorter period", timeline.MaxBins, *activityWidthFlag)
+	}
+
+	bins := timeline.Histogram(events, since, until, *activityWidthFlag)
+
+	if *activityOutput == jsonOutput {
+		return printJSON(bins)
+	}
+
+	printActivityChart(bins, since.Location())
+	return nil
+}
+
+// printNoActivity prints an empty histogram for a path without changes.
+func printNoActivity() error {
+	if *activityOutput == jsonOutput {
+		return printJSON([]timeline.Bin{})
+	}
+	fmt.Printf("No changes found\n")
+	return nil
+}
+
+// eventsOf returns the changes made to all the objects in the bucket/path.
+func eventsOf(binfo brestore.BucketURLInfo) (timeline.Events, error) {
+	switch binfo.Type {
+	case "s3":
+		return eventsAWS(*profileFlag, binfo.BucketName, binfo.Prefix)
+	case "gs":
+		return eventsGCP(*keyFileFlag, binfo.BucketName, binfo.Prefix)
+	}
+	return nil, fmt.Errorf("unsupported bucket type '%s'", binfo.Type)
+}
+
+func printActivityChart(bins []timeline.Bin, loc *time.Location) {
+	var max int64
+	for _, b := range bins {
+		if b.Total() > max {
+			max = b.Total()
+		}
+	}
+
+	fmt.Printf("Legend: '+' writes, '~' overwrites, '-' deletes\n\n")
+
+	for _, b := range bins {
+		bar := chartBar('+', b.Writes, max) + chartBar('~', b.Overwrites, max) + chartBar('-', b.Deletes, max)
+		fmt.Printf("%s |%-*s| %d writes, %d overwrites, %d deletes\n",
+			b.Start.In(loc).Format(timeLayout), chartWidth, bar, b.Writes, b.Overwrites, b.Deletes)
+	}
+}
+
+// chartBar returns a bar proportional to count, where max fills the width of the chart.
+// Counts greater than zero always have at least one character.
+func chartBar(c rune, count int64, max int64) string {
+	if count == 0 || max == 0 {
+		return ""
+	}
+
+	n := int(count * chartWidth / max)
+	if n == 0 {
+		n = 1
+	}
+
+	return strings.Repeat(string(c), n)
+}
@@ -0,0 +1,162 @@
+// Copyright 2021 VILT Group
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package appcmds
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/viltgroup/bucket-restore/internal/brestore"
+	"github.com/viltgroup/bucket-restore/internal/brestore/timeline"
+)
+
+var (
+	analyzeSinceFlag     *string
+	analyzeUntilFlag     *string
+	analyzeGapFlag       *time.Duration
+	analyzeMinKeysFlag   *int
+	analyzeSizeRatioFlag *float64
+	analyzeDepthFlag     *int
+	analyzeOutput        *string
+)
+
+var analyzeExamples = "" +
+	"  Look for mass deletes, bulk overwrites, extension changes and size changes in a bucket:\n" +
+	"    brestore analyze --bucket s3://mybucket\n\n" +
+	"  Only report patterns affecting at least 500 objects since a point in time, in JSON format:\n" +
+	"    brestore analyze --bucket gs://mybucket/path --since \"2021-02-21 00:00:00 +01:00\" --min-keys 500 -o json"
+
+type findingEntry struct {
+	Kind string `json:"kind"`
+	timeline.Finding
+}
+
+func init() {
+	analyzeSinceFlag = analyzeCmd.Flags().String("since", "",
+		"only analyze change sets that end after this point in time. Accepts the same formats as --time.")
+	analyzeUntilFlag = analyzeCmd.Flags().String("until", "",
+		"only analyze change sets that start before this point in time. Accepts the same formats as --time.")
+	analyzeGapFlag = analyzeCmd.Flags().Duration("gap", defaultChangeSetGap,
+		"minimum period without changes that separates two change sets. e.g: --gap 30s, --gap 10m")
+	analyzeMinKeysFlag = analyzeCmd.Flags().Int("min-keys", 100,
+		"minimum number of objects showing a pattern in the same change set for it to be reported.")
+	analyzeSizeRatioFlag = analyzeCmd.Flags().Float64("size-ratio", 10,
+		"minimum ratio between the old and the new size of an overwritten object for its size change "+
+			"to be suspicious. Objects truncated to zero bytes are always suspicious.")
+	analyzeDepthFlag = analyzeCmd.Flags().Int("prefix-depth", 1,
+		"number of path segments used to group the affected objects.")
+	analyzeOutput = analyzeCmd.Flags().StringP("output", "o", textOutput,
+		"output format. One of 'text' or 'json'.")
+
+	rootCmd.AddCommand(analyzeCmd)
+}
+
+var analyzeCmd = &cobra.Command{
+	Use:   "analyze",
+	Short: "Looks for suspicious mass changes in a bucket and suggests restore points",
+	Long: "" +
+		"Description:\n" +
+		"  Groups the changes made to the objects in a bucket into change sets (see the changesets command) " +
+		"and looks for suspicious patterns in each one: mass deletes, bulk overwrites, objects replaced by " +
+		"objects with another file extension and objects overwritten by objects of a very different size. " +
+		"For each finding, shows the affected prefixes and a suggested restore time, which is the latest point " +
+		"in time before the change set. No changes are made to the bucket.",
+	Example:      analyzeExamples,
+	RunE:         analyzeEntryPoint,
+	SilenceUsage: true,
+}
+
+func analyzeEntryPoint(cmd *cobra.Command, args []string) error {
+	if *sourceBucketFlag == "" {
+		return fmt.Errorf("No bucket specified. Specify the bucket to which this action should be applied with -b <bucket_url>.")
+	}
+
+	if *analyzeGapFlag <= 0 {
+		return fmt.Errorf("the --gap must be greater than zero")
+	}
+
+	if err := checkOutputFormat(*analyzeOutput); err != nil {
+		return err
+	}
+
+	binfo, err := brestore.ParseBucketURL(*sourceBucketFlag)
+	if err != nil {
+		return fmt.Errorf("could not parse bucket information from url: %v", err)
+	}
+
+	var since, until time.Time
+	if *analyzeSinceFlag != "" {
+		since, err = brestore.ParseTimestamp(*analyzeSinceFlag)
+		if err != nil {
+			return fmt.Errorf("could not parse --since timestamp: %v", err)
+		}
+	}
+	if *analyzeUntilFlag != "" {
+		until, err = brestore.ParseTimestamp(*analyzeUntilFlag)
+		if err != nil {
+			return fmt.Errorf("could not parse --until timestamp: %v", err)
+		}
+	}
+
+	events, err := eventsOf(binfo)
+	if err != nil {
+		return fmt.Errorf("error performing analyze command: %v", err)
+	}
+
+	var sets []timeline.ChangeSet
+	for _, cs := range timeline.ChangeSets(events, *analyzeGapFlag, *analyzeDepthFlag) {
+		if (!since.IsZero() && cs.End.Before(since)) || (!until.IsZero() && !cs.Start.Before(until)) {
+			continue
+		}
+		sets = append(sets, cs)
+	}
+
+	findings := timeline.Analyze(sets, timeline.Thresholds{
+		MinKeys:     *analyzeMinKeysFlag,
+		SizeRatio:   *analyzeSizeRatioFlag,
+		PrefixDepth: *analyzeDepthFlag,
+	})
+
+	if *analyzeOutput == jsonOutput {
+		var entries []findingEntry
+		for _, f := range findings {
+			entries = append(entries, findingEntry{Kind: f.Kind.String(), Finding: f})
+		}
+		return printJSON(entries)
+	}
+
+	printFindings(findings, len(sets), since.Location(), *analyzeGapFlag)
+	return nil
+}
+
+func printFindings(findings []timeline.Finding, analyzed int, loc *time.Location, gap time.Duration) {
+	fmt.Printf("Analyzed %d change sets.\n\n", analyzed)
+
+	if len(findings) == 0 {
+		fmt.Printf("No suspicious changes found.\n")
+		return
+	}
+
+	for _, f := range findings {
+		fmt.Printf("%s in change set '%s' (%s to %s)\n", f.Kind, f.ChangeSetID,
+			f.Start.In(loc).Format(timeLayout), f.End.In(loc).Format(timeLayout))
+		fmt.Printf("    %s\n", f.Detail)
+		fmt.Printf("    Affected prefixes: %s\n", formatPrefixes(f.Prefixes))
+		fmt.Printf("    Suggested restore time: %s\n\n", f.RestorePoint.In(loc).Format(time.RFC3339Nano))
+	}
+
+	printBeforeChangeSetHint(gap)
+}
@@ -0,0 +1,105 @@
+// Copyright 2021 VILT Group
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package appcmds
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/viltgroup/bucket-restore/internal/brestore"
+	"github.com/viltgroup/bucket-restore/internal/brestore/estimate"
+	gcp_history "github.com/viltgroup/bucket-restore/internal/brestore/gcprestore/history"
+)
+
+// classFeeGCP represents the objects of a storage class charged a fee by a rollback.
+type classFeeGCP struct {
+	Objects int
+	Bytes   int64
+}
+
+// archiveFeesGCP accumulates the objects of a rollback charged the fees of the NEARLINE, COLDLINE and
+// ARCHIVE classes: retrieval fees for the generations copied from them, and early deletion fees for
+// the objects replaced or deleted before the minimum storage duration of their class.
+type archiveFeesGCP struct {
+	prices        estimate.ProviderPrices
+	retrieval     map[string]*classFeeGCP
+	earlyDeletion map[string]*classFeeGCP
+}
+
+func newArchiveFeesGCP(prices estimate.ProviderPrices) *archiveFeesGCP {
+	return &archiveFeesGCP{
+		prices:        prices,
+		retrieval:     map[string]*classFeeGCP{},
+		earlyDeletion: map[string]*classFeeGCP{},
+	}
+}
+
+// add registers the fees charged by an action.
+func (f *archiveFeesGCP) add(action gcp_history.FileAction, from gcp_history.PathState, to gcp_history.PathState,
+	now time.Time) {
+
+	if action.Action == gcp_history.CREATE && f.prices.StorageClasses[to.StorageClass].RetrievalPerGB > 0 {
+		addClassFee(f.retrieval, to.StorageClass, to.Size)
+	}
+
+	if (action.Action == gcp_history.CREATE || action.Action == gcp_history.DELETE) &&
+		from.PathStatus == gcp_history.EXISTS {
+		minDays := f.prices.StorageClasses[from.StorageClass].MinStorageDays
+		if minDays > 0 && now.Sub(from.Created) < time.Duration(minDays)*24*time.Hour {
+			addClassFee(f.earlyDeletion, from.StorageClass, from.Size)
+		}
+	}
+}
+
+func addClassFee(fees map[string]*classFeeGCP, storageClass string, size int64) {
+	fee, ok := fees[storageClass]
+	if !ok {
+		fee = &classFeeGCP{}
+		fees[storageClass] = fee
+	}
+	fee.Objects++
+	fee.Bytes += size
+}
+
+// print prints a warning for each storage class with fees.
+func (f *archiveFeesGCP) print() {
+	if len(f.retrieval) == 0 && len(f.earlyDeletion) == 0 {
+		return
+	}
+
+	fmt.Printf("Storage class warnings:\n")
+	for _, class := range sortedClasses(f.retrieval) {
+		fee := f.retrieval[class]
+		fmt.Printf("    %d objects (%s) are copied from %s generations, which are charged a retrieval fee "+
+			"for each GB read\n", fee.Objects, brestore.ByteCountIECString(fee.Bytes), class)
+	}
+	for _, class := range sortedClasses(f.earlyDeletion) {
+		fee := f.earlyDeletion[class]
+		fmt.Printf("    %d objects (%s) of class %s are replaced or deleted before its minimum storage "+
+			"duration of %d days, and may be charged for the remaining days\n", fee.Objects,
+			brestore.ByteCountIECString(fee.Bytes), class, f.prices.StorageClasses[class].MinStorageDays)
+	}
+	fmt.Printf("    Use '--estimate' to see the cost of these fees.\n\n")
+}
+
+func sortedClasses(fees map[string]*classFeeGCP) []string {
+	var res []string
+	for c := range fees {
+		res = append(res, c)
+	}
+	sort.Strings(res)
+	return res
+}
@@ -0,0 +1,202 @@
+// Copyright 2021 VILT Group
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package appcmds
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/viltgroup/bucket-restore/internal/brestore"
+	"github.com/viltgroup/bucket-restore/internal/brestore/timeline"
+)
+
+// defaultChangeSetGap is the default quiet period that separates two change sets.
+const defaultChangeSetGap = time.Minute
+
+// maxShownPrefixes is the maximum number of prefixes shown for each change set in text output.
+const maxShownPrefixes = 3
+
+var (
+	changeSetsSinceFlag *string
+	changeSetsUntilFlag *string
+	changeSetsGapFlag   *time.Duration
+	changeSetsDepthFlag *int
+	changeSetsOutput    *string
+)
+
+var changeSetsExamples = "" +
+	"  List the bursts of changes in a bucket, where a burst ends after 1 minute without changes:\n" +
+	"    brestore changesets --bucket s3://mybucket\n\n" +
+	"  List the bursts of changes inside a path since a point in time, separated by 10 minutes without changes:\n" +
+	"    brestore changesets --bucket gs://mybucket/path --since \"2021-02-21 00:00:00 +01:00\" --gap 10m\n\n" +
+	"  Rollback a bucket to the point in time just before a change set:\n" +
+	"    brestore rollback --bucket s3://mybucket --before-changeset 3f2a9c01be"
+
+type changeSetEntry struct {
+	timeline.ChangeSet
+	RestorePoint time.Time `json:"restore_point"`
+	Events       int       `json:"events"`
+}
+
+func init() {
+	changeSetsSinceFlag = changeSetsCmd.Flags().String("since", "",
+		"only show change sets that end after this point in time. Accepts the same formats as --time.")
+	changeSetsUntilFlag = changeSetsCmd.Flags().String("until", "",
+		"only show change sets that start before this point in time. Accepts the same formats as --time.")
+	changeSetsGapFlag = changeSetsCmd.Flags().Duration("gap", defaultChangeSetGap,
+		"minimum period without changes that separates two change sets. e.g: --gap 30s, --gap 10m")
+	changeSetsDepthFlag = changeSetsCmd.Flags().Int("prefix-depth", 1,
+		"number of path segments used to group the keys changed by each change set.")
+	changeSetsOutput = changeSetsCmd.Flags().StringP("output", "o", textOutput,
+		"output format. One of 'text' or 'json'.")
+
+	rootCmd.AddCommand(changeSetsCmd)
+}
+
+var changeSetsCmd = &cobra.Command{
+	Use:   "changesets",
+	Short: "Lists the bursts of changes made to the objects in a bucket",
+	Long: "" +
+		"Description:\n" +
+		"  Groups the changes made to the objects in a bucket into change sets: bursts of changes separated " +
+		"by a period without changes (see --gap). For each change set, shows an identifier, when it started " +
+		"and ended, how many keys it changed, the prefixes it touched and its restore point, which is the " +
+		"latest point in time before any of its changes.\n\n" +
+		"  The identifier of a change set can be given to 'rollback --before-changeset' to rollback to its " +
+		"restore point. Identifiers depend on the path given to --bucket and on --gap, so the same values " +
+		"must be used in both commands. No changes are made to the bucket.",
+	Example:      changeSetsExamples,
+	RunE:         changeSetsEntryPoint,
+	SilenceUsage: true,
+}
+
+func changeSetsEntryPoint(cmd *cobra.Command, args []string) error {
+	if *sourceBucketFlag == "" {
+		return fmt.Errorf("No bucket specified. Specify the bucket to which this action should be applied with -b <bucket_url>.")
+	}
+
+	if *changeSetsGapFlag <= 0 {
+		return fmt.Errorf("the --gap must be greater than zero")
+	}
+
+	if err := checkOutputFormat(*changeSetsOutput); err != nil {
+		return err
+	}
+
+	binfo, err := brestore.ParseBucketURL(*sourceBucketFlag)
+	if err != nil {
+		return fmt.Errorf("could not parse bucket information from url: %v", err)
+	}
+
+	var since, until time.Time
+	if *changeSetsSinceFlag != "" {
+		since, err = brestore.ParseTimestamp(*changeSetsSinceFlag)
+		if err != nil {
+			return fmt.Errorf("could not parse --since timestamp: %v", err)
+		}
+	}
+	if *changeSetsUntilFlag != "" {
+		until, err = brestore.ParseTimestamp(*changeSetsUntilFlag)
+		if err != nil {
+			return fmt.Errorf("could not parse --until timestamp: %v", err)
+		}
+	}
+
+	events, err := eventsOf(binfo)
+	if err != nil {
+		return fmt.Errorf("error performing changesets command: %v", err)
+	}
+
+	var entries []changeSetEntry
+	for _, cs := range timeline.ChangeSets(events, *changeSetsGapFlag, *changeSetsDepthFlag) {
+		if (!since.IsZero() && cs.End.Before(since)) || (!until.IsZero() && !cs.Start.Before(until)) {
+			continue
+		}
+		entries = append(entries, changeSetEntry{ChangeSet: cs, RestorePoint: cs.RestorePoint(), Events: len(cs.Events)})
+	}
+
+	if *changeSetsOutput == jsonOutput {
+		return printJSON(entries)
+	}
+
+	printChangeSets(entries, since.Location(), *changeSetsGapFlag)
+	return nil
+}
+
+func printChangeSets(entries []changeSetEntry, loc *time.Location, gap time.Duration) {
+	if len(entries) == 0 {
+		fmt.Printf("No changes found.\n")
+		return
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "ID\tSTART\tEND\tEVENTS\tKEYS\tPREFIXES\n")
+	for _, e := range entries {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%d\t%d\t%s\n", e.ID, e.Start.In(loc).Format(timeLayout),
+			e.End.In(loc).Format(timeLayout), e.Events, e.Keys, formatPrefixes(e.Prefixes))
+	}
+	w.Flush()
+
+	fmt.Printf("\n")
+	printBeforeChangeSetHint(gap)
+}
+
+// printBeforeChangeSetHint prints how to rollback to just before a change set found with the given gap.
+// The rollback must rebuild the change sets with the same gap for their ids to match.
+func printBeforeChangeSetHint(gap time.Duration) {
+	gapFlag := ""
+	if gap != defaultChangeSetGap {
+		gapFlag = fmt.Sprintf(" --changeset-gap %v", gap)
+	}
+	fmt.Printf("To rollback to the point in time just before a change set, use:\n"+
+		"    brestore rollback --bucket <bucket_url> --before-changeset <id>%s\n", gapFlag)
+}
+
+func formatPrefixes(prefixes []timeline.PrefixCount) string {
+	var parts []string
+	for i, p := range prefixes {
+		if i == maxShownPrefixes {
+			parts = append(parts, fmt.Sprintf("(+%d more)", len(prefixes)-maxShownPrefixes))
+			break
+		}
+		prefix := p.Prefix
+		if prefix == "" {
+			prefix = "/"
+		}
+		parts = append(parts, fmt.Sprintf("%s (%d)", prefix, p.Keys))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// restorePointOfChangeSet finds the change set with the given id in the bucket/path and returns its restore point.
+func restorePointOfChangeSet(binfo brestore.BucketURLInfo, id string, gap time.Duration) (time.Time, error) {
+	events, err := eventsOf(binfo)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	cs, ok := timeline.FindChangeSet(timeline.ChangeSets(events, gap, 1), id)
+	if !ok {
+		return time.Time{}, fmt.Errorf("change set '%s' not found. Make sure --bucket and --changeset-gap have "+
+			"the same values given to the changesets command", id)
+	}
+
+	fmt.Printf("Change set '%s' started at %v, changing %d keys.\n", cs.ID, cs.Start, cs.Keys)
+	return cs.RestorePoint(), nil
+}
@@ -0,0 +1,188 @@
+// Copyright 2021 VILT Group
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package appcmds
+
+import (
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/viltgroup/bucket-restore/internal/brestore/awsrestore"
+	"github.com/viltgroup/bucket-restore/internal/brestore/awsrestore/history"
+	"github.com/viltgroup/bucket-restore/internal/brestore/awsrestore/history/versions"
+)
+
+// contentComparerAWS compares the content of versions whose ETags are not the MD5 of their content,
+// using their additional checksums, to avoid copying versions with the same content as the current one.
+type contentComparerAWS struct {
+	client     *s3.S3
+	bucketName string
+	// Whether the bucket encrypts new objects with SSE-KMS by default, which makes every ETag ambiguous
+	kmsBucket bool
+	// Whether the default encryption of the bucket couldn't be read
+	encryptionUnknown bool
+	// Checksums read by prefetch, by key and version id
+	infos map[versionRef]contentInfoResult
+}
+
+// versionRef identifies a version of an object.
+type versionRef struct {
+	Key string
+	ID  string
+}
+
+// contentInfoResult is the result of reading the checksums of a version.
+type contentInfoResult struct {
+	Info awsrestore.ContentInfo
+	Err  error
+}
+
+func newContentComparerAWS(client *s3.S3, bucketName string) *contentComparerAWS {
+	// without permission to read the default encryption, only multipart ETags are known to be ambiguous
+	encryption, err := awsrestore.DefaultEncryptionOf(client, bucketName)
+
+	return &contentComparerAWS{
+		client:            client,
+		bucketName:        bucketName,
+		kmsBucket:         encryption == s3.ServerSideEncryptionAwsKms,
+		encryptionUnknown: err != nil,
+		infos:             map[versionRef]contentInfoResult{},
+	}
+}
+
+// versionsMayHaveOtherKeys checks if versions may be encrypted with other keys than the default of the bucket,
+// which copies would get unless given the encryption of their version. Outside buckets that use SSE-KMS by
+// default, versions are encrypted with the default SSE-S3 encryption or with their customer key.
+func (c *contentComparerAWS) versionsMayHaveOtherKeys() bool {
+	return c.kmsBucket || c.encryptionUnknown
+}
+
+// ambiguous checks if an action copies a version only because its ETag differs from the current one,
+// when the ETags may not be the MD5 of the content.
+func (c *contentComparerAWS) ambiguous(action history.FileAction, from history.PathState,
+	to history.PathState) bool {
+
+	if action.Action != history.CREATE || from.PathStatus != history.EXISTS || from.Size != to.Size {
+		return false
+	}
+	return c.kmsBucket || history.MultipartETag(from.ETag) || history.MultipartETag(to.ETag)
+}
+
+// prefetch reads the checksums of the versions that resolve will compare when rolling back the objects to t,
+// running up to maxConcurrency requests at the same time, so that planning doesn't wait for each of them
+// in turn.
+func (c *contentComparerAWS) prefetch(horizon history.HistoryHorizon, allVersions map[string]versions.Versions,
+	t time.Time, maxConcurrency int) {
+
+	var refs []versionRef
+	for _, vs := range allVersions {
+		vs.SortByLastModifiedAsc()
+		to, from := horizon.StateDiffAtTime(vs, t)
+		if c.ambiguous(history.ActionForStateChange(from, to), from, to) {
+			refs = append(refs, versionRef{from.Key, from.Version.ID}, versionRef{to.Key, to.Version.ID})
+		}
+	}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	refChan := make(chan versionRef)
+
+	if maxConcurrency > len(refs) {
+		maxConcurrency = len(refs)
+	} else if maxConcurrency < 1 {
+		maxConcurrency = 1
+	}
+	wg.Add(maxConcurrency)
+	for i := 0; i < maxConcurrency; i++ {
+		go func() {
+			for ref := range refChan {
+				res := c.contentInfoOf(ref)
+				mu.Lock()
+				c.infos[ref] = res
+				mu.Unlock()
+			}
+			wg.Done()
+		}()
+	}
+
+	for _, ref := range refs {
+		refChan <- ref
+	}
+	close(refChan)
+	wg.Wait()
+}
+
+// contentInfoOf reads the checksums of a version. Versions encrypted with SSE-C are read with the source
+// customer key of the object given to the rollback, which is the key of its versions unless they were
+// written with another one. Without the key, the version is left unresolved.
+func (c *contentComparerAWS) contentInfoOf(ref versionRef) contentInfoResult {
+	key := restoreKeys.KeysFor(ref.Key).SourceCustomerKey
+	info, err := awsrestore.ContentInfoOf(c.client, c.bucketName, ref.Key, ref.ID, key)
+	return contentInfoResult{Info: info, Err: err}
+}
+
+// cachedContentInfoOf returns the checksums read by prefetch, or reads them.
+func (c *contentComparerAWS) cachedContentInfoOf(ref versionRef) contentInfoResult {
+	if res, ok := c.infos[ref]; ok {
+		return res
+	}
+	return c.contentInfoOf(ref)
+}
+
+// resolve checks if an action that copies a version only because its ETag differs from the current one
+// is needed, when the ETags may not be the MD5 of the content. If the checksums can't be read,
+// the version is copied.
+func (c *contentComparerAWS) resolve(action history.FileAction, from history.PathState,
+	to history.PathState) history.FileAction {
+
+	if !c.ambiguous(action, from, to) {
+		return action
+	}
+
+	fromInfo := c.cachedContentInfoOf(versionRef{from.Key, from.Version.ID})
+	if fromInfo.Err != nil {
+		return action
+	}
+	toInfo := c.cachedContentInfoOf(versionRef{to.Key, to.Version.ID})
+	if toInfo.Err != nil {
+		return action
+	}
+
+	same, checksum := history.SameContent(from, to, fromInfo.Info, toInfo.Info)
+	action.Checksum = checksum
+	if same {
+		action.Action = history.NO_ACTION
+		action.UnmodifiedPreCondition = time.Time{}
+	}
+	return action
+}
+
+// copyChecksumAlgorithms are the algorithms of the additional checksums S3 can compute for a copy.
+var copyChecksumAlgorithms = []string{"CRC32", "CRC32C", "SHA1", "SHA256"}
+
+// copyChecksumAlgorithm returns the algorithm given to --checksum-algorithm, in upper case.
+func copyChecksumAlgorithm() string {
+	return strings.ToUpper(*checksumAlgorithmFlag)
+}
+
+func validCopyChecksumAlgorithm(alg string) bool {
+	for _, a := range copyChecksumAlgorithms {
+		if a == alg {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,157 @@
+// Copyright 2021 VILT Group
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package appcmds
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/viltgroup/bucket-restore/internal/brestore"
+)
+
+var (
+	diffFromFlag   *string
+	diffToFlag     *string
+	diffOutputFlag *string
+)
+
+var diffExamples = "" +
+	"  Show what changed in a bucket between 09:00 and 09:30:\n" +
+	"    brestore diff --bucket s3://mybucket --from \"2021-02-21 09:00:00 +01:00\" --to \"2021-02-21 09:30:00 +01:00\"\n\n" +
+	"  The same as the previous command, but only for a path and in JSON format:\n" +
+	"    brestore diff --bucket gs://mybucket/path/to/dir --from \"2021-02-21 09:00:00 +01:00\" " +
+	"--to \"2021-02-21 09:30:00 +01:00\" --output json"
+
+func init() {
+	diffFromFlag = diffCmd.Flags().String("from", "",
+		"the first point in time to compare. Accepts the same formats as --time.")
+	diffToFlag = diffCmd.Flags().String("to", "",
+		"the second point in time to compare. If not given, the current time is used. "+
+			"Accepts the same formats as --time.")
+	diffOutputFlag = diffCmd.Flags().StringP("output", "o", textOutput,
+		"output format. One of 'text' or 'json'.")
+
+	rootCmd.AddCommand(diffCmd)
+}
+
+var diffCmd = &cobra.Command{
+	Use:   "diff",
+	Short: "Shows the changes in a bucket between two points in time",
+	Long: "" +
+		"Description:\n" +
+		"  Shows the objects that were added, removed or modified between two points in time, including " +
+		"objects that were created and deleted again between them. No changes are made to the bucket.",
+	Example:      diffExamples,
+	RunE:         diffEntryPoint,
+	SilenceUsage: true,
+}
+
+// diffEntry represents the change of an object between two points in time.
+type diffEntry struct {
+	Key         string   `json:"key"`
+	Change      string   `json:"change"`
+	FromVersion string   `json:"from_version,omitempty"`
+	FromSize    int64    `json:"from_size,omitempty"`
+	ToVersion   string   `json:"to_version,omitempty"`
+	ToSize      int64    `json:"to_size,omitempty"`
+	Window      []string `json:"versions_in_window,omitempty"`
+}
+
+func diffEntryPoint(cmd *cobra.Command, args []string) error {
+	if *sourceBucketFlag == "" {
+		return fmt.Errorf("No bucket specified. Specify the bucket to which this action should be applied with -b <bucket_url>.")
+	}
+
+	if *diffFromFlag == "" {
+		return fmt.Errorf("No start time specified. Specify the first point in time to compare with --from <timestamp>.")
+	}
+
+	if err := checkOutputFormat(*diffOutputFlag); err != nil {
+		return err
+	}
+
+	binfo, err := brestore.ParseBucketURL(*sourceBucketFlag)
+	if err != nil {
+		return fmt.Errorf("could not parse bucket information from url: %v", err)
+	}
+
+	from, err := brestore.ParseTimestamp(*diffFromFlag)
+	if err != nil {
+		return fmt.Errorf("could not parse --from timestamp: %v", err)
+	}
+
+	to := time.Now()
+	if *diffToFlag != "" {
+		to, err = brestore.ParseTimestamp(*diffToFlag)
+		if err != nil {
+			return fmt.Errorf("could not parse --to timestamp: %v", err)
+		}
+	}
+
+	if !from.Before(to) {
+		return fmt.Errorf("the --from time must be before the --to time")
+	}
+
+	var entries []diffEntry
+	switch binfo.Type {
+	case "s3":
+		entries, err = diffAWS(*profileFlag, binfo.BucketName, binfo.Prefix, from, to)
+	case "gs":
+		entries, err = diffGCP(*keyFileFlag, binfo.BucketName, binfo.Prefix, from, to)
+	}
+	if err != nil {
+		return fmt.Errorf("error performing diff command: %v", err)
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Key < entries[j].Key
+	})
+
+	if *diffOutputFlag == jsonOutput {
+		return printJSON(entries)
+	}
+
+	printDiff(entries, from, to)
+	return nil
+}
+
+func printDiff(entries []diffEntry, from time.Time, to time.Time) {
+	fmt.Printf("Changes between %v and %v:\n\n", from, to)
+
+	counts := make(map[string]int)
+	for _, e := range entries {
+		counts[e.Change]++
+		switch e.Change {
+		case "Added":
+			fmt.Printf("+ %s (%s, %s)\n", e.Key, e.ToVersion, brestore.ByteCountIECString(e.ToSize))
+		case "Removed":
+			fmt.Printf("- %s (%s, %s)\n", e.Key, e.FromVersion, brestore.ByteCountIECString(e.FromSize))
+		case "Modified":
+			fmt.Printf("M %s (%s, %s) -> (%s, %s)\n", e.Key,
+				e.FromVersion, brestore.ByteCountIECString(e.FromSize),
+				e.ToVersion, brestore.ByteCountIECString(e.ToSize))
+		case "Transient":
+			fmt.Printf("~ %s created and deleted again, versions in between: %v\n", e.Key, e.Window)
+		}
+	}
+
+	fmt.Printf("\n")
+	fmt.Printf("    %d objects added\n", counts["Added"])
+	fmt.Printf("    %d objects removed\n", counts["Removed"])
+	fmt.Printf("    %d objects modified\n", counts["Modified"])
+	fmt.Printf("    %d objects created and deleted again\n", counts["Transient"])
+}
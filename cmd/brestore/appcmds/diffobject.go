@@ -0,0 +1,254 @@
+// Copyright 2021 VILT Group
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package appcmds
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/md5"
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/viltgroup/bucket-restore/internal/brestore"
+	"github.com/viltgroup/bucket-restore/internal/brestore/textdiff"
+)
+
+// maxTextDiffSize is the maximum size of the content of each version for a line by line diff to be shown.
+// Larger contents are compared by their checksums.
+const maxTextDiffSize = 8 * 1024 * 1024
+
+var (
+	diffObjectFromFlag    *string
+	diffObjectToFlag      *string
+	diffObjectContextFlag *int
+)
+
+var diffObjectExamples = "" +
+	"  Show how the live version of an object differs from the version that was live at a point in time:\n" +
+	"    brestore diff-object s3://mybucket/path/to/config.yaml --from \"2021-02-21 09:00:00 +01:00\"\n\n" +
+	"  Compare two versions of an object by their version ids:\n" +
+	"    brestore diff-object s3://mybucket/path/to/config.yaml --from 3HL4kqtJlcpXroDTDmJ.rmSpXd3dIbrHY --to " +
+	"Xr.WZi4Ea6kT.1bGr0pWkaQS0f7GyKvA\n\n" +
+	"  Compare two generations of an object in gcp storage:\n" +
+	"    brestore diff-object gs://mybucket/path/to/config.yaml --from 1613944800000000 --to 1613948400000000"
+
+// objectRevision represents the content of an object at a version/generation.
+type objectRevision struct {
+	// Description of the version/generation, or of why the object did not exist
+	Label string
+	// Whether the object existed. Revisions of objects that did not exist have no content
+	Exists bool
+	// Content of the version/generation. Contents larger than maxTextDiffSize are not downloaded, only hashed
+	Data []byte
+	// Whether the content is too large for a line by line diff. Only those have Size and checksums
+	TooLarge bool
+	Size     int64
+	SHA256   []byte
+	MD5      []byte
+}
+
+func init() {
+	diffObjectFromFlag = diffObjectCmd.Flags().String("from", "",
+		"version id (or generation) or point in time of the object to compare from. "+
+			"Points in time accept the same formats as --time.")
+	diffObjectToFlag = diffObjectCmd.Flags().String("to", "",
+		"version id (or generation) or point in time of the object to compare to. "+
+			"If not given, the current version of the object is used.")
+	diffObjectContextFlag = diffObjectCmd.Flags().Int("context", 3,
+		"number of unchanged lines shown around each change.")
+
+	rootCmd.AddCommand(diffObjectCmd)
+}
+
+var diffObjectCmd = &cobra.Command{
+	Use:   "diff-object [object_url]",
+	Short: "Shows the changes in the content of an object between two versions",
+	Long: "" +
+		"Description:\n" +
+		"  Downloads two versions/generations of an object and shows the changes between them. Text content is " +
+		"compared line by line and shown as a unified diff. Gzip compressed content is decompressed before being " +
+		"compared. For binary content and contents larger than 8 MiB, the size and the SHA-256 and MD5 checksums of " +
+		"both versions are shown.\n\n" +
+		"  Each version can be given by its version id (generation for gcp storage) or by a point in time, in " +
+		"which case the version that was live at that time is used. No changes are made to the bucket.",
+	Example:      diffObjectExamples,
+	Args:         cobra.MaximumNArgs(1),
+	RunE:         diffObjectEntryPoint,
+	SilenceUsage: true,
+}
+
+func diffObjectEntryPoint(cmd *cobra.Command, args []string) error {
+	binfo, err := bucketURLFromArgs(args)
+	if err != nil {
+		return err
+	}
+
+	if binfo.Prefix == "" {
+		return fmt.Errorf("No object specified. Specify the object to compare with <bucket_url>/path/to/object.")
+	}
+
+	if *diffObjectFromFlag == "" {
+		return fmt.Errorf("No version specified. Specify the version to compare from with --from <version|time>.")
+	}
+
+	var from, to objectRevision
+	switch binfo.Type {
+	case "s3":
+		from, to, err = objectRevisionsAWS(*profileFlag, binfo.BucketName, binfo.Prefix, *diffObjectFromFlag, *diffObjectToFlag)
+	case "gs":
+		from, to, err = objectRevisionsGCP(*keyFileFlag, binfo.BucketName, binfo.Prefix, *diffObjectFromFlag, *diffObjectToFlag)
+	}
+	if err != nil {
+		return fmt.Errorf("error performing diff-object command: %v", err)
+	}
+
+	if !from.Exists && !to.Exists {
+		return fmt.Errorf("the object does not exist at both versions: %s and %s", from.Label, to.Label)
+	}
+
+	for _, r := range []*objectRevision{&from, &to} {
+		if r.TooLarge {
+			continue
+		}
+		if r.Data, err = decompressIfGzip(r.Data); err != nil {
+			return fmt.Errorf("error performing diff-object command: %v", err)
+		}
+	}
+
+	url := fmt.Sprintf("%s://%s/%s", binfo.Type, binfo.BucketName, binfo.Prefix)
+
+	if from.TooLarge || to.TooLarge || textdiff.IsBinary(from.Data) || textdiff.IsBinary(to.Data) ||
+		len(from.Data) > maxTextDiffSize || len(to.Data) > maxTextDiffSize {
+		printContentSummary(url, from, to)
+		return nil
+	}
+
+	diff := textdiff.Unified(revisionName(url, from), revisionName(url, to),
+		string(from.Data), string(to.Data), *diffObjectContextFlag)
+	if diff == "" {
+		fmt.Printf("Contents of %s and %s are identical.\n", from.Label, to.Label)
+		return nil
+	}
+
+	fmt.Print(diff)
+	return nil
+}
+
+// parseRevisionTime checks if a --from/--to value is a point in time. The empty value means the current time.
+func parseRevisionTime(spec string) (time.Time, bool) {
+	if spec == "" {
+		return time.Now(), true
+	}
+
+	ts, err := brestore.ParseTimestamp(spec)
+	return ts, err == nil
+}
+
+// readRevisionContent reads the content of a version/generation of the given size into a revision. Contents
+// larger than maxTextDiffSize are streamed through the checksums instead of being kept in memory.
+func readRevisionContent(r io.Reader, size int64, rev *objectRevision) error {
+	var src io.Reader = r
+
+	if size <= maxTextDiffSize {
+		// the size is checked again in case the listed size is outdated
+		data, err := io.ReadAll(io.LimitReader(r, maxTextDiffSize+1))
+		if err != nil {
+			return err
+		}
+		if len(data) <= maxTextDiffSize {
+			rev.Data = data
+			return nil
+		}
+		src = io.MultiReader(bytes.NewReader(data), r)
+	}
+
+	sha256Hash, md5Hash := sha256.New(), md5.New()
+	n, err := io.Copy(io.MultiWriter(sha256Hash, md5Hash), src)
+	if err != nil {
+		return err
+	}
+
+	rev.TooLarge = true
+	rev.Size = n
+	rev.SHA256 = sha256Hash.Sum(nil)
+	rev.MD5 = md5Hash.Sum(nil)
+	return nil
+}
+
+// checksums returns the size and checksums of the content of a revision.
+func (r objectRevision) checksums() (int64, []byte, []byte) {
+	if r.TooLarge {
+		return r.Size, r.SHA256, r.MD5
+	}
+	sha256Sum, md5Sum := sha256.Sum256(r.Data), md5.Sum(r.Data)
+	return int64(len(r.Data)), sha256Sum[:], md5Sum[:]
+}
+
+// decompressIfGzip decompresses data if it starts with the gzip magic number.
+func decompressIfGzip(data []byte) ([]byte, error) {
+	if len(data) < 2 || data[0] != 0x1f || data[1] != 0x8b {
+		return data, nil
+	}
+
+	r, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("decompressing gzip content: %w", err)
+	}
+	defer r.Close()
+
+	res, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("decompressing gzip content: %w", err)
+	}
+
+	return res, nil
+}
+
+func revisionName(url string, r objectRevision) string {
+	if !r.Exists {
+		return "/dev/null"
+	}
+	return fmt.Sprintf("%s (%s)", url, r.Label)
+}
+
+func printContentSummary(url string, from objectRevision, to objectRevision) {
+	fmt.Printf("%s\n\n", url)
+
+	for _, r := range []objectRevision{from, to} {
+		fmt.Printf("  %s\n", r.Label)
+		if !r.Exists {
+			fmt.Printf("      (object did not exist)\n")
+			continue
+		}
+		size, sha256Sum, md5Sum := r.checksums()
+		fmt.Printf("      Size: %s (%d bytes)\n", brestore.ByteCountIECString(size), size)
+		fmt.Printf("      SHA-256: %x\n", sha256Sum)
+		fmt.Printf("      MD5: %x\n", md5Sum)
+		if r.TooLarge {
+			fmt.Printf("      (too large for a line diff)\n")
+		}
+	}
+
+	_, fromSum, _ := from.checksums()
+	_, toSum, _ := to.checksums()
+	if from.Exists == to.Exists && bytes.Equal(fromSum, toSum) {
+		fmt.Printf("\nContents are identical.\n")
+	} else {
+		fmt.Printf("\nContents differ.\n")
+	}
+}
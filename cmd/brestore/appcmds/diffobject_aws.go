@@ -0,0 +1,93 @@
+// Copyright 2021 VILT Group
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package appcmds
+
+import (
+	"fmt"
+
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/viltgroup/bucket-restore/internal/brestore/awsrestore"
+	"github.com/viltgroup/bucket-restore/internal/brestore/awsrestore/history"
+	"github.com/viltgroup/bucket-restore/internal/brestore/awsrestore/history/versions"
+)
+
+func objectRevisionsAWS(profile string, bucketName string, key string, fromSpec string, toSpec string) (objectRevision, objectRevision, error) {
+	client, err := awsrestore.GetS3Client(profile)
+	if err != nil {
+		return objectRevision{}, objectRevision{}, fmt.Errorf("unable to get s3 client for profile '%v': %w", profile, err)
+	}
+
+	allVersions, err := versions.OfPathByName(client, bucketName, key)
+	if err != nil {
+		return objectRevision{}, objectRevision{}, fmt.Errorf("listing versions of object: %w", err)
+	}
+
+	vs := allVersions[key]
+	if len(vs) == 0 {
+		return objectRevision{}, objectRevision{}, fmt.Errorf("no versions found for object '%s'", key)
+	}
+
+	from, err := objectRevisionAWS(client, bucketName, vs, fromSpec)
+	if err != nil {
+		return objectRevision{}, objectRevision{}, err
+	}
+
+	to, err := objectRevisionAWS(client, bucketName, vs, toSpec)
+	if err != nil {
+		return objectRevision{}, objectRevision{}, err
+	}
+
+	return from, to, nil
+}
+
+// objectRevisionAWS downloads the version with the given id, or the version live at the given point in time.
+func objectRevisionAWS(client *s3.S3, bucketName string, vs versions.Versions, spec string) (objectRevision, error) {
+	var v versions.Version
+
+	if ts, ok := parseRevisionTime(spec); ok {
+		state := history.StateAtTime(vs, ts)
+		if state.PathStatus != history.EXISTS {
+			return objectRevision{Label: fmt.Sprintf("%s at %v", state.PathStatus, ts)}, nil
+		}
+		v = state.Version
+	} else {
+		found := false
+		for _, candidate := range vs {
+			if candidate.ID == spec {
+				v, found = candidate, true
+				break
+			}
+		}
+		if !found {
+			return objectRevision{}, fmt.Errorf("version '%s' not found", spec)
+		}
+		if v.IsDeleteMarker {
+			return objectRevision{Label: fmt.Sprintf("delete marker %s (%v)", v.ID, v.LastModified)}, nil
+		}
+	}
+
+	body, err := versions.ContentOf(client, bucketName, v)
+	if err != nil {
+		return objectRevision{}, err
+	}
+	defer body.Close()
+
+	res := objectRevision{Label: fmt.Sprintf("version %s (%v)", v.ID, v.LastModified), Exists: true}
+	if err := readRevisionContent(body, v.Size, &res); err != nil {
+		return objectRevision{}, fmt.Errorf("downloading version '%s': %w", v.ID, err)
+	}
+
+	return res, nil
+}
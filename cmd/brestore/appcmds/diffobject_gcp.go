@@ -0,0 +1,102 @@
+// Copyright 2021 VILT Group
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package appcmds
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"cloud.google.com/go/storage"
+	"github.com/viltgroup/bucket-restore/internal/brestore/gcprestore"
+	gcp_history "github.com/viltgroup/bucket-restore/internal/brestore/gcprestore/history"
+	gcp_generations "github.com/viltgroup/bucket-restore/internal/brestore/gcprestore/history/generations"
+)
+
+func objectRevisionsGCP(keyfile string, bucketName string, name string, fromSpec string, toSpec string) (objectRevision, objectRevision, error) {
+	client, _, err := gcprestore.GetStorageClientFromFile(keyfile)
+	if err != nil {
+		return objectRevision{}, objectRevision{}, fmt.Errorf("getting storage client for key file '%v': %w", keyfile, err)
+	}
+
+	bucket := client.Bucket(bucketName)
+
+	allGens, err := gcp_generations.OfPathByName(bucket, name)
+	if err != nil {
+		return objectRevision{}, objectRevision{}, fmt.Errorf("listing generations of object: %w", err)
+	}
+
+	gens := allGens[name]
+	if len(gens) == 0 {
+		return objectRevision{}, objectRevision{}, fmt.Errorf("no generations found for object '%s'", name)
+	}
+
+	from, err := objectRevisionGCP(bucket, gens, fromSpec)
+	if err != nil {
+		return objectRevision{}, objectRevision{}, err
+	}
+
+	to, err := objectRevisionGCP(bucket, gens, toSpec)
+	if err != nil {
+		return objectRevision{}, objectRevision{}, err
+	}
+
+	return from, to, nil
+}
+
+// objectRevisionGCP downloads the given generation, or the generation live at the given point in time.
+// Generations may be prefixed with '#', as shown by the other commands.
+func objectRevisionGCP(bucket *storage.BucketHandle, gens gcp_generations.Generations, spec string) (objectRevision, error) {
+	var g gcp_generations.Generation
+
+	if generation, err := strconv.ParseInt(strings.TrimPrefix(spec, "#"), 10, 64); err == nil {
+		found := false
+		for _, candidate := range gens {
+			if candidate.Generation == generation {
+				g, found = candidate, true
+				break
+			}
+		}
+		if !found {
+			return objectRevision{}, fmt.Errorf("generation '%d' not found", generation)
+		}
+	} else if ts, ok := parseRevisionTime(spec); ok {
+		state := gcp_history.StateAtTime(gens, ts)
+		if state.PathStatus != gcp_history.EXISTS {
+			return objectRevision{Label: fmt.Sprintf("%s at %v", state.PathStatus, ts)}, nil
+		}
+		for _, candidate := range gens {
+			if candidate.Generation == state.Generation {
+				g = candidate
+				break
+			}
+		}
+	} else {
+		return objectRevision{}, fmt.Errorf("'%s' is neither a generation nor a point in time", spec)
+	}
+
+	r, err := gcp_generations.ContentOf(bucket, g)
+	if err != nil {
+		return objectRevision{}, err
+	}
+	defer r.Close()
+
+	res := objectRevision{Label: fmt.Sprintf("generation #%d (%v)", g.Generation, g.Created), Exists: true}
+	if err := readRevisionContent(r, g.Size, &res); err != nil {
+		return objectRevision{}, fmt.Errorf("downloading generation '%d': %w", g.Generation, err)
+	}
+
+	return res, nil
+}
@@ -0,0 +1,138 @@
+// Copyright 2021 VILT Group
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package appcmds
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+)
+
+var doctorOutput *string
+
+// Status of a doctor check.
+const (
+	checkOK      = "OK"
+	checkWarning = "WARN"
+	checkFailed  = "FAIL"
+	checkUnknown = "UNKNOWN"
+)
+
+var doctorExamples = "" +
+	"  Check if an AWS S3 bucket is protected and the current credentials can rollback its objects:\n" +
+	"    brestore doctor s3://mybucket\n\n" +
+	"  The same as the previous command, but for gcp storage, in JSON format:\n" +
+	"    brestore doctor gs://mybucket -o json"
+
+// doctorCheck represents the result of checking a setting of a bucket, or a permission of the credentials.
+type doctorCheck struct {
+	Name   string `json:"name"`
+	Status string `json:"status"`
+	Detail string `json:"detail"`
+}
+
+// doctorReport represents the output of the doctor command.
+type doctorReport struct {
+	Bucket string        `json:"bucket"`
+	Checks []doctorCheck `json:"checks"`
+	// False if any check failed, which makes a rollback unsafe
+	Safe bool `json:"safe"`
+}
+
+func init() {
+	doctorOutput = doctorCmd.Flags().StringP("output", "o", textOutput,
+		"output format. One of 'text' or 'json'.")
+
+	rootCmd.AddCommand(doctorCmd)
+}
+
+var doctorCmd = &cobra.Command{
+	Use:   "doctor [bucket_url]",
+	Short: "Checks the protection of a bucket and the permissions needed to rollback",
+	Long: "" +
+		"Description:\n" +
+		"  Checks the settings of a bucket that limit what can be restored: versioning, soft delete, " +
+		"object lock and retention, lifecycle rules and replication. Also checks if the current credentials " +
+		"are allowed to list, copy and delete objects, without making changes to the bucket. " +
+		"Exits with an error if a rollback would be unsafe.",
+	Example:      doctorExamples,
+	Args:         cobra.MaximumNArgs(1),
+	RunE:         doctorEntryPoint,
+	SilenceUsage: true,
+}
+
+func doctorEntryPoint(cmd *cobra.Command, args []string) error {
+	binfo, err := bucketURLFromArgs(args)
+	if err != nil {
+		return err
+	}
+
+	if err := checkOutputFormat(*doctorOutput); err != nil {
+		return err
+	}
+
+	var checks []doctorCheck
+	switch binfo.Type {
+	case "s3":
+		checks, err = doctorAWS(*profileFlag, binfo.BucketName, binfo.Prefix)
+	case "gs":
+		checks, err = doctorGCP(*keyFileFlag, binfo.BucketName)
+	}
+	if err != nil {
+		return fmt.Errorf("error performing doctor command: %v", err)
+	}
+
+	report := doctorReport{
+		Bucket: fmt.Sprintf("%s://%s", binfo.Type, binfo.BucketName),
+		Checks: checks,
+		Safe:   true,
+	}
+	for _, c := range checks {
+		if c.Status == checkFailed {
+			report.Safe = false
+		}
+	}
+
+	if *doctorOutput == jsonOutput {
+		if err := printJSON(report); err != nil {
+			return err
+		}
+	} else {
+		printDoctorReport(report)
+	}
+
+	if !report.Safe {
+		return fmt.Errorf("a rollback of %s is unsafe, see the failed checks", report.Bucket)
+	}
+	return nil
+}
+
+// unknownCheck returns the result of a check that couldn't be made.
+func unknownCheck(name string, err error) doctorCheck {
+	return doctorCheck{Name: name, Status: checkUnknown, Detail: err.Error()}
+}
+
+func printDoctorReport(report doctorReport) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+
+	fmt.Fprintf(w, "Check\tStatus\tDetail\n")
+	for _, c := range report.Checks {
+		fmt.Fprintf(w, "%s\t%s\t%s\n", c.Name, c.Status, c.Detail)
+	}
+
+	w.Flush()
+}
@@ -0,0 +1,162 @@
+// Copyright 2021 VILT Group
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package appcmds
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/viltgroup/bucket-restore/internal/brestore/awsrestore"
+)
+
+func doctorAWS(profile string, bucketName string, path string) ([]doctorCheck, error) {
+	client, err := awsrestore.GetS3Client(profile)
+	if err != nil {
+		return nil, fmt.Errorf("unable to get s3 client for profile '%v': %w", profile, err)
+	}
+
+	checks := []doctorCheck{
+		versioningCheckAWS(client, bucketName),
+		objectLockCheckAWS(client, bucketName),
+		lifecycleCheckAWS(client, bucketName),
+		replicationCheckAWS(client, bucketName),
+	}
+
+	return append(checks, permissionChecksAWS(client, bucketName, path)...), nil
+}
+
+func versioningCheckAWS(client *s3.S3, bucketName string) doctorCheck {
+	name := "Versioning"
+
+	status, err := awsrestore.VersioningStatus(client, bucketName)
+	if err != nil {
+		return unknownCheck(name, err)
+	}
+
+	switch status {
+	case s3.BucketVersioningStatusEnabled:
+		return doctorCheck{Name: name, Status: checkOK, Detail: "enabled"}
+	case s3.BucketVersioningStatusSuspended:
+		return doctorCheck{Name: name, Status: checkFailed, Detail: "suspended. Overwritten and deleted objects " +
+			"are not kept, so the changes made by a rollback can't be undone. Run 'brestore protect' to enable it"}
+	default:
+		return doctorCheck{Name: name, Status: checkFailed,
+			Detail: "not enabled. There is no history to rollback to. Run 'brestore protect' to enable it"}
+	}
+}
+
+func objectLockCheckAWS(client *s3.S3, bucketName string) doctorCheck {
+	name := "Object Lock"
+
+	conf, err := awsrestore.ObjectLockConfigurationOf(client, bucketName)
+	if err != nil {
+		return unknownCheck(name, err)
+	}
+
+	if conf == nil || aws.StringValue(conf.ObjectLockEnabled) != s3.ObjectLockEnabledEnabled {
+		return doctorCheck{Name: name, Status: checkOK, Detail: "not enabled"}
+	}
+
+	detail := "enabled, without default retention"
+	if conf.Rule != nil && conf.Rule.DefaultRetention != nil {
+		r := conf.Rule.DefaultRetention
+		period := fmt.Sprintf("%d days", aws.Int64Value(r.Days))
+		if r.Years != nil {
+			period = fmt.Sprintf("%d years", aws.Int64Value(r.Years))
+		}
+		detail = fmt.Sprintf("enabled, default retention: %s for %s", aws.StringValue(r.Mode), period)
+	}
+
+	return doctorCheck{Name: name, Status: checkOK, Detail: detail + ". Locked versions can't be pruned"}
+}
+
+func lifecycleCheckAWS(client *s3.S3, bucketName string) doctorCheck {
+	name := "Lifecycle"
+
+	lc, err := awsrestore.LifecycleOf(client, bucketName)
+	if err != nil {
+		return unknownCheck(name, err)
+	}
+
+	rules := lc.NoncurrentRules()
+	if len(rules) == 0 {
+		return doctorCheck{Name: name, Status: checkOK, Detail: "no rules delete noncurrent versions"}
+	}
+
+	return doctorCheck{Name: name, Status: checkWarning, Detail: strings.Join(rules, "; ") +
+		". Older points in time may not be restorable"}
+}
+
+func replicationCheckAWS(client *s3.S3, bucketName string) doctorCheck {
+	name := "Replication"
+
+	conf, err := awsrestore.ReplicationOf(client, bucketName)
+	if err != nil {
+		return unknownCheck(name, err)
+	}
+
+	var destinations []string
+	if conf != nil {
+		for _, rule := range conf.Rules {
+			if aws.StringValue(rule.Status) != s3.ReplicationRuleStatusEnabled || rule.Destination == nil {
+				continue
+			}
+			destinations = append(destinations, aws.StringValue(rule.Destination.Bucket))
+		}
+	}
+
+	if len(destinations) == 0 {
+		return doctorCheck{Name: name, Status: checkOK, Detail: "not configured"}
+	}
+
+	return doctorCheck{Name: name, Status: checkWarning, Detail: fmt.Sprintf("replicates to %s. "+
+		"The changes made by a rollback are replicated too", strings.Join(destinations, ", "))}
+}
+
+// permissionChecksAWS checks if the credentials are allowed to list, copy and delete objects, without making
+// changes to the bucket.
+func permissionChecksAWS(client *s3.S3, bucketName string, path string) []doctorCheck {
+	listCheck := doctorCheck{Name: "List permission", Status: checkOK, Detail: "allowed"}
+	copyCheck := doctorCheck{Name: "Copy permission", Status: checkOK, Detail: "allowed"}
+	// deleting is the only way S3 has to check if deleting is allowed
+	deleteCheck := doctorCheck{Name: "Delete permission", Status: checkUnknown,
+		Detail: "can't be checked without deleting an object. A rollback needs s3:DeleteObject"}
+
+	allowed, sample, err := awsrestore.CanListVersions(client, bucketName, path)
+	switch {
+	case err != nil:
+		listCheck = unknownCheck(listCheck.Name, err)
+	case !allowed:
+		listCheck.Status, listCheck.Detail = checkFailed, "denied. A rollback needs s3:ListBucketVersions"
+	}
+
+	if sample == nil {
+		copyCheck.Status, copyCheck.Detail = checkUnknown, "no object versions to check with"
+		return []doctorCheck{listCheck, copyCheck, deleteCheck}
+	}
+
+	allowed, err = awsrestore.CanCopyVersion(client, bucketName, aws.StringValue(sample.Key),
+		aws.StringValue(sample.VersionId))
+	switch {
+	case err != nil:
+		copyCheck = unknownCheck(copyCheck.Name, err)
+	case !allowed:
+		copyCheck.Status, copyCheck.Detail = checkFailed, "denied. A rollback needs s3:GetObjectVersion and s3:PutObject"
+	}
+
+	return []doctorCheck{listCheck, copyCheck, deleteCheck}
+}
@@ -0,0 +1,187 @@
+// Copyright 2021 VILT Group
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package appcmds
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"cloud.google.com/go/storage"
+	"github.com/viltgroup/bucket-restore/internal/brestore/gcprestore"
+)
+
+func doctorGCP(keyfile string, bucketName string) ([]doctorCheck, error) {
+	client, ctx, err := gcprestore.GetStorageClientFromFile(keyfile)
+	if err != nil {
+		return nil, fmt.Errorf("getting storage client for key file '%v': %w", keyfile, err)
+	}
+
+	bucket := client.Bucket(bucketName)
+
+	attrs, err := bucket.Attrs(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("getting attributes of bucket: %w", err)
+	}
+
+	checks := []doctorCheck{
+		versioningCheckGCP(attrs),
+		softDeleteCheckGCP(keyfile, bucketName),
+		retentionCheckGCP(attrs),
+		lifecycleCheckGCP(attrs),
+		replicationCheckGCP(attrs),
+	}
+
+	return append(checks, permissionChecksGCP(ctx, bucket)...), nil
+}
+
+func versioningCheckGCP(attrs *storage.BucketAttrs) doctorCheck {
+	if !attrs.VersioningEnabled {
+		return doctorCheck{Name: "Versioning", Status: checkFailed,
+			Detail: "not enabled. There is no history to rollback to. Run 'brestore protect' to enable it"}
+	}
+	return doctorCheck{Name: "Versioning", Status: checkOK, Detail: "enabled"}
+}
+
+func softDeleteCheckGCP(keyfile string, bucketName string) doctorCheck {
+	name := "Soft delete"
+
+	client, ctx, err := gcprestore.GetHTTPClientFromFile(keyfile, storage.ScopeReadOnly)
+	if err != nil {
+		return unknownCheck(name, err)
+	}
+
+	policy, err := gcprestore.SoftDeletePolicyOf(ctx, client, bucketName)
+	if err != nil {
+		return unknownCheck(name, err)
+	}
+
+	if policy.RetentionDuration() == 0 {
+		return doctorCheck{Name: name, Status: checkOK, Detail: "disabled. Deleted generations can't be recovered"}
+	}
+	return doctorCheck{Name: name, Status: checkOK, Detail: fmt.Sprintf("deleted generations are kept for %s",
+		formatDays(policy.RetentionDuration()))}
+}
+
+func retentionCheckGCP(attrs *storage.BucketAttrs) doctorCheck {
+	name := "Retention"
+
+	var details []string
+	status := checkOK
+
+	if p := attrs.RetentionPolicy; p != nil {
+		locked := ""
+		if p.IsLocked {
+			locked = " (locked)"
+		}
+		details = append(details, fmt.Sprintf("retention policy of %s%s. Objects newer than that can't be "+
+			"deleted or overwritten by a rollback", formatDays(p.RetentionPeriod), locked))
+		status = checkWarning
+	}
+	if attrs.DefaultEventBasedHold {
+		details = append(details, "new objects get an event-based hold, which blocks a later rollback from "+
+			"deleting or overwriting them")
+		status = checkWarning
+	}
+
+	if len(details) == 0 {
+		return doctorCheck{Name: name, Status: checkOK, Detail: "no retention policy or default hold"}
+	}
+	return doctorCheck{Name: name, Status: status, Detail: strings.Join(details, "; ")}
+}
+
+func lifecycleCheckGCP(attrs *storage.BucketAttrs) doctorCheck {
+	name := "Lifecycle"
+
+	rules := gcprestore.Lifecycle{Lifecycle: attrs.Lifecycle}.ArchivedRules()
+	if len(rules) == 0 {
+		return doctorCheck{Name: name, Status: checkOK, Detail: "no rules delete noncurrent generations"}
+	}
+
+	return doctorCheck{Name: name, Status: checkWarning, Detail: "noncurrent generations are deleted by " +
+		strings.Join(rules, "; ") + ". Older points in time may not be restorable"}
+}
+
+func replicationCheckGCP(attrs *storage.BucketAttrs) doctorCheck {
+	return doctorCheck{Name: "Replication", Status: checkOK,
+		Detail: fmt.Sprintf("%s location %s", attrs.LocationType, attrs.Location)}
+}
+
+// permissionChecksGCP checks if the credentials are allowed to list, copy, delete and update objects.
+func permissionChecksGCP(ctx context.Context, bucket *storage.BucketHandle) []doctorCheck {
+	checks := []struct {
+		name        string
+		permissions []string
+	}{
+		{"List permission", []string{"storage.objects.list"}},
+		// reading the ACL of a generation to copy it needs storage.objects.getIamPolicy
+		{"Copy permission", []string{"storage.objects.get", "storage.objects.create", "storage.objects.getIamPolicy"}},
+		{"Delete permission", []string{"storage.objects.delete"}},
+		// restoring metadata changed in place updates the live generation
+		{"Metadata permission", []string{"storage.objects.update"}},
+	}
+
+	var all []string
+	for _, c := range checks {
+		all = append(all, c.permissions...)
+	}
+
+	var res []doctorCheck
+
+	granted, err := bucket.IAM().TestPermissions(ctx, all)
+	if err != nil {
+		for _, c := range checks {
+			res = append(res, unknownCheck(c.name, fmt.Errorf("testing permissions: %w", err)))
+		}
+		return res
+	}
+
+	for _, c := range checks {
+		var missing []string
+		for _, p := range c.permissions {
+			if !containsPermission(granted, p) {
+				missing = append(missing, p)
+			}
+		}
+
+		if len(missing) == 0 {
+			res = append(res, doctorCheck{Name: c.name, Status: checkOK, Detail: "allowed"})
+		} else {
+			res = append(res, doctorCheck{Name: c.name, Status: checkFailed,
+				Detail: "denied. A rollback needs " + strings.Join(missing, ", ")})
+		}
+	}
+
+	return res
+}
+
+func containsPermission(permissions []string, p string) bool {
+	for _, granted := range permissions {
+		if granted == p {
+			return true
+		}
+	}
+	return false
+}
+
+// formatDays formats a duration in days, if it's a whole number of days.
+func formatDays(d time.Duration) string {
+	day := 24 * time.Hour
+	if d%day == 0 {
+		return fmt.Sprintf("%d days", d/day)
+	}
+	return d.String()
+}
@@ -0,0 +1,128 @@
+// Copyright 2021 VILT Group
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package appcmds
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/viltgroup/bucket-restore/internal/brestore"
+)
+
+var (
+	duDepthFlag *int
+	duOutput    *string
+)
+
+var duExamples = "" +
+	"  Show the storage used by current and noncurrent versions in a bucket:\n" +
+	"    brestore du s3://mybucket\n\n" +
+	"  Show the storage used inside a path, grouped by the first two directories, in JSON format:\n" +
+	"    brestore du gs://mybucket/path --depth 2 -o json"
+
+// duReport represents the output of the du command.
+type duReport struct {
+	Groups []brestore.StorageGroup `json:"groups"`
+	Total  brestore.StorageGroup   `json:"total"`
+}
+
+func init() {
+	duDepthFlag = duCmd.Flags().Int("depth", 1,
+		"group the objects by the first N segments of their path. 0 shows only the total.")
+	duOutput = duCmd.Flags().StringP("output", "o", textOutput,
+		"output format. One of 'text' or 'json'.")
+
+	rootCmd.AddCommand(duCmd)
+}
+
+var duCmd = &cobra.Command{
+	Use:   "du [bucket_url]",
+	Short: "Shows the storage used by current and noncurrent versions",
+	Long: "" +
+		"Description:\n" +
+		"  Adds up the number and size of the current versions, the noncurrent versions and the delete markers " +
+		"of the objects in a bucket, grouped by the first segments of their path. Also shows the oldest and " +
+		"newest points in time the objects of each group can be restored to. This shows how much keeping the " +
+		"history costs, to help tuning the lifecycle rules of the bucket. No changes are made to the bucket.",
+	Example:      duExamples,
+	Args:         cobra.MaximumNArgs(1),
+	RunE:         duEntryPoint,
+	SilenceUsage: true,
+}
+
+func duEntryPoint(cmd *cobra.Command, args []string) error {
+	binfo, err := bucketURLFromArgs(args)
+	if err != nil {
+		return err
+	}
+
+	if err := checkOutputFormat(*duOutput); err != nil {
+		return err
+	}
+
+	var usage *brestore.StorageUsage
+	switch binfo.Type {
+	case "s3":
+		usage, err = duAWS(*profileFlag, binfo.BucketName, binfo.Prefix, *duDepthFlag)
+	case "gs":
+		usage, err = duGCP(*keyFileFlag, binfo.BucketName, binfo.Prefix, *duDepthFlag)
+	}
+	if err != nil {
+		return fmt.Errorf("error performing du command: %v", err)
+	}
+
+	report := duReport{Groups: usage.Groups(), Total: usage.Total()}
+	// without grouping, the only group is the total
+	if *duDepthFlag <= 0 {
+		report.Groups = []brestore.StorageGroup{}
+	}
+
+	if *duOutput == jsonOutput {
+		return printJSON(report)
+	}
+
+	printStorageUsage(binfo, report)
+	return nil
+}
+
+func printStorageUsage(binfo brestore.BucketURLInfo, report duReport) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+
+	fmt.Fprintf(w, "Current\tCurrent size\tNoncurrent\tNoncurrent size\tDelete markers\tOldest\tNewest\tPath\n")
+
+	row := func(g brestore.StorageGroup, path string) {
+		fmt.Fprintf(w, "%d\t%s\t%d\t%s\t%d\t%s\t%s\t%s\n",
+			g.CurrentCount, brestore.ByteCountIECString(g.CurrentBytes),
+			g.NoncurrentCount, brestore.ByteCountIECString(g.NoncurrentBytes),
+			g.DeleteMarkers, formatRestorable(g.OldestRestorable), formatRestorable(g.NewestRestorable), path)
+	}
+
+	for _, g := range report.Groups {
+		row(g, fmt.Sprintf("%s://%s/%s", binfo.Type, binfo.BucketName, g.Prefix))
+	}
+	row(report.Total, "Total")
+
+	w.Flush()
+}
+
+func formatRestorable(t time.Time) string {
+	if t.IsZero() {
+		return "-"
+	}
+	return t.Format(time.RFC3339)
+}
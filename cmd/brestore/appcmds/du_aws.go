@@ -0,0 +1,50 @@
+// Copyright 2021 VILT Group
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package appcmds
+
+import (
+	"fmt"
+
+	"github.com/viltgroup/bucket-restore/internal/brestore"
+	"github.com/viltgroup/bucket-restore/internal/brestore/awsrestore"
+	"github.com/viltgroup/bucket-restore/internal/brestore/awsrestore/history/versions"
+)
+
+func duAWS(profile string, bucketName string, path string, depth int) (*brestore.StorageUsage, error) {
+	client, err := awsrestore.GetS3Client(profile)
+	if err != nil {
+		return nil, fmt.Errorf("unable to get s3 client for profile '%v': %w", profile, err)
+	}
+
+	vs, err := versions.OfPath(client, bucketName, path)
+	if err != nil {
+		return nil, fmt.Errorf("listing contents of bucket: %w", err)
+	}
+
+	usage := brestore.NewStorageUsage(depth)
+
+	for _, v := range vs {
+		switch {
+		case v.IsDeleteMarker:
+			usage.AddDeleteMarker(v.Key, v.LastModified)
+		case v.IsLatest:
+			usage.AddCurrent(v.Key, v.Size, v.LastModified)
+		default:
+			usage.AddNoncurrent(v.Key, v.Size, v.LastModified)
+		}
+	}
+
+	return usage, nil
+}
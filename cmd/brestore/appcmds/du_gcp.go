@@ -0,0 +1,47 @@
+// Copyright 2021 VILT Group
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package appcmds
+
+import (
+	"fmt"
+
+	"github.com/viltgroup/bucket-restore/internal/brestore"
+	"github.com/viltgroup/bucket-restore/internal/brestore/gcprestore"
+	gcp_generations "github.com/viltgroup/bucket-restore/internal/brestore/gcprestore/history/generations"
+)
+
+func duGCP(keyfile string, bucketName string, path string, depth int) (*brestore.StorageUsage, error) {
+	client, _, err := gcprestore.GetStorageClientFromFile(keyfile)
+	if err != nil {
+		return nil, fmt.Errorf("getting storage client for key file '%v': %w", keyfile, err)
+	}
+
+	gens, err := gcp_generations.OfPath(client.Bucket(bucketName), path)
+	if err != nil {
+		return nil, fmt.Errorf("listing contents of bucket: %w", err)
+	}
+
+	usage := brestore.NewStorageUsage(depth)
+
+	for _, g := range gens {
+		if g.Deleted.IsZero() {
+			usage.AddCurrent(g.Name, g.Size, g.Created)
+		} else {
+			usage.AddNoncurrent(g.Name, g.Size, g.Created)
+		}
+	}
+
+	return usage, nil
+}
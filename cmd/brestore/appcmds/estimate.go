@@ -0,0 +1,62 @@
+// Copyright 2021 VILT Group
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package appcmds
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/viltgroup/bucket-restore/internal/brestore"
+	"github.com/viltgroup/bucket-restore/internal/brestore/estimate"
+)
+
+// printEstimate prints the estimated cost and duration of a rollback.
+func printEstimate(e estimate.Estimate) {
+	fmt.Printf("Estimated requests:\n")
+	fmt.Printf("    %d list requests\n", e.ListRequests)
+	fmt.Printf("    %d copy requests\n", e.CopyRequests)
+	fmt.Printf("    %d multipart copy requests\n", e.MultipartCopyRequests)
+	fmt.Printf("    %d delete requests\n", e.DeleteRequests)
+	fmt.Printf("    %d metadata read requests\n", e.ReadRequests)
+	fmt.Printf("    %d other requests billed like copies\n", e.UpdateRequests)
+
+	classes := make([]string, 0, len(e.CopiedBytes))
+	for class := range e.CopiedBytes {
+		classes = append(classes, class)
+	}
+	sort.Strings(classes)
+
+	fmt.Printf("Bytes copied per storage class:\n")
+	if len(classes) == 0 {
+		fmt.Printf("    none\n")
+	}
+	for _, class := range classes {
+		fmt.Printf("    %s: %s\n", class, brestore.ByteCountIECString(e.CopiedBytes[class]))
+	}
+
+	fmt.Printf("Estimated cost:\n")
+	fmt.Printf("    Requests: %.4f %s\n", e.RequestsCost, e.Currency)
+	fmt.Printf("    Retrieval fees: %.4f %s\n", e.RetrievalCost, e.Currency)
+	fmt.Printf("    Early-deletion fees: %.4f %s "+
+		"(only charged if the replaced data is removed, e.g. the bucket has no versioning)\n",
+		e.EarlyDeletionCost, e.Currency)
+	fmt.Printf("    Total: %.4f %s\n", e.TotalCost(), e.Currency)
+	if len(e.UnknownClasses) > 0 {
+		fmt.Printf("    Storage classes without prices in the price table: %v\n", e.UnknownClasses)
+	}
+
+	fmt.Printf("Estimated duration: %v\n", e.Duration.Round(time.Second))
+}
@@ -0,0 +1,212 @@
+// Copyright 2021 VILT Group
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package appcmds
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/viltgroup/bucket-restore/internal/brestore"
+)
+
+var (
+	findChecksumFlag      *string
+	findMinSizeFlag       *string
+	findMaxSizeFlag       *string
+	findSinceFlag         *string
+	findUntilFlag         *string
+	findKeyFlag           *string
+	findDeleteMarkersFlag *string
+	findOutput            *string
+)
+
+var findExamples = "" +
+	"  Find every version of every object with a given MD5 checksum or ETag:\n" +
+	"    brestore find s3://mybucket --checksum 9e107d9d372bb6826bd81d3542a419d6\n\n" +
+	"  Find the versions larger than 1 GiB written inside a path during a day:\n" +
+	"    brestore find gs://mybucket/path --min-size 1GiB --since \"2021-02-21 00:00:00 +01:00\" " +
+	"--until \"2021-02-22 00:00:00 +01:00\"\n\n" +
+	"  Find the delete markers of the log files of any directory, in JSON format:\n" +
+	"    brestore find s3://mybucket --key \"**/*.log\" --delete-markers only -o json"
+
+// findEntry represents a version/generation found by the find command.
+type findEntry struct {
+	URL string `json:"url"`
+	Key string `json:"key"`
+	// Version id or generation
+	Version string `json:"version"`
+	// One of 'current', 'noncurrent' or 'delete marker'
+	Status   string    `json:"status"`
+	Size     int64     `json:"size"`
+	Time     time.Time `json:"time"`
+	Checksum string    `json:"checksum,omitempty"`
+	// Point in time that can be given to rollback --time to restore the key to this version
+	RestoreTime *time.Time `json:"restore_time,omitempty"`
+}
+
+func init() {
+	findChecksumFlag = findCmd.Flags().String("checksum", "",
+		"only find versions with this ETag or MD5 checksum, in hex or base64.")
+	findMinSizeFlag = findCmd.Flags().String("min-size", "",
+		"only find versions with at least this size. e.g: --min-size 100MB, --min-size 1GiB")
+	findMaxSizeFlag = findCmd.Flags().String("max-size", "",
+		"only find versions with at most this size. e.g: --max-size 512KiB")
+	findSinceFlag = findCmd.Flags().String("since", "",
+		"only find versions modified at or after this point in time. Accepts the same formats as --time.")
+	findUntilFlag = findCmd.Flags().String("until", "",
+		"only find versions modified before this point in time. Accepts the same formats as --time.")
+	findKeyFlag = findCmd.Flags().String("key", "",
+		"only find versions of keys matching this pattern. '*' matches any characters except '/', "+
+			"'**' matches any characters and '?' matches a single character except '/'. e.g: --key \"logs/**.gz\"")
+	findDeleteMarkersFlag = findCmd.Flags().String("delete-markers", "include",
+		"how to treat delete markers. One of 'include', 'exclude' or 'only'. "+
+			"Gcp storage has no delete markers, so 'only' finds nothing in gs buckets.")
+	findOutput = findCmd.Flags().StringP("output", "o", textOutput,
+		"output format. One of 'text' or 'json'.")
+
+	rootCmd.AddCommand(findCmd)
+}
+
+var findCmd = &cobra.Command{
+	Use:   "find [bucket_url]",
+	Short: "Searches the versions/generations of the objects in a bucket",
+	Long: "" +
+		"Description:\n" +
+		"  Searches all the versions/generations of the objects in a bucket, including current and noncurrent " +
+		"versions and delete markers, by checksum, size, modification time, key pattern and delete marker " +
+		"status. All criteria must match.\n\n" +
+		"  For each version found, shows a restore time: a point in time when the version was live, which can be " +
+		"given to the rollback command together with the object url to restore the object to that version. " +
+		"No changes are made to the bucket.",
+	Example:      findExamples,
+	Args:         cobra.MaximumNArgs(1),
+	RunE:         findEntryPoint,
+	SilenceUsage: true,
+}
+
+func findEntryPoint(cmd *cobra.Command, args []string) error {
+	binfo, err := bucketURLFromArgs(args)
+	if err != nil {
+		return err
+	}
+
+	if err := checkOutputFormat(*findOutput); err != nil {
+		return err
+	}
+
+	filter, err := findFilterFromFlags()
+	if err != nil {
+		return err
+	}
+
+	var entries []findEntry
+	switch binfo.Type {
+	case "s3":
+		entries, err = findAWS(*profileFlag, binfo.BucketName, binfo.Prefix, filter)
+	case "gs":
+		entries, err = findGCP(*keyFileFlag, binfo.BucketName, binfo.Prefix, filter)
+	}
+	if err != nil {
+		return fmt.Errorf("error performing find command: %v", err)
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].Key == entries[j].Key {
+			return entries[i].Time.Before(entries[j].Time)
+		}
+		return entries[i].Key < entries[j].Key
+	})
+
+	if *findOutput == jsonOutput {
+		return printJSON(entries)
+	}
+
+	printFind(entries)
+	return nil
+}
+
+func findFilterFromFlags() (brestore.VersionFilter, error) {
+	var err error
+	filter := brestore.VersionFilter{Checksum: *findChecksumFlag}
+
+	if filter.DeleteMarkers, err = brestore.ParseDeleteMarkerMode(*findDeleteMarkersFlag); err != nil {
+		return filter, err
+	}
+
+	if *findMinSizeFlag != "" {
+		if filter.MinSize, err = brestore.ParseByteCount(*findMinSizeFlag); err != nil {
+			return filter, fmt.Errorf("could not parse --min-size: %v", err)
+		}
+	}
+	if *findMaxSizeFlag != "" {
+		if filter.MaxSize, err = brestore.ParseByteCount(*findMaxSizeFlag); err != nil {
+			return filter, fmt.Errorf("could not parse --max-size: %v", err)
+		}
+	}
+
+	if *findSinceFlag != "" {
+		if filter.Since, err = brestore.ParseTimestamp(*findSinceFlag); err != nil {
+			return filter, fmt.Errorf("could not parse --since timestamp: %v", err)
+		}
+	}
+	if *findUntilFlag != "" {
+		if filter.Until, err = brestore.ParseTimestamp(*findUntilFlag); err != nil {
+			return filter, fmt.Errorf("could not parse --until timestamp: %v", err)
+		}
+	}
+
+	if *findKeyFlag != "" {
+		if filter.KeyPattern, err = brestore.NewKeyPattern(*findKeyFlag); err != nil {
+			return filter, err
+		}
+	}
+
+	return filter, nil
+}
+
+// restoreTimeOf returns the earliest point in time at which a version created at t is the state of its path.
+func restoreTimeOf(t time.Time) *time.Time {
+	res := t.Add(time.Nanosecond)
+	return &res
+}
+
+func printFind(entries []findEntry) {
+	if len(entries) == 0 {
+		fmt.Printf("No versions found.\n")
+		return
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "STATUS\tSIZE\tMODIFIED\tVERSION\tRESTORE TIME\tURL\n")
+	for _, e := range entries {
+		size, restoreTime := "-", "-"
+		if e.Status != "delete marker" {
+			size = brestore.ByteCountIECString(e.Size)
+		}
+		if e.RestoreTime != nil {
+			restoreTime = e.RestoreTime.Format(time.RFC3339Nano)
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n",
+			e.Status, size, e.Time.Format(time.RFC3339), e.Version, restoreTime, e.URL)
+	}
+	w.Flush()
+
+	fmt.Printf("\n%d versions found. To restore an object to one of them, use:\n"+
+		"    brestore rollback --bucket <url> --time <restore time>\n", len(entries))
+}
@@ -0,0 +1,76 @@
+// Copyright 2021 VILT Group
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package appcmds
+
+import (
+	"fmt"
+
+	"github.com/viltgroup/bucket-restore/internal/brestore"
+	"github.com/viltgroup/bucket-restore/internal/brestore/awsrestore"
+	"github.com/viltgroup/bucket-restore/internal/brestore/awsrestore/history/versions"
+)
+
+func findAWS(profile string, bucketName string, path string, filter brestore.VersionFilter) ([]findEntry, error) {
+	client, err := awsrestore.GetS3Client(profile)
+	if err != nil {
+		return nil, fmt.Errorf("unable to get s3 client for profile '%v': %w", profile, err)
+	}
+
+	vs, err := versions.OfPath(client, bucketName, path)
+	if err != nil {
+		return nil, fmt.Errorf("listing contents of bucket: %w", err)
+	}
+
+	var entries []findEntry
+
+	for _, v := range vs {
+		candidate := brestore.VersionCandidate{
+			Key:          v.Key,
+			Size:         v.Size,
+			Time:         v.LastModified,
+			DeleteMarker: v.IsDeleteMarker,
+			Checksums:    []string{v.ETag},
+		}
+		if !filter.Matches(candidate) {
+			continue
+		}
+
+		e := findEntry{
+			URL:      fmt.Sprintf("s3://%s/%s", bucketName, v.Key),
+			Key:      v.Key,
+			Version:  v.ID,
+			Size:     v.Size,
+			Time:     v.LastModified,
+			Checksum: v.ETag,
+		}
+
+		switch {
+		case v.IsDeleteMarker:
+			e.Status = "delete marker"
+		case v.IsLatest:
+			e.Status = "current"
+		default:
+			e.Status = "noncurrent"
+		}
+
+		if !v.IsDeleteMarker {
+			e.RestoreTime = restoreTimeOf(v.LastModified)
+		}
+
+		entries = append(entries, e)
+	}
+
+	return entries, nil
+}
@@ -0,0 +1,68 @@
+// Copyright 2021 VILT Group
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package appcmds
+
+import (
+	"fmt"
+
+	"github.com/viltgroup/bucket-restore/internal/brestore"
+	"github.com/viltgroup/bucket-restore/internal/brestore/gcprestore"
+	gcp_generations "github.com/viltgroup/bucket-restore/internal/brestore/gcprestore/history/generations"
+)
+
+func findGCP(keyfile string, bucketName string, path string, filter brestore.VersionFilter) ([]findEntry, error) {
+	client, _, err := gcprestore.GetStorageClientFromFile(keyfile)
+	if err != nil {
+		return nil, fmt.Errorf("getting storage client for key file '%v': %w", keyfile, err)
+	}
+
+	gens, err := gcp_generations.OfPath(client.Bucket(bucketName), path)
+	if err != nil {
+		return nil, fmt.Errorf("listing contents of bucket: %w", err)
+	}
+
+	var entries []findEntry
+
+	for _, g := range gens {
+		md5 := fmt.Sprintf("%x", g.MD5)
+		candidate := brestore.VersionCandidate{
+			Key:       g.Name,
+			Size:      g.Size,
+			Time:      g.Created,
+			Checksums: []string{md5},
+		}
+		if !filter.Matches(candidate) {
+			continue
+		}
+
+		status := "current"
+		if !g.Deleted.IsZero() {
+			status = "noncurrent"
+		}
+
+		entries = append(entries, findEntry{
+			URL:         fmt.Sprintf("gs://%s/%s", bucketName, g.Name),
+			Key:         g.Name,
+			Version:     fmt.Sprintf("#%d", g.Generation),
+			Status:      status,
+			Size:        g.Size,
+			Time:        g.Created,
+			Checksum:    md5,
+			RestoreTime: restoreTimeOf(g.Created),
+		})
+	}
+
+	return entries, nil
+}
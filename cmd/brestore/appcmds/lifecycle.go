@@ -0,0 +1,144 @@
+// Copyright 2021 VILT Group
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package appcmds
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// maxShownLifecycleWarnings is the maximum number of lifecycle warnings shown by a dry-run.
+const maxShownLifecycleWarnings = 10
+
+// maxShownUnrecoverable is the maximum number of unrecoverable objects listed by a dry-run.
+const maxShownUnrecoverable = 10
+
+// maxShownBlocked is the maximum number of blocked objects listed by a dry-run or a rollback.
+const maxShownBlocked = 10
+
+// blockedObject represents an object that a rollback can't change, and why.
+type blockedObject struct {
+	Key    string
+	Reason string
+}
+
+// lifecycleWarning represents a version needed or replaced by a rollback action
+// that will be permanently deleted by a lifecycle rule of the bucket.
+type lifecycleWarning struct {
+	// Key of the object
+	Key string
+	// Description of the version that will be deleted, e.g. "source version 3HL4kqtJ"
+	Subject string
+	// Lifecycle rule that deletes the version
+	Rule string
+	// When the version will be deleted
+	At time.Time
+}
+
+// String converts a lifecycleWarning to a string.
+// Implements the Stringer interface.
+func (w lifecycleWarning) String() string {
+	if w.At.Before(time.Now()) {
+		return fmt.Sprintf("%s of '%s' is already due for deletion by lifecycle rule '%s' (since %v)",
+			w.Subject, w.Key, w.Rule, w.At)
+	}
+	return fmt.Sprintf("%s of '%s' will be deleted by lifecycle rule '%s' at %v", w.Subject, w.Key, w.Rule, w.At)
+}
+
+// printUnreadableLifecycle warns that the lifecycle rules of the bucket couldn't be read, so objects whose
+// history they deleted can't be told apart from objects that didn't exist at the point in time.
+func printUnreadableLifecycle(err error) {
+	fmt.Printf("Lifecycle warning: the lifecycle rules of the bucket could not be read, so the rollback assumes "+
+		"they never delete noncurrent versions. Objects whose older versions were deleted by them may be "+
+		"treated as not existing at the point in time: %v\n\n", err)
+}
+
+// printLifecycleWarnings prints the warnings that expire soonest, and how many more there are.
+func printLifecycleWarnings(warnings []lifecycleWarning, dryRun bool) {
+	if len(warnings) == 0 {
+		return
+	}
+
+	sort.Slice(warnings, func(i, j int) bool {
+		return warnings[i].At.Before(warnings[j].At)
+	})
+
+	fmt.Printf("Lifecycle warnings: %d versions needed or replaced by the rollback will be deleted by lifecycle rules\n",
+		len(warnings))
+	for i, w := range warnings {
+		if i == maxShownLifecycleWarnings {
+			printMoreNotShown(len(warnings)-maxShownLifecycleWarnings, dryRun)
+			break
+		}
+		fmt.Printf("    %s\n", w)
+	}
+	fmt.Printf("\n")
+}
+
+// printUnrecoverable prints the objects left untouched by the rollback because their history expired.
+func printUnrecoverable(scheme string, bucketName string, keys []string) {
+	if len(keys) == 0 {
+		return
+	}
+
+	sort.Strings(keys)
+
+	fmt.Printf("Unrecoverable: %d objects may have versions at the point in time that were deleted by lifecycle "+
+		"rules or overwritten in place, and are left untouched. Use '--delete-expired-history' to delete them "+
+		"instead\n", len(keys))
+	for i, k := range keys {
+		if i == maxShownUnrecoverable {
+			fmt.Printf("    ... and %d more. Use '--dry-run-explain' to see all of them.\n",
+				len(keys)-maxShownUnrecoverable)
+			break
+		}
+		fmt.Printf("    %s://%s/%s\n", scheme, bucketName, k)
+	}
+	fmt.Printf("\n")
+}
+
+// printBlocked prints the objects left untouched by the rollback because a hold or retention policy
+// prevents changing them.
+func printBlocked(scheme string, bucketName string, blocked []blockedObject, dryRun bool) {
+	if len(blocked) == 0 {
+		return
+	}
+
+	sort.Slice(blocked, func(i, j int) bool {
+		return blocked[i].Key < blocked[j].Key
+	})
+
+	fmt.Printf("Blocked: %d objects can't be deleted or replaced because of holds or the retention policy of "+
+		"the bucket, and are left untouched\n", len(blocked))
+	for i, b := range blocked {
+		if i == maxShownBlocked {
+			printMoreNotShown(len(blocked)-maxShownBlocked, dryRun)
+			break
+		}
+		fmt.Printf("    %s://%s/%s (%s)\n", scheme, bucketName, b.Key, b.Reason)
+	}
+	fmt.Printf("\n")
+}
+
+// printMoreNotShown prints how many more entries of a list were left out. Only the dry-runs can show all
+// of them, so the hint to see them is left out during a rollback.
+func printMoreNotShown(n int, dryRun bool) {
+	if dryRun {
+		fmt.Printf("    ... and %d more. Use '--dry-run-explain' to see all of them.\n", n)
+		return
+	}
+	fmt.Printf("    ... and %d more.\n", n)
+}
@@ -0,0 +1,91 @@
+// Copyright 2021 VILT Group
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package appcmds
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/viltgroup/bucket-restore/internal/brestore/awsrestore"
+	"github.com/viltgroup/bucket-restore/internal/brestore/awsrestore/history"
+	"github.com/viltgroup/bucket-restore/internal/brestore/awsrestore/history/versions"
+)
+
+// lifecycleWarningsAWS checks if the version copied by a rollback action, or the version it replaces, will be
+// deleted by the lifecycle rules of the bucket. The versions must be sorted by ascending order of modification.
+func lifecycleWarningsAWS(lc awsrestore.Lifecycle, vs versions.Versions, action history.FileAction,
+	from history.PathState, to history.PathState, now time.Time) []lifecycleWarning {
+
+	var res []lifecycleWarning
+
+	if action.Action == history.NO_ACTION {
+		return res
+	}
+
+	if action.Action == history.CREATE {
+		for i, v := range vs {
+			if v.ID != to.Version.ID || i == len(vs)-1 {
+				continue
+			}
+			// a version becomes noncurrent when the next version is created
+			if at, rule, ok := lc.NoncurrentExpiry(v.Key, vs[i+1].LastModified); ok {
+				res = append(res, lifecycleWarning{
+					Key:     v.Key,
+					Subject: fmt.Sprintf("source version %s", v.ID),
+					Rule:    rule,
+					At:      at,
+				})
+			}
+		}
+	}
+
+	if from.PathStatus == history.EXISTS {
+		// the current version becomes noncurrent when the rollback replaces or deletes it
+		if at, rule, ok := lc.NoncurrentExpiry(from.Key, now); ok {
+			res = append(res, lifecycleWarning{
+				Key:     from.Key,
+				Subject: fmt.Sprintf("replaced version %s", from.Version.ID),
+				Rule:    rule,
+				At:      at,
+			})
+		}
+	}
+
+	return res
+}
+
+// historyHorizonAWS gets the lifecycle rules and the creation date of a bucket, which limit how far back the
+// history of its objects goes. With --delete-expired-history, the history of an object is never expired.
+// Reading the lifecycle rules may not be allowed, so without them the bucket is treated as having no expiry rules.
+func historyHorizonAWS(client *s3.S3, bucketName string, now time.Time) history.HistoryHorizon {
+	lc, err := awsrestore.LifecycleOf(client, bucketName)
+	if err != nil {
+		printUnreadableLifecycle(err)
+	}
+
+	h := history.HistoryHorizon{Lifecycle: lc}
+	if *deleteExpiredHistoryFlag {
+		return h
+	}
+	h.Now = now
+
+	// listing the buckets of the account may not be allowed, so the creation date is optional
+	if created, err := awsrestore.BucketCreationDate(client, bucketName); err == nil {
+		h.BucketCreated = created
+	}
+
+	return h
+}
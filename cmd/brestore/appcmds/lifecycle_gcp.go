@@ -0,0 +1,114 @@
+// Copyright 2021 VILT Group
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package appcmds
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"cloud.google.com/go/storage"
+	"github.com/viltgroup/bucket-restore/internal/brestore/gcprestore"
+	gcp_history "github.com/viltgroup/bucket-restore/internal/brestore/gcprestore/history"
+	gcp_generations "github.com/viltgroup/bucket-restore/internal/brestore/gcprestore/history/generations"
+)
+
+// lifecycleWarningsGCP checks if the generation copied by a rollback action, or the generation it replaces, will be
+// deleted by the lifecycle rules of the bucket, taking into account the generation created by the copy.
+// The generations must be sorted by ascending order of creation.
+func lifecycleWarningsGCP(lc gcprestore.Lifecycle, gens gcp_generations.Generations, action gcp_history.FileAction,
+	from gcp_history.PathState, to gcp_history.PathState, now time.Time) []lifecycleWarning {
+
+	var res []lifecycleWarning
+
+	// number of generations newer than the live one once the rollback replaces or deletes it
+	var newerThanReplaced int64
+
+	switch action.Action {
+	case gcp_history.NO_ACTION, gcp_history.UPDATE_METADATA:
+		// metadata updates don't create nor replace generations
+		return res
+	case gcp_history.CREATE:
+		// copies create a new generation, which is newer than all the others
+		newerThanReplaced = 1
+		for i, g := range gens {
+			if g.Generation != to.Generation {
+				continue
+			}
+			at, rule, ok := lc.ArchivedExpiry(gcprestore.ArchivedGeneration{
+				Created:         g.Created,
+				NoncurrentSince: g.Deleted,
+				CustomTime:      g.CustomTime,
+				StorageClass:    g.StorageClass,
+				NewerVersions:   int64(len(gens) - i),
+			})
+			if ok {
+				res = append(res, lifecycleWarning{
+					Key:     g.Name,
+					Subject: fmt.Sprintf("source generation #%d", g.Generation),
+					Rule:    rule,
+					At:      at,
+				})
+			}
+		}
+	}
+
+	if from.PathStatus == gcp_history.EXISTS {
+		for _, g := range gens {
+			if g.Generation != from.Generation {
+				continue
+			}
+			// the live generation becomes noncurrent when the rollback replaces or deletes it
+			at, rule, ok := lc.ArchivedExpiry(gcprestore.ArchivedGeneration{
+				Created:         g.Created,
+				NoncurrentSince: now,
+				CustomTime:      g.CustomTime,
+				StorageClass:    g.StorageClass,
+				NewerVersions:   newerThanReplaced,
+			})
+			if ok {
+				res = append(res, lifecycleWarning{
+					Key:     g.Name,
+					Subject: fmt.Sprintf("replaced generation #%d", g.Generation),
+					Rule:    rule,
+					At:      at,
+				})
+			}
+		}
+	}
+
+	return res
+}
+
+// historyHorizonGCP gets the lifecycle rules and the creation date of a bucket, which limit how far back the
+// history of its objects goes. With --delete-expired-history, the history of an object is never expired.
+// Reading the attributes of the bucket may not be allowed, so without them the bucket is treated as having
+// no expiry rules.
+func historyHorizonGCP(ctx context.Context, bucket *storage.BucketHandle, now time.Time) gcp_history.HistoryHorizon {
+	attrs, err := bucket.Attrs(ctx)
+	if err != nil {
+		printUnreadableLifecycle(fmt.Errorf("getting attributes of bucket: %w", err))
+		attrs = &storage.BucketAttrs{}
+	}
+
+	h := gcp_history.HistoryHorizon{Lifecycle: gcprestore.Lifecycle{Lifecycle: attrs.Lifecycle}}
+	if *deleteExpiredHistoryFlag {
+		return h
+	}
+	h.BucketCreated = attrs.Created
+	h.Now = now
+
+	return h
+}
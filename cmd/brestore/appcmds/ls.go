@@ -0,0 +1,139 @@
+// Copyright 2021 VILT Group
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package appcmds
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/viltgroup/bucket-restore/internal/brestore"
+)
+
+var (
+	lsRecursiveFlag *bool
+	lsLongFlag      *bool
+)
+
+var lsExamples = "" +
+	"  List the objects and directories inside a path as they were at a point in time:\n" +
+	"    brestore ls s3://mybucket/path/ --time \"February 21, 2021, 23:00:00 (UTC+01:00)\"\n\n" +
+	"  List all objects inside a path, recursively, with their size, version and modification date:\n" +
+	"    brestore ls gs://mybucket/path/ --time \"February 21, 2021, 23:00:00 (UTC+01:00)\" -r -l"
+
+func init() {
+	lsRecursiveFlag = lsCmd.Flags().BoolP("recursive", "r", false,
+		"list all objects inside the path, instead of grouping them by directory.")
+	lsLongFlag = lsCmd.Flags().BoolP("long", "l", false,
+		"show the size, version/generation and last modification date of each object.")
+
+	rootCmd.AddCommand(lsCmd)
+}
+
+var lsCmd = &cobra.Command{
+	Use:   "ls [bucket_url]",
+	Short: "Lists the objects that existed in a bucket at a point in time",
+	Long: "" +
+		"Description:\n" +
+		"  Lists the objects that existed in a bucket at the point in time given to the --time flag. " +
+		"If no time is given, the current objects are listed. No changes are made to the bucket.",
+	Example:      lsExamples,
+	Args:         cobra.MaximumNArgs(1),
+	RunE:         lsEntryPoint,
+	SilenceUsage: true,
+}
+
+// lsEntry represents an object that existed at a point in time.
+type lsEntry struct {
+	Key          string
+	Size         int64
+	Version      string
+	LastModified time.Time
+}
+
+func lsEntryPoint(cmd *cobra.Command, args []string) error {
+	binfo, err := bucketURLFromArgs(args)
+	if err != nil {
+		return err
+	}
+
+	ts := time.Now()
+	if *timestampFlag != "" {
+		ts, err = brestore.ParseTimestamp(*timestampFlag)
+		if err != nil {
+			return fmt.Errorf("could not parse timestamp: %v", err)
+		}
+	}
+
+	var entries []lsEntry
+	switch binfo.Type {
+	case "s3":
+		entries, err = lsAWS(*profileFlag, binfo.BucketName, binfo.Prefix, ts)
+	case "gs":
+		entries, err = lsGCP(*keyFileFlag, binfo.BucketName, binfo.Prefix, ts)
+	}
+	if err != nil {
+		return fmt.Errorf("error performing ls command: %v", err)
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Key < entries[j].Key
+	})
+
+	printLs(binfo, entries, *lsRecursiveFlag, *lsLongFlag)
+	return nil
+}
+
+// printLs prints the objects that existed at a point in time. If recursive is false, objects inside
+// directories of the listed path are grouped and shown as a single directory entry.
+func printLs(binfo brestore.BucketURLInfo, entries []lsEntry, recursive bool, long bool) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	defer w.Flush()
+
+	// the listed path works like a prefix, so the directories start at the last separator of the prefix
+	dirStart := strings.LastIndex(binfo.Prefix, "/") + 1
+	var lastDir string
+
+	for _, e := range entries {
+		if !recursive {
+			if i := strings.Index(e.Key[dirStart:], "/"); i >= 0 {
+				dir := e.Key[:dirStart+i+1]
+				if dir != lastDir {
+					if long {
+						fmt.Fprintf(w, "\t\t\tDIR\t%s://%s/%s\n", binfo.Type, binfo.BucketName, dir)
+					} else {
+						fmt.Fprintf(w, "%s://%s/%s\n", binfo.Type, binfo.BucketName, dir)
+					}
+					lastDir = dir
+				}
+				continue
+			}
+		}
+
+		if long {
+			fmt.Fprintf(w, "%s\t%s\t%s\t\t%s://%s/%s\n",
+				brestore.ByteCountIECString(e.Size),
+				e.LastModified.Format(time.RFC3339),
+				e.Version,
+				binfo.Type, binfo.BucketName, e.Key)
+		} else {
+			fmt.Fprintf(w, "%s://%s/%s\n", binfo.Type, binfo.BucketName, e.Key)
+		}
+	}
+}
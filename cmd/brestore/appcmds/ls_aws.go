@@ -0,0 +1,55 @@
+// Copyright 2021 VILT Group
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package appcmds
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/viltgroup/bucket-restore/internal/brestore/awsrestore"
+	"github.com/viltgroup/bucket-restore/internal/brestore/awsrestore/history"
+	"github.com/viltgroup/bucket-restore/internal/brestore/awsrestore/history/versions"
+)
+
+func lsAWS(profile string, bucketName string, path string, timestamp time.Time) ([]lsEntry, error) {
+	client, err := awsrestore.GetS3Client(profile)
+	if err != nil {
+		return nil, fmt.Errorf("unable to get s3 client for profile '%v': %w", profile, err)
+	}
+
+	allVersions, err := versions.OfPathByName(client, bucketName, path)
+	if err != nil {
+		return nil, fmt.Errorf("listing contents of bucket: %w", err)
+	}
+
+	var entries []lsEntry
+
+	for key, vs := range allVersions {
+		vs.SortByLastModifiedAsc()
+		state := history.StateAtTime(vs, timestamp)
+		if state.PathStatus != history.EXISTS {
+			continue
+		}
+
+		entries = append(entries, lsEntry{
+			Key:          key,
+			Size:         state.Size,
+			Version:      state.Version.ID,
+			LastModified: state.LastModified,
+		})
+	}
+
+	return entries, nil
+}
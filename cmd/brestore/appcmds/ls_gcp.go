@@ -0,0 +1,55 @@
+// Copyright 2021 VILT Group
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package appcmds
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/viltgroup/bucket-restore/internal/brestore/gcprestore"
+	gcp_history "github.com/viltgroup/bucket-restore/internal/brestore/gcprestore/history"
+	gcp_generations "github.com/viltgroup/bucket-restore/internal/brestore/gcprestore/history/generations"
+)
+
+func lsGCP(keyfile string, bucketName string, path string, timestamp time.Time) ([]lsEntry, error) {
+	client, _, err := gcprestore.GetStorageClientFromFile(keyfile)
+	if err != nil {
+		return nil, fmt.Errorf("getting storage client for key file '%v': %w", keyfile, err)
+	}
+
+	allGens, err := gcp_generations.OfPathByName(client.Bucket(bucketName), path)
+	if err != nil {
+		return nil, fmt.Errorf("listing contents of bucket: %w", err)
+	}
+
+	var entries []lsEntry
+
+	for name, gens := range allGens {
+		gens.SortByCreatedDateAsc()
+		state := gcp_history.StateAtTime(gens, timestamp)
+		if state.PathStatus != gcp_history.EXISTS {
+			continue
+		}
+
+		entries = append(entries, lsEntry{
+			Key:          name,
+			Size:         state.Size,
+			Version:      fmt.Sprintf("#%d", state.Generation),
+			LastModified: state.Created,
+		})
+	}
+
+	return entries, nil
+}
@@ -0,0 +1,112 @@
+// Copyright 2021 VILT Group
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package appcmds
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"cloud.google.com/go/storage"
+	gcp_history "github.com/viltgroup/bucket-restore/internal/brestore/gcprestore/history"
+)
+
+// maxShownUnknownMetadata is the maximum number of objects with unrecoverable metadata listed by a dry-run
+// or a rollback.
+const maxShownUnknownMetadata = 10
+
+// printUnknownMetadata prints the objects whose metadata changed after the point in time, but whose metadata
+// at the point in time is not known from their generations or the metadata snapshots.
+func printUnknownMetadata(scheme string, bucketName string, names []string, dryRun bool) {
+	if len(names) == 0 {
+		return
+	}
+
+	sort.Strings(names)
+
+	fmt.Printf("Metadata changed: the metadata of %d objects changed after the point in time, but no earlier "+
+		"generation or snapshot has the metadata they had then. Their metadata is left untouched. "+
+		"Take snapshots with the snapshot command to be able to restore it\n", len(names))
+	for i, name := range names {
+		if i == maxShownUnknownMetadata {
+			printMoreNotShown(len(names)-maxShownUnknownMetadata, dryRun)
+			break
+		}
+		fmt.Printf("    %s://%s/%s\n", scheme, bucketName, name)
+	}
+	fmt.Printf("\n")
+}
+
+// doUpdateMetadataGCP sets the metadata of the live generation of an object, if neither its generation nor its
+// metadata changed since the rollback was planned.
+func doUpdateMetadataGCP(ctx context.Context, bucket *storage.BucketHandle,
+	action gcp_history.FileAction) (*storage.ObjectAttrs, error) {
+
+	obj := bucket.Object(action.Source.Name)
+	conditions := storage.Conditions{
+		GenerationMatch:     action.GenerationPreCondition,
+		MetagenerationMatch: action.MetagenerationPreCondition,
+	}
+	update := action.Metadata.ToUpdate()
+
+	// updates merge custom metadata, so keys to delete are only deleted by clearing all of them first
+	if action.Metadata.RemovesKeys(action.CurrentMetadata) && len(action.Metadata.Metadata) > 0 {
+		cleared := update
+		cleared.Metadata = map[string]string{}
+		attrs, err := obj.If(conditions).Update(ctx, cleared)
+		if err != nil {
+			return nil, err
+		}
+		conditions.MetagenerationMatch = attrs.Metageneration
+
+		// the two updates are not atomic, so a failure here leaves the object without custom metadata
+		attrs, err = obj.If(conditions).Update(ctx, update)
+		if err != nil {
+			return nil, fmt.Errorf("setting metadata after clearing its custom metadata, which is left empty. "+
+				"Run the rollback again to restore it: %w", err)
+		}
+		return attrs, nil
+	}
+
+	return obj.If(conditions).Update(ctx, update)
+}
+
+// formatMetadataChangesGCP lists the metadata fields a metadata update changes.
+func formatMetadataChangesGCP(action gcp_history.FileAction) string {
+	from, to := action.CurrentMetadata, *action.Metadata
+	var changed []string
+
+	if from.ContentType != to.ContentType {
+		changed = append(changed, "content type")
+	}
+	if from.ContentLanguage != to.ContentLanguage {
+		changed = append(changed, "content language")
+	}
+	if from.ContentEncoding != to.ContentEncoding {
+		changed = append(changed, "content encoding")
+	}
+	if from.ContentDisposition != to.ContentDisposition {
+		changed = append(changed, "content disposition")
+	}
+	if from.CacheControl != to.CacheControl {
+		changed = append(changed, "cache control")
+	}
+	if !(gcp_history.ObjectMetadata{Metadata: from.Metadata}).Equal(gcp_history.ObjectMetadata{Metadata: to.Metadata}) {
+		changed = append(changed, "custom metadata")
+	}
+
+	return strings.Join(changed, ", ")
+}
@@ -0,0 +1,112 @@
+// Copyright 2021 VILT Group
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package appcmds
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/viltgroup/bucket-restore/internal/brestore/awsrestore"
+	"github.com/viltgroup/bucket-restore/internal/brestore/awsrestore/history"
+	"github.com/viltgroup/bucket-restore/internal/brestore/awsrestore/history/versions"
+)
+
+// maxShownNullVersionWarnings is the maximum number of null version warnings shown by a dry-run.
+const maxShownNullVersionWarnings = 10
+
+// nullVersionWarning represents a rollback action whose outcome depends on the null version of an object,
+// which S3 overwrites in place instead of keeping the previous content.
+type nullVersionWarning struct {
+	// Key of the object
+	Key string
+	// Description of the problem
+	Message string
+}
+
+// String converts a nullVersionWarning to a string.
+// Implements the Stringer interface.
+func (w nullVersionWarning) String() string {
+	return fmt.Sprintf("'%s': %s", w.Key, w.Message)
+}
+
+// versioningStatusAWS gets the versioning status of a bucket. Without permission to read it,
+// versioning is assumed to be enabled.
+func versioningStatusAWS(client *s3.S3, bucketName string) string {
+	status, err := awsrestore.VersioningStatus(client, bucketName)
+	if err != nil {
+		return s3.BucketVersioningStatusEnabled
+	}
+	return status
+}
+
+// nullVersionWarningsAWS checks if the state restored by a rollback action may be wrong because of an
+// overwritten null version, and if the action permanently replaces a null version, which happens to every
+// write while versioning is suspended or was never enabled.
+func nullVersionWarningsAWS(vs versions.Versions, action history.FileAction, to history.PathState,
+	versioning string) []nullVersionWarning {
+
+	var res []nullVersionWarning
+
+	if to.NullOverwritten {
+		res = append(res, nullVersionWarning{Key: to.Key, Message: "the object may have had a different content " +
+			"at the point in time, written to its null version and overwritten in place since then"})
+	}
+
+	if action.Action == history.NO_ACTION || versioning == s3.BucketVersioningStatusEnabled {
+		return res
+	}
+
+	for _, v := range vs {
+		if v.IsNull() {
+			res = append(res, nullVersionWarning{Key: v.Key, Message: fmt.Sprintf("the null version (%v) will be "+
+				"permanently replaced by the rollback, because versioning is %s, so it can't be undone",
+				v.LastModified, formatVersioningStatus(versioning))})
+			break
+		}
+	}
+
+	return res
+}
+
+func formatVersioningStatus(status string) string {
+	if status == "" {
+		return "not enabled"
+	}
+	return "suspended"
+}
+
+// printNullVersionWarnings prints the first null version warnings by key, and how many more there are.
+func printNullVersionWarnings(warnings []nullVersionWarning) {
+	if len(warnings) == 0 {
+		return
+	}
+
+	sort.Slice(warnings, func(i, j int) bool {
+		return warnings[i].Key < warnings[j].Key
+	})
+
+	fmt.Printf("Null version warnings: %d warnings about null versions, which S3 overwrites in place\n",
+		len(warnings))
+	for i, w := range warnings {
+		if i == maxShownNullVersionWarnings {
+			fmt.Printf("    ... and %d more. Use '--dry-run-explain' to see all of them.\n",
+				len(warnings)-maxShownNullVersionWarnings)
+			break
+		}
+		fmt.Printf("    %s\n", w)
+	}
+	fmt.Printf("\n")
+}
@@ -0,0 +1,55 @@
+// Copyright 2021 VILT Group
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package appcmds
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+// Output formats supported by the commands with machine-readable output.
+const (
+	textOutput = "text"
+	jsonOutput = "json"
+)
+
+// checkOutputFormat returns an error if the given output format is not supported.
+func checkOutputFormat(format string) error {
+	if format != textOutput && format != jsonOutput {
+		return fmt.Errorf("unsupported output format '%s'. Supported formats are '%s' and '%s'.",
+			format, textOutput, jsonOutput)
+	}
+	return nil
+}
+
+// printJSON prints a value as indented JSON to the standard output.
+func printJSON(v interface{}) error {
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(v); err != nil {
+		return fmt.Errorf("writing json output: %w", err)
+	}
+	return nil
+}
+
+// saveJSONToFile writes a value as indented JSON to a new file.
+func saveJSONToFile(filename string, v interface{}) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filename, append(data, '\n'), 0600)
+}
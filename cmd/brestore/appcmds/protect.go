@@ -0,0 +1,147 @@
+// Copyright 2021 VILT Group
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package appcmds
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/viltgroup/bucket-restore/internal/brestore"
+)
+
+var (
+	softDeleteRetentionFlag *string
+	noncurrentDaysFlag      *int
+	protectBackupFlag       *string
+	protectApplyFlag        *bool
+)
+
+var protectExamples = "" +
+	"  Preview the changes needed to enable versioning in an AWS S3 bucket:\n" +
+	"    brestore protect s3://mybucket\n\n" +
+	"  Enable versioning and add a lifecycle rule that deletes noncurrent versions after 90 days:\n" +
+	"    brestore protect s3://mybucket --noncurrent-days 90 --apply\n\n" +
+	"  Enable versioning in a gcp bucket and keep soft-deleted objects for 30 days:\n" +
+	"    brestore protect gs://mybucket --soft-delete-retention 30d --apply"
+
+// protectChange represents a change to the configuration of a bucket made by the protect command.
+type protectChange struct {
+	Setting string
+	From    string
+	To      string
+}
+
+func init() {
+	softDeleteRetentionFlag = protectCmd.Flags().String("soft-delete-retention", "",
+		"gcp storage only. How long soft-deleted objects are kept, between 7d and 90d, or 0 to disable "+
+			"soft delete. If not given, the soft delete policy is not changed. e.g: --soft-delete-retention 30d")
+	noncurrentDaysFlag = protectCmd.Flags().Int("noncurrent-days", 0,
+		"if greater than 0, adds a lifecycle rule that deletes noncurrent versions/generations after N days, "+
+			"limiting the cost of keeping the history. Points in time older than that can't be restored.")
+	protectBackupFlag = protectCmd.Flags().String("backup-file", "",
+		"file where the configuration of the bucket before the changes is saved, in JSON format. "+
+			"Defaults to 'protect-<bucket>-<time>.json' in the current directory.")
+	protectApplyFlag = protectCmd.Flags().Bool("apply", false,
+		"make the changes shown in the preview. Without this flag, nothing is changed.")
+
+	rootCmd.AddCommand(protectCmd)
+}
+
+var protectCmd = &cobra.Command{
+	Use:   "protect [bucket_url]",
+	Short: "Enables versioning and soft delete in a bucket",
+	Long: "" +
+		"Description:\n" +
+		"  Changes the configuration of a bucket so its objects can be restored to a point in time: enables " +
+		"versioning, and optionally sets the soft delete retention (gcp storage) and adds a lifecycle rule " +
+		"that deletes old noncurrent versions/generations.\n\n" +
+		"  A preview of the changes is always shown. Nothing is changed unless --apply is given, in which " +
+		"case the previous configuration is saved to a file first.",
+	Example:      protectExamples,
+	Args:         cobra.MaximumNArgs(1),
+	RunE:         protectEntryPoint,
+	SilenceUsage: true,
+}
+
+func protectEntryPoint(cmd *cobra.Command, args []string) error {
+	binfo, err := bucketURLFromArgs(args)
+	if err != nil {
+		return err
+	}
+
+	if *noncurrentDaysFlag < 0 {
+		return fmt.Errorf("--noncurrent-days can't be negative")
+	}
+
+	var softDeleteRetention *time.Duration
+	if *softDeleteRetentionFlag != "" {
+		if binfo.Type != "gs" {
+			return fmt.Errorf("--soft-delete-retention is only supported for gcp storage buckets")
+		}
+		d, err := brestore.ParseDuration(*softDeleteRetentionFlag)
+		if err != nil {
+			return fmt.Errorf("could not parse --soft-delete-retention: %v", err)
+		}
+		softDeleteRetention = &d
+	}
+
+	backupFile := *protectBackupFlag
+	if backupFile == "" {
+		backupFile = fmt.Sprintf("protect-%s-%s.json", binfo.BucketName, time.Now().Format("20060102T150405"))
+	}
+
+	switch binfo.Type {
+	case "s3":
+		err = protectAWS(*profileFlag, binfo.BucketName, int64(*noncurrentDaysFlag), backupFile, *protectApplyFlag)
+	case "gs":
+		err = protectGCP(*keyFileFlag, binfo.BucketName, softDeleteRetention, int64(*noncurrentDaysFlag),
+			backupFile, *protectApplyFlag)
+	}
+	if err != nil {
+		return fmt.Errorf("error performing protect command: %v", err)
+	}
+
+	return nil
+}
+
+// printProtectPlan prints the changes to make to a bucket. Returns false if there are none.
+func printProtectPlan(bucketURL string, changes []protectChange, apply bool) bool {
+	if len(changes) == 0 {
+		fmt.Printf("%s is already protected, nothing to change.\n", bucketURL)
+		return false
+	}
+
+	fmt.Printf("Changes to %s:\n", bucketURL)
+	for _, c := range changes {
+		fmt.Printf("    %s: %s -> %s\n", c.Setting, c.From, c.To)
+	}
+	fmt.Printf("\n")
+
+	if !apply {
+		fmt.Printf("Nothing was changed. Run again with --apply to make the changes.\n")
+		return false
+	}
+	return true
+}
+
+// saveProtectBackup saves the configuration of a bucket before the changes made by the protect command.
+func saveProtectBackup(backupFile string, backup interface{}) error {
+	if err := saveJSONToFile(backupFile, backup); err != nil {
+		return fmt.Errorf("saving previous configuration to '%s': %w", backupFile, err)
+	}
+	fmt.Printf("Previous configuration saved to '%s'\n", backupFile)
+	return nil
+}
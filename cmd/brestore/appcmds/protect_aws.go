@@ -0,0 +1,102 @@
+// Copyright 2021 VILT Group
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package appcmds
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/viltgroup/bucket-restore/internal/brestore/awsrestore"
+)
+
+// protectBackupAWS represents the configuration of an AWS S3 bucket before the changes made by the protect command.
+type protectBackupAWS struct {
+	Bucket         string              `json:"bucket"`
+	SavedAt        time.Time           `json:"savedAt"`
+	Versioning     string              `json:"versioning"`
+	LifecycleRules []*s3.LifecycleRule `json:"lifecycleRules"`
+}
+
+func protectAWS(profile string, bucketName string, noncurrentDays int64, backupFile string, apply bool) error {
+	client, err := awsrestore.GetS3Client(profile)
+	if err != nil {
+		return fmt.Errorf("unable to get s3 client for profile '%v': %w", profile, err)
+	}
+
+	versioning, err := awsrestore.VersioningStatus(client, bucketName)
+	if err != nil {
+		return err
+	}
+	lc, err := awsrestore.LifecycleOf(client, bucketName)
+	if err != nil {
+		return err
+	}
+
+	var changes []protectChange
+
+	enableVersioning := versioning != s3.BucketVersioningStatusEnabled
+	if enableVersioning {
+		from := versioning
+		if from == "" {
+			from = "Disabled"
+		}
+		changes = append(changes, protectChange{Setting: "Versioning", From: from, To: s3.BucketVersioningStatusEnabled})
+	}
+
+	var rules []*s3.LifecycleRule
+	changeLifecycle := false
+	if noncurrentDays > 0 {
+		rules, changeLifecycle = lc.WithNoncurrentExpiration(noncurrentDays)
+	}
+	if changeLifecycle {
+		from := "no noncurrent expiration"
+		if current := lc.NoncurrentRules(); len(current) > 0 {
+			from = strings.Join(current, "; ")
+		}
+		changes = append(changes, protectChange{Setting: "Lifecycle", From: from,
+			To: fmt.Sprintf("rule '%s' deletes noncurrent versions after %d days",
+				awsrestore.NoncurrentExpirationRuleID, noncurrentDays)})
+	}
+
+	if !printProtectPlan(fmt.Sprintf("s3://%s", bucketName), changes, apply) {
+		return nil
+	}
+
+	err = saveProtectBackup(backupFile, protectBackupAWS{
+		Bucket:         bucketName,
+		SavedAt:        time.Now(),
+		Versioning:     versioning,
+		LifecycleRules: lc.Rules,
+	})
+	if err != nil {
+		return err
+	}
+
+	if enableVersioning {
+		if err := awsrestore.EnableVersioning(client, bucketName); err != nil {
+			return err
+		}
+	}
+	if changeLifecycle {
+		if err := awsrestore.PutLifecycle(client, bucketName, rules); err != nil {
+			return err
+		}
+	}
+
+	fmt.Printf("%d changes made to s3://%s\n", len(changes), bucketName)
+	return nil
+}
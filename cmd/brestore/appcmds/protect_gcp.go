@@ -0,0 +1,137 @@
+// Copyright 2021 VILT Group
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package appcmds
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"cloud.google.com/go/storage"
+	"github.com/viltgroup/bucket-restore/internal/brestore/gcprestore"
+)
+
+// protectBackupGCP represents the configuration of a gcp bucket before the changes made by the protect command.
+type protectBackupGCP struct {
+	Bucket            string                      `json:"bucket"`
+	SavedAt           time.Time                   `json:"savedAt"`
+	Metageneration    int64                       `json:"metageneration"`
+	VersioningEnabled bool                        `json:"versioningEnabled"`
+	SoftDeletePolicy  gcprestore.SoftDeletePolicy `json:"softDeletePolicy"`
+	Lifecycle         storage.Lifecycle           `json:"lifecycle"`
+}
+
+func protectGCP(keyfile string, bucketName string, softDeleteRetention *time.Duration, noncurrentDays int64,
+	backupFile string, apply bool) error {
+
+	if softDeleteRetention != nil {
+		if err := gcprestore.CheckSoftDeleteRetention(*softDeleteRetention); err != nil {
+			return err
+		}
+	}
+
+	client, ctx, err := gcprestore.GetStorageClientFromFile(keyfile)
+	if err != nil {
+		return fmt.Errorf("getting storage client for key file '%v': %w", keyfile, err)
+	}
+	httpClient, _, err := gcprestore.GetHTTPClientFromFile(keyfile, storage.ScopeFullControl)
+	if err != nil {
+		return fmt.Errorf("getting http client for key file '%v': %w", keyfile, err)
+	}
+
+	bucket := client.Bucket(bucketName)
+
+	attrs, err := bucket.Attrs(ctx)
+	if err != nil {
+		return fmt.Errorf("getting attributes of bucket: %w", err)
+	}
+	softDelete, err := gcprestore.SoftDeletePolicyOf(ctx, httpClient, bucketName)
+	if err != nil {
+		return err
+	}
+
+	var changes []protectChange
+	var update storage.BucketAttrsToUpdate
+	changeAttrs := false
+
+	if !attrs.VersioningEnabled {
+		changes = append(changes, protectChange{Setting: "Versioning", From: "Disabled", To: "Enabled"})
+		update.VersioningEnabled = true
+		changeAttrs = true
+	}
+
+	changeSoftDelete := softDeleteRetention != nil && *softDeleteRetention != softDelete.RetentionDuration()
+	if changeSoftDelete {
+		changes = append(changes, protectChange{Setting: "Soft delete retention",
+			From: formatRetention(softDelete.RetentionDuration()), To: formatRetention(*softDeleteRetention)})
+	}
+
+	lc := gcprestore.Lifecycle{Lifecycle: attrs.Lifecycle}
+	if noncurrentDays > 0 {
+		if rules, changed := lc.WithArchivedDeletion(noncurrentDays); changed {
+			from := "no noncurrent deletion"
+			if current := lc.ArchivedRules(); len(current) > 0 {
+				from = strings.Join(current, "; ")
+			}
+			changes = append(changes, protectChange{Setting: "Lifecycle", From: from,
+				To: fmt.Sprintf("add a rule that deletes noncurrent generations after %d days", noncurrentDays)})
+			update.Lifecycle = &rules
+			changeAttrs = true
+		}
+	}
+
+	if !printProtectPlan(fmt.Sprintf("gs://%s", bucketName), changes, apply) {
+		return nil
+	}
+
+	err = saveProtectBackup(backupFile, protectBackupGCP{
+		Bucket:            bucketName,
+		SavedAt:           time.Now(),
+		Metageneration:    attrs.MetaGeneration,
+		VersioningEnabled: attrs.VersioningEnabled,
+		SoftDeletePolicy:  softDelete,
+		Lifecycle:         attrs.Lifecycle,
+	})
+	if err != nil {
+		return err
+	}
+
+	// the bucket must not have changed since the previous configuration was saved
+	metageneration := attrs.MetaGeneration
+	if changeAttrs {
+		updated, err := bucket.If(storage.BucketConditions{MetagenerationMatch: metageneration}).Update(ctx, update)
+		if err != nil {
+			return fmt.Errorf("updating bucket: %w", err)
+		}
+		metageneration = updated.MetaGeneration
+	}
+	if changeSoftDelete {
+		_, err := gcprestore.SetSoftDeletePolicy(ctx, httpClient, bucketName, *softDeleteRetention, metageneration)
+		if err != nil {
+			return err
+		}
+	}
+
+	fmt.Printf("%d changes made to gs://%s\n", len(changes), bucketName)
+	return nil
+}
+
+// formatRetention formats the retention duration of a soft delete policy.
+func formatRetention(d time.Duration) string {
+	if d == 0 {
+		return "disabled"
+	}
+	return formatDays(d)
+}
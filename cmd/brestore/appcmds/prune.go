@@ -0,0 +1,366 @@
+// Copyright 2021 VILT Group
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package appcmds
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/viltgroup/bucket-restore/internal/brestore"
+	"github.com/viltgroup/bucket-restore/internal/brestore/retention"
+)
+
+var (
+	keepLastFlag    *int
+	keepWithinFlag  *string
+	keepHourlyFlag  *int
+	keepDailyFlag   *int
+	keepWeeklyFlag  *int
+	keepMonthlyFlag *int
+	keepYearlyFlag  *int
+	pruneApplyFlag  *bool
+
+	pruneConcurrencyFlag *int
+)
+
+var pruneExamples = "" +
+	"  Preview which noncurrent versions would be removed, keeping the 3 newest versions of each object, all\n" +
+	"  versions of the last 30 days and one version per day for 14 days:\n" +
+	"    brestore prune s3://mybucket --keep-last 3 --keep-within 30d --keep-daily 14\n\n" +
+	"  Remove them:\n" +
+	"    brestore prune s3://mybucket --keep-last 3 --keep-within 30d --keep-daily 14 --apply\n\n" +
+	"  Keep one version per month for a year inside a path of a gcp bucket:\n" +
+	"    brestore prune gs://mybucket/path --keep-monthly 12 --apply"
+
+// pruneVersion represents a version/generation of an object considered by the prune command.
+type pruneVersion struct {
+	Key string
+	// Version id or generation
+	ID           string
+	Time         time.Time
+	Size         int64
+	DeleteMarker bool
+	// Whether this is the current version of the object. Current versions are never removed
+	Current bool
+	// Rules of the retention policy that keep the version
+	Reasons []string
+	// Whether the version will be removed
+	Remove bool
+	// Why the version can't be removed, if it's not kept by the policy but is retained by the bucket
+	Blocked string
+}
+
+func init() {
+	keepLastFlag = pruneCmd.Flags().Int("keep-last", 0,
+		"keep the N newest versions of each object.")
+	keepWithinFlag = pruneCmd.Flags().String("keep-within", "",
+		"keep all versions created within this duration before now. e.g: --keep-within 30d, --keep-within 2w, "+
+			"--keep-within 12h")
+	keepHourlyFlag = pruneCmd.Flags().Int("keep-hourly", 0,
+		"keep the newest version of each of the last N hours with versions, for each object.")
+	keepDailyFlag = pruneCmd.Flags().Int("keep-daily", 0,
+		"keep the newest version of each of the last N days with versions, for each object.")
+	keepWeeklyFlag = pruneCmd.Flags().Int("keep-weekly", 0,
+		"keep the newest version of each of the last N weeks with versions, for each object.")
+	keepMonthlyFlag = pruneCmd.Flags().Int("keep-monthly", 0,
+		"keep the newest version of each of the last N months with versions, for each object.")
+	keepYearlyFlag = pruneCmd.Flags().Int("keep-yearly", 0,
+		"keep the newest version of each of the last N years with versions, for each object.")
+	pruneApplyFlag = pruneCmd.Flags().Bool("apply", false,
+		"remove the versions shown in the preview. Without this flag, nothing is removed.")
+	pruneConcurrencyFlag = pruneCmd.Flags().IntP("max-concurrency", "c", 32,
+		"AWS S3 only. Maximum number of versions whose Object Lock retention and legal hold are read at the "+
+			"same time for the preview of a bucket with S3 Object Lock.")
+
+	rootCmd.AddCommand(pruneCmd)
+}
+
+var pruneCmd = &cobra.Command{
+	Use:   "prune [bucket_url]",
+	Short: "Removes noncurrent versions according to retention rules",
+	Long: "" +
+		"Description:\n" +
+		"  Removes the noncurrent versions/generations of each object that are not kept by any of the --keep-* " +
+		"rules, and the delete markers left without any version to hide. The rules are applied to the versions " +
+		"of each object separately, from the newest to the oldest, and the current version counts towards them.\n\n" +
+		"  The current version of an object is never removed. Buckets with S3 Object Lock or a gcp storage " +
+		"retention policy are refused.\n\n" +
+		"  A preview of the versions to remove is always shown. Nothing is removed unless --apply is given.",
+	Example:      pruneExamples,
+	Args:         cobra.MaximumNArgs(1),
+	RunE:         pruneEntryPoint,
+	SilenceUsage: true,
+}
+
+func pruneEntryPoint(cmd *cobra.Command, args []string) error {
+	binfo, err := bucketURLFromArgs(args)
+	if err != nil {
+		return err
+	}
+
+	policy := retention.Policy{
+		Last:    *keepLastFlag,
+		Hourly:  *keepHourlyFlag,
+		Daily:   *keepDailyFlag,
+		Weekly:  *keepWeeklyFlag,
+		Monthly: *keepMonthlyFlag,
+		Yearly:  *keepYearlyFlag,
+	}
+	if *keepWithinFlag != "" {
+		policy.Within, err = brestore.ParseDuration(*keepWithinFlag)
+		if err != nil {
+			return fmt.Errorf("could not parse --keep-within: %v", err)
+		}
+	}
+
+	if policy.Empty() {
+		return fmt.Errorf("No retention rules specified. Specify which versions to keep with the --keep-* flags.")
+	}
+
+	switch binfo.Type {
+	case "s3":
+		err = pruneAWS(*profileFlag, binfo.BucketName, binfo.Prefix, policy, *pruneApplyFlag)
+	case "gs":
+		err = pruneGCP(*keyFileFlag, binfo.BucketName, binfo.Prefix, policy, *pruneApplyFlag)
+	}
+	if err != nil {
+		return fmt.Errorf("error performing prune command: %v", err)
+	}
+
+	return nil
+}
+
+// planPrune decides which versions of an object to remove. The versions must belong to the same object
+// and be sorted from the newest to the oldest. Delete markers are only removed when none of the object
+// versions is kept, since they hide nothing then.
+func planPrune(vs []pruneVersion, policy retention.Policy, now time.Time) {
+	var objects []*pruneVersion
+	var times []time.Time
+	for i := range vs {
+		if !vs[i].DeleteMarker {
+			objects = append(objects, &vs[i])
+			times = append(times, vs[i].Time)
+		}
+	}
+
+	kept := false
+	for i, d := range policy.Apply(times, now) {
+		v := objects[i]
+		v.Reasons = d.Reasons
+		if v.Current {
+			v.Reasons = append(v.Reasons, "current")
+		}
+		v.Remove = len(v.Reasons) == 0
+		kept = kept || !v.Remove
+	}
+
+	for i := range vs {
+		if vs[i].DeleteMarker {
+			vs[i].Remove = !kept
+		}
+	}
+}
+
+// blockPruneVersions keeps the versions marked for removal that the bucket doesn't allow to remove, with
+// the reason given by blockedBy, and the delete markers of their objects, which still hide them.
+// blockedBy is called for up to maxConcurrency versions at the same time.
+func blockPruneVersions(vs []pruneVersion, maxConcurrency int, blockedBy func(v pruneVersion) string) {
+	var candidates []int
+	for i := range vs {
+		if vs[i].Remove && !vs[i].DeleteMarker {
+			candidates = append(candidates, i)
+		}
+	}
+
+	// each version has its own slot, so the results need no lock
+	reasons := make([]string, len(vs))
+	var wg sync.WaitGroup
+	indexChan := make(chan int)
+
+	if maxConcurrency > len(candidates) {
+		maxConcurrency = len(candidates)
+	} else if maxConcurrency < 1 {
+		maxConcurrency = 1
+	}
+	wg.Add(maxConcurrency)
+	for i := 0; i < maxConcurrency; i++ {
+		go func() {
+			for i := range indexChan {
+				reasons[i] = blockedBy(vs[i])
+			}
+			wg.Done()
+		}()
+	}
+
+	for _, i := range candidates {
+		indexChan <- i
+	}
+	close(indexChan)
+	wg.Wait()
+
+	blockedKeys := map[string]bool{}
+	for _, i := range candidates {
+		if reason := reasons[i]; reason != "" {
+			vs[i].Remove = false
+			vs[i].Blocked = reason
+			blockedKeys[vs[i].Key] = true
+		}
+	}
+
+	for i := range vs {
+		if vs[i].DeleteMarker && blockedKeys[vs[i].Key] {
+			vs[i].Remove = false
+		}
+	}
+}
+
+// printPrunePlan prints the versions that will be removed and a summary of the plan.
+func printPrunePlan(scheme string, bucketName string, vs []pruneVersion) {
+	var removed, markers, kept, blocked, freed int64
+
+	for _, v := range vs {
+		if v.Blocked != "" {
+			blocked++
+			fmt.Printf("keep %s://%s/%s (%s, %v): %s\n", scheme, bucketName, v.Key, v.ID, v.Time, v.Blocked)
+		}
+		if !v.Remove {
+			kept++
+			continue
+		}
+
+		if v.DeleteMarker {
+			markers++
+			fmt.Printf("remove delete marker %s://%s/%s (%s, %v)\n", scheme, bucketName, v.Key, v.ID, v.Time)
+		} else {
+			removed++
+			freed += v.Size
+			fmt.Printf("remove %s://%s/%s (%s, %v, %s)\n",
+				scheme, bucketName, v.Key, v.ID, v.Time, brestore.ByteCountIECString(v.Size))
+		}
+	}
+
+	fmt.Printf("\nPrune plan:\n")
+	fmt.Printf("    %d versions to remove, freeing %s\n", removed, brestore.ByteCountIECString(freed))
+	fmt.Printf("    %d delete markers to remove\n", markers)
+	fmt.Printf("    %d versions and delete markers kept\n", kept)
+	if blocked > 0 {
+		fmt.Printf("    %d of them kept only because the bucket retains them\n", blocked)
+	}
+}
+
+// removePrunedVersions removes the versions marked for removal using the remove function.
+func removePrunedVersions(vs []pruneVersion, remove func(v pruneVersion) error) error {
+	removed, skipped, errors := runPruneRemovals(vs, remove)
+
+	fmt.Printf("\n%d versions removed, %d errors\n", removed, len(errors))
+	if skipped > 0 {
+		fmt.Printf("%d delete markers kept because some of the versions they hide were not removed\n", skipped)
+	}
+
+	if len(errors) > 0 {
+		if err := saveErrorsToFile("errors.log", errors); err != nil {
+			return fmt.Errorf("writing errors to 'error.log': %w", err)
+		}
+		fmt.Printf("" +
+			"There were errors running the prune command.\n" +
+			"A file 'errors.log' was created with the error details\n")
+	}
+
+	return nil
+}
+
+// runPruneRemovals removes the versions marked for removal using the remove function, and returns how many
+// were removed, how many delete markers were kept and the errors. Delete markers are removed after all the
+// versions of their objects, and kept if any of those fails, so that objects stay deleted when the prune is
+// interrupted or fails.
+func runPruneRemovals(vs []pruneVersion, remove func(v pruneVersion) error) (int, int, []error) {
+	var objectVersions, markers []pruneVersion
+	for _, v := range vs {
+		if !v.Remove || v.Current {
+			continue
+		}
+		if v.DeleteMarker {
+			markers = append(markers, v)
+		} else {
+			objectVersions = append(objectVersions, v)
+		}
+	}
+
+	toRemove := append(objectVersions, markers...)
+	failedKeys := map[string]bool{}
+	var removed, skipped int
+	var errors []error
+
+	for i, v := range toRemove {
+		if v.DeleteMarker && failedKeys[v.Key] {
+			skipped++
+			fmt.Printf("[%d/%d] Kept delete marker of '%s' (%s)\n", i+1, len(toRemove), v.Key, v.ID)
+			continue
+		}
+		if err := remove(v); err != nil {
+			errors = append(errors, err)
+			failedKeys[v.Key] = true
+			fmt.Printf("[%d/%d] Error removing '%s' (%s): %v\n", i+1, len(toRemove), v.Key, v.ID, err)
+			continue
+		}
+		removed++
+		fmt.Printf("[%d/%d] Removed '%s' (%s)\n", i+1, len(toRemove), v.Key, v.ID)
+	}
+
+	return removed, skipped, errors
+}
+
+// confirmPrune prints how to apply the plan when it's only a preview, and returns whether to remove the versions.
+func confirmPrune(apply bool, vs []pruneVersion) bool {
+	for _, v := range vs {
+		if v.Remove {
+			if !apply {
+				fmt.Printf("\nThis is a preview. Nothing was removed. To remove these versions, add the flag --apply.\n")
+			}
+			return apply
+		}
+	}
+
+	fmt.Printf("\nNothing to remove.\n")
+	return false
+}
+
+// pruneKeys returns the keys of the versions grouped by key, sorted.
+func pruneKeys(byKey map[string][]pruneVersion) []string {
+	keys := make([]string, 0, len(byKey))
+	for key := range byKey {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// planPruneAll plans each object and returns all their versions, sorted by key.
+// The versions of each object must be sorted from the newest to the oldest.
+func planPruneAll(byKey map[string][]pruneVersion, policy retention.Policy) []pruneVersion {
+	now := time.Now()
+
+	var res []pruneVersion
+	for _, key := range pruneKeys(byKey) {
+		vs := byKey[key]
+		planPrune(vs, policy, now)
+		res = append(res, vs...)
+	}
+
+	return res
+}
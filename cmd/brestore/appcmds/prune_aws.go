@@ -0,0 +1,88 @@
+// Copyright 2021 VILT Group
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package appcmds
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/viltgroup/bucket-restore/internal/brestore/awsrestore"
+	"github.com/viltgroup/bucket-restore/internal/brestore/awsrestore/history/versions"
+	"github.com/viltgroup/bucket-restore/internal/brestore/retention"
+)
+
+func pruneAWS(profile string, bucketName string, path string, policy retention.Policy, apply bool) error {
+	client, err := awsrestore.GetS3Client(profile)
+	if err != nil {
+		return fmt.Errorf("unable to get s3 client for profile '%v': %w", profile, err)
+	}
+
+	locked, err := awsrestore.ObjectLockEnabled(client, bucketName)
+	if err != nil {
+		return err
+	}
+
+	allVersions, err := versions.OfPathByName(client, bucketName, path)
+	if err != nil {
+		return fmt.Errorf("listing contents of bucket: %w", err)
+	}
+
+	byKey := make(map[string][]pruneVersion)
+	for key, vs := range allVersions {
+		vs.SortByLastModifiedDesc()
+		for _, v := range vs {
+			byKey[key] = append(byKey[key], pruneVersion{
+				Key:          v.Key,
+				ID:           v.ID,
+				Time:         v.LastModified,
+				Size:         v.Size,
+				DeleteMarker: v.IsDeleteMarker,
+				Current:      v.IsLatest && !v.IsDeleteMarker,
+			})
+		}
+	}
+
+	plan := planPruneAll(byKey, policy)
+	if locked {
+		now := time.Now()
+		blockPruneVersions(plan, *pruneConcurrencyFlag, func(v pruneVersion) string {
+			lock, err := awsrestore.ObjectLockOf(client, bucketName, v.Key, v.ID)
+			if err != nil {
+				return fmt.Sprintf("Object Lock unknown: %v", err)
+			}
+			return lock.BlockedBy(now)
+		})
+	}
+	printPrunePlan("s3", bucketName, plan)
+
+	if locked {
+		return fmt.Errorf("bucket '%s' has S3 Object Lock enabled. Refusing to remove versions", bucketName)
+	}
+
+	if !confirmPrune(apply, plan) {
+		return nil
+	}
+
+	return removePrunedVersions(plan, func(v pruneVersion) error {
+		_, err := client.DeleteObject(&s3.DeleteObjectInput{
+			Bucket:    aws.String(bucketName),
+			Key:       aws.String(v.Key),
+			VersionId: aws.String(v.ID),
+		})
+		return err
+	})
+}
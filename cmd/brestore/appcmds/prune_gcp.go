@@ -0,0 +1,76 @@
+// Copyright 2021 VILT Group
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package appcmds
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/viltgroup/bucket-restore/internal/brestore/gcprestore"
+	gcp_generations "github.com/viltgroup/bucket-restore/internal/brestore/gcprestore/history/generations"
+	"github.com/viltgroup/bucket-restore/internal/brestore/retention"
+)
+
+func pruneGCP(keyfile string, bucketName string, path string, policy retention.Policy, apply bool) error {
+	client, ctx, err := gcprestore.GetStorageClientFromFile(keyfile)
+	if err != nil {
+		return fmt.Errorf("getting storage client for key file '%v': %w", keyfile, err)
+	}
+
+	bucket := client.Bucket(bucketName)
+
+	attrs, err := bucket.Attrs(ctx)
+	if err != nil {
+		return fmt.Errorf("getting attributes of bucket '%s': %w", bucketName, err)
+	}
+	if attrs.RetentionPolicy != nil {
+		return fmt.Errorf("bucket '%s' has a retention policy of %v. Refusing to remove generations",
+			bucketName, attrs.RetentionPolicy.RetentionPeriod)
+	}
+
+	allGens, err := gcp_generations.OfPathByName(bucket, path)
+	if err != nil {
+		return fmt.Errorf("listing contents of bucket: %w", err)
+	}
+
+	byKey := make(map[string][]pruneVersion)
+	for name, gens := range allGens {
+		gens.SortByCreatedDateDesc()
+		for _, g := range gens {
+			byKey[name] = append(byKey[name], pruneVersion{
+				Key:     g.Name,
+				ID:      strconv.FormatInt(g.Generation, 10),
+				Time:    g.Created,
+				Size:    g.Size,
+				Current: g.Deleted.IsZero(),
+			})
+		}
+	}
+
+	plan := planPruneAll(byKey, policy)
+	printPrunePlan("gs", bucketName, plan)
+
+	if !confirmPrune(apply, plan) {
+		return nil
+	}
+
+	return removePrunedVersions(plan, func(v pruneVersion) error {
+		generation, err := strconv.ParseInt(v.ID, 10, 64)
+		if err != nil {
+			return err
+		}
+		return bucket.Object(v.Key).Generation(generation).Delete(ctx)
+	})
+}
@@ -0,0 +1,100 @@
+// Copyright 2021 VILT Group
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package appcmds
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+func TestRunPruneRemovals(t *testing.T) {
+	// versions of each object sorted from the newest to the oldest, as planned by planPruneAll
+	plan := []pruneVersion{
+		{Key: "a", ID: "d1", DeleteMarker: true, Remove: true},
+		{Key: "a", ID: "a2", Remove: true},
+		{Key: "a", ID: "a1", Remove: true},
+		{Key: "b", ID: "d2", DeleteMarker: true, Remove: true},
+		{Key: "b", ID: "b1", Remove: true},
+		{Key: "c", ID: "c2", Current: true},
+		{Key: "c", ID: "c1", Remove: true},
+	}
+
+	var calls []string
+	removed, skipped, errors := runPruneRemovals(plan, func(v pruneVersion) error {
+		calls = append(calls, v.ID)
+		if v.ID == "a1" {
+			return fmt.Errorf("access denied")
+		}
+		return nil
+	})
+
+	// delete markers go last, and the one of 'a' is kept because one of the versions it hides failed
+	expectedCalls := []string{"a2", "a1", "b1", "c1", "d2"}
+	if !reflect.DeepEqual(calls, expectedCalls) {
+		t.Fatalf("expected removals %v | got: %v", expectedCalls, calls)
+	}
+	if removed != 4 || skipped != 1 || len(errors) != 1 {
+		t.Fatalf("expected 4 removed, 1 skipped and 1 error | got: %d removed, %d skipped and %d errors",
+			removed, skipped, len(errors))
+	}
+}
+
+func TestBlockPruneVersions(t *testing.T) {
+	var tests = []struct {
+		versions       []pruneVersion
+		locked         map[string]bool
+		maxConcurrency int
+		expected       []bool
+	}{
+		// the delete marker of an object with a blocked version still hides it
+		{
+			[]pruneVersion{
+				{Key: "a", ID: "m", DeleteMarker: true, Remove: true},
+				{Key: "a", ID: "1", Remove: true},
+				{Key: "b", ID: "2", Remove: true},
+				{Key: "b", ID: "3"},
+			},
+			map[string]bool{"1": true},
+			4,
+			[]bool{false, false, true, false},
+		},
+		{
+			[]pruneVersion{
+				{Key: "a", ID: "1", Remove: true},
+				{Key: "a", ID: "2", Remove: true},
+				{Key: "a", ID: "3", Remove: true},
+			},
+			map[string]bool{"2": true},
+			0,
+			[]bool{true, false, true},
+		},
+		{nil, nil, 8, nil},
+	}
+
+	for i, test := range tests {
+		blockPruneVersions(test.versions, test.maxConcurrency, func(v pruneVersion) string {
+			if test.locked[v.ID] {
+				return "legal hold"
+			}
+			return ""
+		})
+		for j, v := range test.versions {
+			if v.Remove != test.expected[j] {
+				t.Fatalf("test %d: expected remove of version %s %v | got: %v", i, v.ID, test.expected[j], v.Remove)
+			}
+		}
+	}
+}
@@ -17,18 +17,59 @@ package appcmds
 import (
 	"fmt"
 	"github.com/viltgroup/bucket-restore/internal/brestore"
+	"github.com/viltgroup/bucket-restore/internal/brestore/awsrestore"
+	"github.com/viltgroup/bucket-restore/internal/brestore/encryption"
+	"github.com/viltgroup/bucket-restore/internal/brestore/gcprestore"
+	"strings"
 	"time"
 
 	"github.com/spf13/cobra"
+	gcp_history "github.com/viltgroup/bucket-restore/internal/brestore/gcprestore/history"
 )
 
 var (
-	dryRunExplainFlag  *bool
-	dryRunFlag         *bool
-	quietFlag          *bool
-	maxConcurrencyFlag *int
+	dryRunExplainFlag   *bool
+	dryRunFlag          *bool
+	quietFlag           *bool
+	maxConcurrencyFlag  *int
+	summaryDepthFlag    *int
+	estimateFlag        *bool
+	priceTableFlag      *string
+	beforeChangeSetFlag *string
+	changeSetGapFlag    *time.Duration
+
+	deleteExpiredHistoryFlag *bool
+	checksumAlgorithmFlag    *string
+	partSizeFlag             *string
+	partConcurrencyFlag      *int
+	storageClassFlag         *string
+	encryptionKeysFlag       *string
+	sourceCustomerKeyFlag    *string
+	destCustomerKeyFlag      *string
+	kmsKeyFlag               *string
+	thawTierFlag             *string
+	thawDaysFlag             *int64
+	thawStateFileFlag        *string
+	objectLockModeFlag       *string
+	objectLockUntilFlag      *string
+	metadataSnapshotFlag     *[]string
 )
 
+// copyPartSize is the part size given to --part-size, in bytes, or 0 to choose it from the object size.
+var copyPartSize int64
+
+// copyStorageClass is the storage class given to --storage-class, or empty to keep the class of each version.
+var copyStorageClass string
+
+// copyObjectLock is the Object Lock retention given to --object-lock-mode and --object-lock-retain-until.
+var copyObjectLock awsrestore.ObjectLock
+
+// restoreKeys maps objects to the encryption keys given to --encryption-keys and the customer and KMS key flags.
+var restoreKeys encryption.KeyMap
+
+// restoreSnapshots are the metadata snapshots given to --metadata-snapshot.
+var restoreSnapshots gcp_history.MetadataSnapshots
+
 var rollbackExamples = "" +
 	"  Rollback all objects in the AWS S3 bucket 'mybucket' to the specific point in time\n" +
 	"    brestore rollback --bucket s3://mybucket --time \"February 21, 2021, 23:00:00 (UTC+01:00)\"\n\n" +
@@ -37,7 +78,9 @@ var rollbackExamples = "" +
 	"  Rollback a specific object or all objects under a path inside the bucket:\n" +
 	"    brestore rollback --bucket gs://mybucket/path/to/dir_or_file --time \"February 21, 2021, 23:00:00 (UTC+01:00)\"\n\n" +
 	"  To perform a dry run, add the flag --dry-run-explain or --dry-run to the rollback command:\n" +
-	"    brestore versions --bucket gs://mybucket/ --time \"February 21, 2021, 23:00:00 (UTC+01:00)\" --dry-run-explain"
+	"    brestore versions --bucket gs://mybucket/ --time \"February 21, 2021, 23:00:00 (UTC+01:00)\" --dry-run-explain\n\n" +
+	"  Rollback a bucket to the point in time just before a change set listed by the changesets command:\n" +
+	"    brestore rollback --bucket s3://mybucket --before-changeset 3f2a9c01be"
 
 func init() {
 
@@ -52,6 +95,71 @@ func init() {
 		"show less output.")
 	maxConcurrencyFlag = rollbackCmd.PersistentFlags().IntP("max-concurrency", "c", 32,
 		"controls the maximum number of rollback actions that can run concurrently.")
+	summaryDepthFlag = rollbackCmd.PersistentFlags().Int("summary-depth", 0,
+		"if greater than 0, the dry-run summary groups the objects to create, delete or leave untouched by the "+
+			"first N segments of their path, showing object counts and the total bytes to copy for each group. "+
+			"Implies '--dry-run'. e.g: --summary-depth 2")
+	estimateFlag = rollbackCmd.PersistentFlags().Bool("estimate", false,
+		"if present, the dry-run also estimates the number of requests, the bytes copied per storage class, "+
+			"the retrieval and early-deletion fees and the duration of the rollback. Implies '--dry-run'.")
+	priceTableFlag = rollbackCmd.PersistentFlags().String("price-table", "",
+		"path to a JSON file with the prices used by '--estimate'. If not given, a price table bundled "+
+			"with brestore is used. e.g: --price-table \"~/prices.json\"")
+
+	beforeChangeSetFlag = rollbackCmd.PersistentFlags().String("before-changeset", "",
+		"id of a change set listed by the changesets command. Rolls back to the point in time just before "+
+			"its first change, instead of the point in time given to --time.")
+	changeSetGapFlag = rollbackCmd.PersistentFlags().Duration("changeset-gap", defaultChangeSetGap,
+		"period without changes that separates two change sets. Must have the value given to --gap in the "+
+			"changesets command.")
+	deleteExpiredHistoryFlag = rollbackCmd.PersistentFlags().Bool("delete-expired-history", false,
+		"by default, objects whose versions at the point in time may have been deleted by lifecycle rules are "+
+			"left untouched and reported as unrecoverable. If present, they are treated as objects that didn't "+
+			"exist at the point in time, and deleted.")
+	checksumAlgorithmFlag = rollbackCmd.PersistentFlags().String("checksum-algorithm", "",
+		"AWS S3 only. Algorithm of an additional checksum that S3 computes for the restored copies, so they can "+
+			"be compared by content later. One of CRC32, CRC32C, SHA1 or SHA256. Objects of 5 GiB or more, "+
+			"which are copied in parts, get a checksum of the checksums of their parts. e.g: --checksum-algorithm SHA256")
+	partSizeFlag = rollbackCmd.PersistentFlags().String("part-size", "",
+		"AWS S3 only. Size of the parts of the copies of objects larger than 5 GiB, between 5 MiB and 5 GiB. "+
+			"If not given, each object is divided evenly by --part-concurrency, with at most 10000 parts. "+
+			"e.g: --part-size 512MiB")
+	partConcurrencyFlag = rollbackCmd.PersistentFlags().Int("part-concurrency", awsrestore.DefaultPartConcurrency,
+		"AWS S3 only. Number of parts of an object larger than 5 GiB copied at the same time. Each object "+
+			"copied in parts can use this many requests on top of --max-concurrency.")
+	storageClassFlag = rollbackCmd.PersistentFlags().String("storage-class", "",
+		"storage class of the restored copies. By default, each copy keeps the storage class of the version "+
+			"it was copied from, except copies of GLACIER and DEEP_ARCHIVE versions (AWS S3), which go to STANDARD. "+
+			"e.g: --storage-class STANDARD_IA (AWS S3) or --storage-class NEARLINE (GCP Storage)")
+	encryptionKeysFlag = rollbackCmd.PersistentFlags().String("encryption-keys", "",
+		"path to a JSON file with the encryption keys of the objects under each prefix. Rules in the file "+
+			"take precedence over the customer and KMS key flags. e.g: --encryption-keys \"~/keys.json\"")
+	sourceCustomerKeyFlag = rollbackCmd.PersistentFlags().String("source-customer-key", "",
+		"base64-encoded AES-256 key the versions to restore are encrypted with (AWS SSE-C or GCP CSEK). "+
+			"Unless another key is given, the restored copies are encrypted with it too.")
+	destCustomerKeyFlag = rollbackCmd.PersistentFlags().String("destination-customer-key", "",
+		"base64-encoded AES-256 key to encrypt the restored copies with (AWS SSE-C or GCP CSEK).")
+	kmsKeyFlag = rollbackCmd.PersistentFlags().String("kms-key", "",
+		"KMS key to encrypt the restored copies with: an AWS KMS key id or ARN, or a GCP Cloud KMS key name. "+
+			"By default, copies keep the KMS key of the version they were copied from.")
+	thawTierFlag = rollbackCmd.PersistentFlags().String("thaw-tier", "Standard",
+		"AWS S3 only. Retrieval tier of the thaws of archived versions (GLACIER, DEEP_ARCHIVE or the archive "+
+			"access tiers of INTELLIGENT_TIERING): Expedited, Standard or Bulk.")
+	thawDaysFlag = rollbackCmd.PersistentFlags().Int64("thaw-days", awsrestore.DefaultThawDays,
+		"AWS S3 only. Number of days thawed versions stay readable. The rollback must be run again within "+
+			"this time to copy them.")
+	thawStateFileFlag = rollbackCmd.PersistentFlags().String("thaw-state-file", "",
+		"AWS S3 only. File where the archived versions being thawed are saved between runs of the rollback. "+
+			"Defaults to 'thaw-<bucket>.json' in the current directory.")
+	objectLockModeFlag = rollbackCmd.PersistentFlags().String("object-lock-mode", "",
+		"AWS S3 only. S3 Object Lock retention mode of the restored copies: GOVERNANCE or COMPLIANCE. "+
+			"Requires --object-lock-retain-until. By default, copies get the default retention of the bucket.")
+	objectLockUntilFlag = rollbackCmd.PersistentFlags().String("object-lock-retain-until", "",
+		"AWS S3 only. Time until which the restored copies are retained, in any of the formats of --time. "+
+			"e.g: --object-lock-retain-until \"2022-02-21 00:00:00 +01:00\"")
+	metadataSnapshotFlag = rollbackCmd.PersistentFlags().StringArray("metadata-snapshot", nil,
+		"gcp storage only. Snapshot saved by the snapshot command, used to restore metadata changed in place "+
+			"after the point in time. Can be given more than once. e.g: --metadata-snapshot \"snapshot-mybucket.json\"")
 
 	rootCmd.AddCommand(rollbackCmd)
 }
@@ -74,18 +182,103 @@ func rollbackEntryPoint(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("No bucket specified. Specify the bucket to which this action should be applied with -b <bucket_url>.")
 	}
 
-	if *timestampFlag == "" {
+	if *timestampFlag == "" && *beforeChangeSetFlag == "" {
 		return fmt.Errorf("No timestamp specified. Specify a timestamp for this action with -t <timestamp>.\n")
 	}
 
+	if *timestampFlag != "" && *beforeChangeSetFlag != "" {
+		return fmt.Errorf("--time and --before-changeset can't be used together")
+	}
+
+	if *dryRunExplainFlag && (*estimateFlag || *priceTableFlag != "") {
+		return fmt.Errorf("--estimate and --price-table can't be used with --dry-run-explain. " +
+			"Use them with --dry-run instead")
+	}
+
 	binfo, err := brestore.ParseBucketURL(*sourceBucketFlag)
 	if err != nil {
 		return fmt.Errorf("could not parse bucket information from url: %v", err)
 	}
 
-	ts, err := brestore.ParseTimestamp(*timestampFlag)
+	if *checksumAlgorithmFlag != "" {
+		if binfo.Type != "s3" {
+			return fmt.Errorf("--checksum-algorithm is only supported for AWS S3 buckets")
+		}
+		if !validCopyChecksumAlgorithm(copyChecksumAlgorithm()) {
+			return fmt.Errorf("unsupported checksum algorithm '%s'. Supported algorithms are CRC32, CRC32C, "+
+				"SHA1 and SHA256", *checksumAlgorithmFlag)
+		}
+	}
+
+	if *partSizeFlag != "" {
+		copyPartSize, err = brestore.ParseByteCount(*partSizeFlag)
+		if err != nil {
+			return fmt.Errorf("could not parse --part-size: %v", err)
+		}
+		if copyPartSize < awsrestore.MinPartSize || copyPartSize > awsrestore.MaxPartSize {
+			return fmt.Errorf("--part-size must be between 5 MiB and 5 GiB")
+		}
+	}
+	if *partConcurrencyFlag < 1 {
+		return fmt.Errorf("--part-concurrency must be at least 1")
+	}
+
+	if *storageClassFlag != "" {
+		var ok bool
+		var classes []string
+		if binfo.Type == "s3" {
+			copyStorageClass, ok = awsrestore.NormalizeStorageClass(*storageClassFlag)
+			classes = awsrestore.StorageClasses
+		} else {
+			copyStorageClass, ok = gcprestore.NormalizeStorageClass(*storageClassFlag)
+			classes = gcprestore.StorageClasses
+		}
+		if !ok {
+			return fmt.Errorf("unsupported storage class '%s'. Supported storage classes are %s",
+				*storageClassFlag, strings.Join(classes, ", "))
+		}
+	}
+
+	tier, ok := normalizeThawTier(*thawTierFlag)
+	if !ok {
+		return fmt.Errorf("unsupported thaw tier '%s'. Supported tiers are %s", *thawTierFlag,
+			strings.Join(awsrestore.ThawTiers, ", "))
+	}
+	*thawTierFlag = tier
+	if *thawDaysFlag < 1 {
+		return fmt.Errorf("--thaw-days must be at least 1")
+	}
+
+	if *objectLockModeFlag != "" || *objectLockUntilFlag != "" {
+		copyObjectLock, err = parseObjectLockFlags(binfo)
+		if err != nil {
+			return err
+		}
+	}
+
+	restoreKeys, err = loadRestoreKeys()
 	if err != nil {
-		return fmt.Errorf("could not parse timestamp: %v", err)
+		return err
+	}
+
+	if len(*metadataSnapshotFlag) > 0 {
+		restoreSnapshots, err = loadMetadataSnapshots(binfo)
+		if err != nil {
+			return err
+		}
+	}
+
+	var ts time.Time
+	if *beforeChangeSetFlag != "" {
+		ts, err = restorePointOfChangeSet(binfo, *beforeChangeSetFlag, *changeSetGapFlag)
+		if err != nil {
+			return fmt.Errorf("error performing rollback command: %v", err)
+		}
+	} else {
+		ts, err = brestore.ParseTimestamp(*timestampFlag)
+		if err != nil {
+			return fmt.Errorf("could not parse timestamp: %v", err)
+		}
 	}
 
 	fmt.Printf("Restoring objects inside path '%v' at bucket '%s':\n"+
@@ -94,7 +287,7 @@ func rollbackEntryPoint(cmd *cobra.Command, args []string) error {
 
 	if *dryRunExplainFlag {
 		err = doDryRunExplain(binfo, ts)
-	} else if *dryRunFlag {
+	} else if *dryRunFlag || *summaryDepthFlag > 0 || *estimateFlag {
 		err = doDryRun(binfo, ts)
 	} else {
 		err = doRestore(binfo, ts)
@@ -127,9 +320,9 @@ func doDryRun(binfo brestore.BucketURLInfo, ts time.Time) error {
 		"To see a dry-run with more details, use the flag '--dry-run-explain'\n\n")
 	switch binfo.Type {
 	case "s3":
-		return doDryRunAWS(*profileFlag, binfo.BucketName, binfo.Prefix, ts)
+		return doDryRunAWS(*profileFlag, binfo.BucketName, binfo.Prefix, ts, *summaryDepthFlag)
 	case "gs":
-		return doDryRunGCP(*keyFileFlag, binfo.BucketName, binfo.Prefix, ts)
+		return doDryRunGCP(*keyFileFlag, binfo.BucketName, binfo.Prefix, ts, *summaryDepthFlag)
 	}
 	return nil
 }
@@ -143,3 +336,107 @@ func doRestore(binfo brestore.BucketURLInfo, ts time.Time) error {
 	}
 	return nil
 }
+
+// loadRestoreKeys builds the key map of the rollback from --encryption-keys and the customer and KMS key flags,
+// which apply to the objects not matched by any rule of the file. A rule of the file for the empty prefix
+// replaces the flags.
+func loadRestoreKeys() (encryption.KeyMap, error) {
+	var rules []encryption.Rule
+	var err error
+
+	if *encryptionKeysFlag != "" {
+		rules, err = encryption.LoadRules(*encryptionKeysFlag)
+		if err != nil {
+			return encryption.KeyMap{}, err
+		}
+	}
+
+	flagsGiven := *sourceCustomerKeyFlag != "" || *destCustomerKeyFlag != "" || *kmsKeyFlag != ""
+	if flagsGiven && !hasEmptyPrefixRule(rules) {
+		rules = append(rules, encryption.Rule{
+			SourceCustomerKey:      *sourceCustomerKeyFlag,
+			DestinationCustomerKey: *destCustomerKeyFlag,
+			KMSKey:                 *kmsKeyFlag,
+		})
+	}
+
+	keys, err := encryption.NewKeyMap(rules)
+	if err != nil {
+		return encryption.KeyMap{}, fmt.Errorf("invalid encryption keys: %v", err)
+	}
+	return keys, nil
+}
+
+func hasEmptyPrefixRule(rules []encryption.Rule) bool {
+	for _, r := range rules {
+		if r.Prefix == "" {
+			return true
+		}
+	}
+	return false
+}
+
+// normalizeThawTier converts a thaw tier given by the user to the name used by S3.
+func normalizeThawTier(tier string) (string, bool) {
+	for _, t := range awsrestore.ThawTiers {
+		if strings.EqualFold(t, strings.TrimSpace(tier)) {
+			return t, true
+		}
+	}
+	return "", false
+}
+
+// parseObjectLockFlags parses the Object Lock retention of the restored copies.
+func parseObjectLockFlags(binfo brestore.BucketURLInfo) (awsrestore.ObjectLock, error) {
+	var res awsrestore.ObjectLock
+
+	if binfo.Type != "s3" {
+		return res, fmt.Errorf("--object-lock-mode and --object-lock-retain-until are only supported for " +
+			"AWS S3 buckets")
+	}
+	if *objectLockModeFlag == "" || *objectLockUntilFlag == "" {
+		return res, fmt.Errorf("--object-lock-mode and --object-lock-retain-until must be given together")
+	}
+
+	for _, m := range awsrestore.ObjectLockModes {
+		if strings.EqualFold(m, *objectLockModeFlag) {
+			res.Mode = m
+		}
+	}
+	if res.Mode == "" {
+		return res, fmt.Errorf("unsupported Object Lock mode '%s'. Supported modes are %s", *objectLockModeFlag,
+			strings.Join(awsrestore.ObjectLockModes, ", "))
+	}
+
+	until, err := brestore.ParseTimestamp(*objectLockUntilFlag)
+	if err != nil {
+		return res, fmt.Errorf("could not parse --object-lock-retain-until: %v", err)
+	}
+	if !until.After(time.Now()) {
+		return res, fmt.Errorf("--object-lock-retain-until must be in the future")
+	}
+	res.RetainUntil = until
+
+	return res, nil
+}
+
+// loadMetadataSnapshots reads the snapshots given to --metadata-snapshot, which must be of the rolled back bucket.
+func loadMetadataSnapshots(binfo brestore.BucketURLInfo) (gcp_history.MetadataSnapshots, error) {
+	if binfo.Type != "gs" {
+		return nil, fmt.Errorf("--metadata-snapshot is only supported for gcp storage buckets")
+	}
+
+	var res gcp_history.MetadataSnapshots
+	for _, path := range *metadataSnapshotFlag {
+		snapshot, err := gcp_history.LoadSnapshot(path)
+		if err != nil {
+			return nil, err
+		}
+		if snapshot.Bucket != binfo.BucketName {
+			return nil, fmt.Errorf("metadata snapshot '%s' is of bucket '%s', not '%s'", path, snapshot.Bucket,
+				binfo.BucketName)
+		}
+		res = append(res, snapshot)
+	}
+	return res, nil
+}
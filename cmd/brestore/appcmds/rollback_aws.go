@@ -16,7 +16,9 @@ package appcmds
 
 import (
 	"fmt"
+	"github.com/viltgroup/bucket-restore/internal/brestore"
 	"github.com/viltgroup/bucket-restore/internal/brestore/awsrestore"
+	"github.com/viltgroup/bucket-restore/internal/brestore/estimate"
 	"sync"
 	"time"
 
@@ -25,7 +27,6 @@ import (
 
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/service/s3"
-	"github.com/aws/aws-sdk-go/service/s3/s3manager"
 )
 
 // FiveGibibytes is the number of bytes in 5 Gibibytes.
@@ -37,13 +38,29 @@ func doRestoreAWS(profile string, bucketName string, path string, timestamp time
 	if err != nil {
 		return fmt.Errorf("unable to get s3 client for profile '%v': %w", profile, err)
 	}
+	comparer := newContentComparerAWS(client, bucketName)
+	versioning := versioningStatusAWS(client, bucketName)
 
-	var created, deleted, noAction uint64
+	if copyObjectLock.Mode != "" {
+		locked, err := awsrestore.ObjectLockEnabled(client, bucketName)
+		if err != nil {
+			return err
+		}
+		if !locked {
+			return fmt.Errorf("bucket '%s' doesn't have S3 Object Lock enabled, so the restored copies can't be "+
+				"retained with --object-lock-mode", bucketName)
+		}
+	}
+
+	var created, deleted, noAction, unrecoverable, nullWarnings uint64
+	var warnings []lifecycleWarning
 
 	actions := history.FileActions{}
 
 	listingStarted := time.Now()
 
+	horizon := historyHorizonAWS(client, bucketName, listingStarted)
+
 	allGens, err := versions.OfPathByName(client, bucketName, path)
 	if err != nil {
 		return fmt.Errorf("listing contents of client: %v", err)
@@ -53,17 +70,38 @@ func doRestoreAWS(profile string, bucketName string, path string, timestamp time
 
 	decisionsStarted := time.Now()
 
+	comparer.prefetch(horizon, allGens, timestamp, *maxConcurrencyFlag)
+
 	for _, fileGens := range allGens {
 		fileGens.SortByLastModifiedAsc()
-		desiredState, lastState := history.StateDiffAtTime(fileGens, timestamp)
+		desiredState, lastState := horizon.StateDiffAtTime(fileGens, timestamp)
 		action := history.ActionForStateChange(lastState, desiredState)
-		if action.Action != history.NO_ACTION {
+		action = comparer.resolve(action, lastState, desiredState)
+		if len(nullVersionWarningsAWS(fileGens, action, desiredState, versioning)) > 0 {
+			nullWarnings++
+		}
+		if desiredState.PathStatus == history.EXPIRED {
+			unrecoverable++
+		} else if action.Action != history.NO_ACTION {
 			actions = append(actions, action)
+			warnings = append(warnings, lifecycleWarningsAWS(horizon.Lifecycle, fileGens, action, lastState,
+				desiredState, listingStarted)...)
 		} else {
 			noAction++
 		}
 	}
 
+	printLifecycleWarnings(warnings, false)
+
+	// archived versions can't be copied until they are thawed
+	actions, pending, thawErrors, err := thawArchivedAWS(client, bucketName, path, timestamp, actions)
+	if err != nil {
+		return err
+	}
+	for _, e := range thawErrors {
+		fmt.Printf("Error: %v\n", e)
+	}
+
 	decisionsElapsed := time.Since(decisionsStarted)
 
 	actionsStarted := time.Now()
@@ -76,10 +114,11 @@ func doRestoreAWS(profile string, bucketName string, path string, timestamp time
 		parts = *maxConcurrencyFlag
 	}
 
-	go concurrentActionsAWS(client, bucketName, actions, parts, resChan)
+	go concurrentActionsAWS(client, bucketName, actions, parts, comparer.versionsMayHaveOtherKeys(), resChan)
 
 	i := 1
-	var errors []error
+	errors := thawErrors
+	var aclWarnings []error
 	for result := range resChan {
 		if result.Err != nil {
 			errors = append(errors, result.Err)
@@ -95,6 +134,10 @@ func doRestoreAWS(profile string, bucketName string, path string, timestamp time
 						result.Action.Source.Key,
 						result.Action.Source.Version)
 				}
+				if result.NewObj.Warning != nil {
+					aclWarnings = append(aclWarnings, result.NewObj.Warning)
+					fmt.Printf("[%d/%d] Warning for %s: %v\n", i, nActions, result.Action.Source.Key, result.NewObj.Warning)
+				}
 				created++
 			case history.DELETE:
 				if !quiet {
@@ -119,6 +162,10 @@ func doRestoreAWS(profile string, bucketName string, path string, timestamp time
 	fmt.Printf("    %d objects created\n", created)
 	fmt.Printf("    %d objects deleted\n", deleted)
 	fmt.Printf("    %d objects did not need any action\n", noAction)
+	fmt.Printf("    %d objects left untouched because their history expired\n", unrecoverable)
+	fmt.Printf("    %d objects with null version warnings\n", nullWarnings)
+	fmt.Printf("    %d objects waiting for archived versions to be thawed\n", len(pending))
+	fmt.Printf("    %d objects created without the ACL of their version\n", len(aclWarnings))
 	fmt.Printf("    %d errors\n", len(errors))
 	fmt.Printf(""+
 		"Elapsed time: %v\n"+
@@ -128,6 +175,8 @@ func doRestoreAWS(profile string, bucketName string, path string, timestamp time
 		listingElapsed+decisionsElapsed+actionsElapsed,
 		listingElapsed, decisionsElapsed, actionsElapsed)
 
+	printPendingThaws(bucketName, pending)
+
 	if len(errors) > 0 {
 		err = saveErrorsToFile("errors.log", errors)
 		if err != nil {
@@ -153,6 +202,7 @@ func concurrentActionsAWS(
 	bucketName string,
 	actions history.FileActions,
 	parts int,
+	readEncryption bool,
 	resultChan chan<- RunActionResultAWS) {
 
 	var wg sync.WaitGroup
@@ -163,7 +213,7 @@ func concurrentActionsAWS(
 	for _, chunk := range chunks {
 		chunk := chunk
 		go func() {
-			runActions(client, bucketName, chunk, resultChan)
+			runActions(client, bucketName, chunk, readEncryption, resultChan)
 			wg.Done()
 		}()
 	}
@@ -193,6 +243,7 @@ func runActions(
 	bucket *s3.S3,
 	bucketName string,
 	actions history.FileActions,
+	readEncryption bool,
 	resultChan chan<- RunActionResultAWS) {
 
 	for _, action := range actions {
@@ -200,7 +251,7 @@ func runActions(
 
 		switch action.Action {
 		case history.CREATE:
-			newObject, err := doCreate(bucket, bucketName, action)
+			newObject, err := doCreate(bucket, bucketName, action, readEncryption)
 			if err != nil {
 				res.Err = fmt.Errorf("creating object '%s': %v", action.Source.Key, err)
 			} else {
@@ -220,12 +271,16 @@ func runActions(
 
 }
 
-func doDryRunExplainAWS(profile string, bucketName string, path string, time time.Time) error {
+func doDryRunExplainAWS(profile string, bucketName string, path string, timestamp time.Time) error {
 
 	client, err := awsrestore.GetS3Client(profile)
 	if err != nil {
 		return fmt.Errorf("unable to get s3 client for profile '%v': %w", profile, err)
 	}
+	comparer := newContentComparerAWS(client, bucketName)
+	versioning := versioningStatusAWS(client, bucketName)
+
+	horizon := historyHorizonAWS(client, bucketName, time.Now())
 
 	actions := history.FileActions{}
 
@@ -234,10 +289,15 @@ func doDryRunExplainAWS(profile string, bucketName string, path string, time tim
 		return fmt.Errorf("listing contents of bucket: %w", err)
 	}
 
+	comparer.prefetch(horizon, allGens, timestamp, *maxConcurrencyFlag)
+
+	now := time.Now()
+
 	for _, fileGens := range allGens {
 		fileGens.SortByLastModifiedAsc()
-		desiredState, lastState := history.StateDiffAtTime(fileGens, time)
+		desiredState, lastState := horizon.StateDiffAtTime(fileGens, timestamp)
 		action := history.ActionForStateChange(lastState, desiredState)
+		action = comparer.resolve(action, lastState, desiredState)
 		actions = append(actions, action)
 		fmt.Printf(""+
 			"%s: %s\n"+
@@ -247,53 +307,152 @@ func doDryRunExplainAWS(profile string, bucketName string, path string, time tim
 			formatAction(action),
 			formatState(lastState),
 			formatState(desiredState))
+		for _, w := range lifecycleWarningsAWS(horizon.Lifecycle, fileGens, action, lastState, desiredState, now) {
+			fmt.Printf("  Lifecycle warning: %s\n", w)
+		}
+		for _, w := range nullVersionWarningsAWS(fileGens, action, desiredState, versioning) {
+			fmt.Printf("  Null version warning: %s\n", w.Message)
+		}
+		if action.Action == history.CREATE && awsrestore.ArchivedStorageClass(desiredState.StorageClass) {
+			fmt.Printf("  Archived: the version is in %s and is thawed before it's copied\n",
+				desiredState.StorageClass)
+		}
 	}
 
 	return nil
 }
 
-func doDryRunAWS(profile string, bucketName string, path string, time time.Time) error {
+func doDryRunAWS(profile string, bucketName string, path string, timestamp time.Time, summaryDepth int) error {
 	client, err := awsrestore.GetS3Client(profile)
 	if err != nil {
 		return fmt.Errorf("unable to get s3 client for profile '%v': %w", profile, err)
 	}
+	comparer := newContentComparerAWS(client, bucketName)
+	versioning := versioningStatusAWS(client, bucketName)
+
+	summary := brestore.NewPlanSummary(summaryDepth)
+
+	prices, err := estimate.LoadPriceTable(*priceTableFlag)
+	if err != nil {
+		return err
+	}
+	usage := estimate.NewUsage()
 
-	var toCreate, toDelete, noAction int64
+	horizon := historyHorizonAWS(client, bucketName, time.Now())
+	var warnings []lifecycleWarning
+	var nullWarnings []nullVersionWarning
+	var unrecoverable []string
+	archived := map[string]int{}
+
+	listingStarted := time.Now()
 
 	allGens, err := versions.OfPathByName(client, bucketName, path)
 	if err != nil {
 		return fmt.Errorf("listing contents of bucket: %w", err)
 	}
 
-	for _, fileGens := range allGens {
+	usage.ListingDuration = time.Since(listingStarted)
+
+	comparer.prefetch(horizon, allGens, timestamp, *maxConcurrencyFlag)
+
+	for key, fileGens := range allGens {
+		usage.ListedVersions += int64(len(fileGens))
 		fileGens.SortByLastModifiedAsc()
-		desiredState, lastState := history.StateDiffAtTime(fileGens, time)
+		desiredState, lastState := horizon.StateDiffAtTime(fileGens, timestamp)
 		action := history.ActionForStateChange(lastState, desiredState)
+		action = comparer.resolve(action, lastState, desiredState)
+		if desiredState.PathStatus == history.EXPIRED {
+			summary.AddUnrecoverable(key)
+			unrecoverable = append(unrecoverable, key)
+			continue
+		}
 		switch action.Action {
 		case history.CREATE:
-			toCreate++
+			summary.AddCreate(key, desiredState.Size)
+			if awsrestore.ArchivedStorageClass(desiredState.StorageClass) {
+				archived[desiredState.StorageClass]++
+			}
+			if desiredState.Size < FiveGibibytes {
+				usage.AddCopy(desiredState.StorageClass, desiredState.Size)
+				// the encryption of the version is read to be kept
+				if comparer.versionsMayHaveOtherKeys() && !restoreKeys.KeysFor(key).KeysChosen() {
+					usage.AddReads(1)
+				}
+			} else {
+				usage.AddMultipartCopy(desiredState.StorageClass, desiredState.Size, multipartCopyParts(desiredState.Size))
+				// the metadata and tags of the version are read to be copied explicitly, and the object is
+				// listed to check it wasn't modified during the copy
+				usage.AddReads(2)
+				if !action.UnmodifiedPreCondition.IsZero() {
+					usage.AddUpdates(1)
+				}
+			}
+			// the ACL of the version is read and set on the copy
+			usage.AddReads(1)
+			usage.AddUpdates(1)
 		case history.DELETE:
-			toDelete++
+			summary.AddDelete(key)
+			usage.AddDelete()
 		case history.NO_ACTION:
-			noAction++
+			summary.AddNoAction(key)
 		}
+
+		if action.Action != history.NO_ACTION && lastState.PathStatus == history.EXISTS {
+			usage.AddReplaced(prices.Providers["s3"], lastState.StorageClass, lastState.Size,
+				time.Since(lastState.LastModified))
+		}
+
+		warnings = append(warnings, lifecycleWarningsAWS(horizon.Lifecycle, fileGens, action, lastState, desiredState, listingStarted)...)
+		nullWarnings = append(nullWarnings, nullVersionWarningsAWS(fileGens, action, desiredState, versioning)...)
 	}
 
-	fmt.Printf("To create: %d objects\n", toCreate)
-	fmt.Printf("To delete %d objects\n", toDelete)
-	fmt.Printf("No action: %d objects\n", noAction)
+	if *estimateFlag {
+		e, err := prices.Estimate("s3", usage, *maxConcurrencyFlag)
+		if err != nil {
+			return fmt.Errorf("estimating rollback cost: %w", err)
+		}
+		printEstimate(e)
+		fmt.Printf("\n")
+	}
+
+	printLifecycleWarnings(warnings, true)
+	printNullVersionWarnings(nullWarnings)
+	printArchivedAWS(archived)
+	printUnrecoverable("s3", bucketName, unrecoverable)
+
+	if summaryDepth > 0 {
+		printPlanSummary("s3", bucketName, summary)
+		return nil
+	}
+
+	total := summary.Total()
+	fmt.Printf("To create: %d objects\n", total.ToCreate)
+	fmt.Printf("To delete %d objects\n", total.ToDelete)
+	fmt.Printf("No action: %d objects\n", total.NoAction)
+	fmt.Printf("Unrecoverable: %d objects\n", total.Unrecoverable)
 
 	return nil
 }
 
+// multipartCopyParts returns the number of parts used by a multipart copy of an object with the given size.
+func multipartCopyParts(size int64) int64 {
+	return awsrestore.CopyParts(size, copyPartSize, *partConcurrencyFlag)
+}
+
 // CopyResult is the result of a copy action
 type CopyResult struct {
 	Key       string
 	VersionId string
 	ETag      string
+	// Warning is set when the copy was created but its ACL could not be copied
+	Warning error
 }
 
-func doCreate(client *s3.S3, bucketName string, action history.FileAction) (CopyResult, error) {
+// doCreate copies the version of an action over its object. Unless the keys of the object choose the encryption
+// of the copy, the encryption of the version is read and kept when readEncryption is true.
+func doCreate(client *s3.S3, bucketName string, action history.FileAction, readEncryption bool) (CopyResult,
+	error) {
+
 
 	var copy *s3.CopyObjectOutput
 	var res CopyResult
@@ -304,13 +463,40 @@ func doCreate(client *s3.S3, bucketName string, action history.FileAction) (Copy
 	if !action.UnmodifiedPreCondition.IsZero() {
 		matchUnmodified = &action.UnmodifiedPreCondition
 	}
+	keys := restoreKeys.KeysFor(action.Source.Key)
+
 	if action.Source.Size < FiveGibibytes {
-		copy, err = client.CopyObject(&s3.CopyObjectInput{
-			Bucket:                      aws.String(bucketName),
-			Key:                         aws.String(action.Source.Key),
-			CopySource:                  aws.String(action.Source.ToSourceURL(bucketName)),
-			CopySourceIfUnmodifiedSince: matchUnmodified,
+		// copies get the default encryption of the bucket, unless given the encryption of the version
+		var head *s3.HeadObjectOutput
+		if readEncryption && !keys.KeysChosen() {
+			head, err = awsrestore.VersionHead(client, bucketName, action.Source.Key, action.Source.Version,
+				keys.SourceCustomerKey)
+			if err != nil {
+				return res, err
+			}
+		}
+		enc := awsrestore.NewCopyEncryption(keys, head)
+
+		req, out := client.CopyObjectRequest(&s3.CopyObjectInput{
+			Bucket:                         aws.String(bucketName),
+			Key:                            aws.String(action.Source.Key),
+			CopySource:                     aws.String(action.Source.ToSourceURL(bucketName)),
+			CopySourceIfUnmodifiedSince:    matchUnmodified,
+			StorageClass:                   awsrestore.CopyStorageClass(action.Source.StorageClass, copyStorageClass),
+			CopySourceSSECustomerAlgorithm: enc.CopySourceSSECustomerAlgorithm,
+			CopySourceSSECustomerKey:       enc.CopySourceSSECustomerKey,
+			SSECustomerAlgorithm:           enc.SSECustomerAlgorithm,
+			SSECustomerKey:                 enc.SSECustomerKey,
+			ServerSideEncryption:           enc.ServerSideEncryption,
+			SSEKMSKeyId:                    enc.SSEKMSKeyId,
+			ObjectLockMode:                 copyObjectLock.ModeParam(),
+			ObjectLockRetainUntilDate:      copyObjectLock.RetainUntilParam(),
 		})
+		// the version of the SDK in use doesn't support the checksum algorithm parameter
+		if alg := copyChecksumAlgorithm(); alg != "" {
+			req.HTTPRequest.Header.Set("x-amz-checksum-algorithm", alg)
+		}
+		copy, err = out, req.Send()
 		if err != nil {
 			return res, fmt.Errorf("copying object: %w", err)
 		}
@@ -320,19 +506,33 @@ func doCreate(client *s3.S3, bucketName string, action history.FileAction) (Copy
 			ETag:      *copy.CopyObjectResult.ETag,
 		}
 	} else {
-		copier := s3manager.NewCopierWithClient(client)
-		copy, err := copier.Copy(&s3manager.CopyInput{
-			Bucket:     aws.String(bucketName),
-			Key:        aws.String(action.Source.Key),
-			CopySource: aws.String(action.Source.ToSourceURL(bucketName)),
-		})
+		mc := awsrestore.MultipartCopy{
+			Client:            client,
+			BucketName:        bucketName,
+			Key:               action.Source.Key,
+			VersionID:         action.Source.Version,
+			Size:              action.Source.Size,
+			PartSize:          copyPartSize,
+			Concurrency:       *partConcurrencyFlag,
+			UnmodifiedSince:   action.UnmodifiedPreCondition,
+			StorageClass:      copyStorageClass,
+			Keys:              keys,
+			ObjectLock:        copyObjectLock,
+			ChecksumAlgorithm: copyChecksumAlgorithm(),
+		}
+		if !*quietFlag {
+			mc.OnPart = func(copied int64, parts int64) {
+				fmt.Printf("    %s: copied part %d/%d\n", action.Source.Key, copied, parts)
+			}
+		}
+		versionID, etag, err := mc.Run()
 		if err != nil {
-			return res, fmt.Errorf("copying object: %w", err)
+			return res, fmt.Errorf("copying object in parts: %w", err)
 		}
 		res = CopyResult{
 			Key:       action.Source.Key,
-			VersionId: *copy.VersionId,
-			ETag:      *copy.ETag,
+			VersionId: versionID,
+			ETag:      etag,
 		}
 	}
 
@@ -340,6 +540,13 @@ func doCreate(client *s3.S3, bucketName string, action history.FileAction) (Copy
 		return res, fmt.Errorf("copying object: %w", err)
 	}
 
+	// copies get the default private ACL. The copy already exists, so a failure here doesn't fail the
+	// create: a re-run would copy the object again
+	err = awsrestore.CopyACL(client, bucketName, action.Source.Key, action.Source.Version, res.VersionId)
+	if err != nil {
+		res.Warning = fmt.Errorf("copying ACL of '%s' to version '%s': %w", action.Source.Key, res.VersionId, err)
+	}
+
 	return res, nil
 }
 
@@ -358,6 +565,8 @@ func formatState(state history.PathState) string {
 		return "Not Existent"
 	case history.EXISTS:
 		return fmt.Sprintf("Exists at version %s ETag: %s", state.Version.ID, state.ETag)
+	case history.EXPIRED:
+		return "History Expired, the object may have existed but its versions were deleted"
 	case history.DELETED:
 		return fmt.Sprintf("Deleted on version %s", state.Version.ID)
 	default:
@@ -370,8 +579,14 @@ func formatAction(action history.FileAction) string {
 	case history.DELETE:
 		return "Delete"
 	case history.CREATE:
+		if action.Checksum != "" {
+			return fmt.Sprintf("Create from version %s (content differs by %s)", action.Source.Version, action.Checksum)
+		}
 		return fmt.Sprintf("Create from version %s", action.Source.Version)
 	case history.NO_ACTION:
+		if action.Checksum != "" {
+			return fmt.Sprintf("No Action (same content by %s)", action.Checksum)
+		}
 		return "No Action"
 	default:
 		return "Unknown Status"
@@ -17,6 +17,8 @@ package appcmds
 import (
 	"context"
 	"fmt"
+	"github.com/viltgroup/bucket-restore/internal/brestore"
+	"github.com/viltgroup/bucket-restore/internal/brestore/estimate"
 	"github.com/viltgroup/bucket-restore/internal/brestore/gcprestore"
 	"sync"
 	"time"
@@ -28,20 +30,31 @@ import (
 
 func doRestoreGCP(keyfile string, bucketName string, path string, timestamp time.Time, quiet bool) error {
 
-	client, _, err := gcprestore.GetStorageClientFromFile(keyfile)
+	client, ctx, err := gcprestore.GetStorageClientFromFile(keyfile)
 	if err != nil {
 		return fmt.Errorf("getting storage client for key file '%v': %w", keyfile, err)
 	}
 
 	bucket := client.Bucket(bucketName)
 
-	var created, deleted, noAction int64
+	prices, err := estimate.LoadPriceTable(*priceTableFlag)
+	if err != nil {
+		return err
+	}
+	fees := newArchiveFeesGCP(prices.Providers["gs"])
+
+	var created, deleted, updated, noAction, unrecoverable int64
 	var errors []error
+	var blocked []blockedObject
+	var unknownMetadata []string
+	var warnings []lifecycleWarning
 
 	actions := gcp_history.FileActions{}
 
 	listingStarted := time.Now()
 
+	horizon := historyHorizonGCP(ctx, bucket, listingStarted)
+
 	allGens, err := gcp_generations.OfPathByName(bucket, path)
 	if err != nil {
 		return fmt.Errorf("listing contents of bucket: %v", err)
@@ -53,10 +66,23 @@ func doRestoreGCP(keyfile string, bucketName string, path string, timestamp time
 
 	for _, fileGens := range allGens {
 		fileGens.SortByCreatedDateAsc()
-		desiredState, lastState := gcp_history.StateDiffAtTime(fileGens, timestamp)
+		desiredState, lastState := horizon.StateDiffAtTime(fileGens, timestamp)
 		action := gcp_history.ActionForStateChange(lastState, desiredState)
-		if action.Action != gcp_history.NO_ACTION {
+		action, metadataUnknown := gcp_history.MetadataActionFor(action, lastState, desiredState, timestamp,
+			restoreSnapshots)
+		if metadataUnknown {
+			unknownMetadata = append(unknownMetadata, lastState.Name)
+		}
+		reason := gcp_history.BlockedBy(action, lastState, listingStarted)
+		if desiredState.PathStatus == gcp_history.EXPIRED {
+			unrecoverable++
+		} else if reason != "" {
+			blocked = append(blocked, blockedObject{Key: action.Source.Name, Reason: reason})
+		} else if action.Action != gcp_history.NO_ACTION {
 			actions = append(actions, action)
+			fees.add(action, lastState, desiredState, listingStarted)
+			warnings = append(warnings, lifecycleWarningsGCP(horizon.Lifecycle, fileGens, action, lastState,
+				desiredState, listingStarted)...)
 		} else {
 			noAction++
 		}
@@ -64,9 +90,13 @@ func doRestoreGCP(keyfile string, bucketName string, path string, timestamp time
 
 	decisionsElapsed := time.Since(decisionsStarted)
 
+	printLifecycleWarnings(warnings, false)
+	fees.print()
+	printBlocked("gs", bucketName, blocked, false)
+	printUnknownMetadata("gs", bucketName, unknownMetadata, false)
+
 	actionsStarted := time.Now()
 
-	ctx := context.Background()
 	resChan := make(chan RunActionResultGCP, 1024)
 
 	nActions := len(actions)
@@ -104,6 +134,16 @@ func doRestoreGCP(keyfile string, bucketName string, path string, timestamp time
 						result.Action.Source.Generation)
 				}
 				deleted++
+			case gcp_history.UPDATE_METADATA:
+				if !quiet {
+					fmt.Printf("[%d/%d] Updated metadata of %s(#%d) to metageneration %d\n",
+						i,
+						nActions,
+						result.Action.Source.Name,
+						result.Action.Source.Generation,
+						result.NewObj.Metageneration)
+				}
+				updated++
 			default:
 			}
 		}
@@ -116,7 +156,10 @@ func doRestoreGCP(keyfile string, bucketName string, path string, timestamp time
 	fmt.Printf("Bucket restored to %v:\n", timestamp)
 	fmt.Printf("    %d objects created\n", created)
 	fmt.Printf("    %d objects deleted\n", deleted)
+	fmt.Printf("    %d objects with their metadata restored\n", updated)
 	fmt.Printf("    %d objects did not need any action\n", noAction)
+	fmt.Printf("    %d objects left untouched because their history expired\n", unrecoverable)
+	fmt.Printf("    %d objects left untouched because of holds or retention\n", len(blocked))
 	fmt.Printf("    %d errors\n", errors)
 	fmt.Printf(""+
 		"Elapsed time: %v\n"+
@@ -202,6 +245,14 @@ func runActionsGCP(ctx context.Context, bucket *storage.BucketHandle, actions gc
 			if err != nil {
 				res.Err = fmt.Errorf("deleting object '%s': %v", action.Source.Name, err)
 			}
+
+		case gcp_history.UPDATE_METADATA:
+			newObject, err := doUpdateMetadataGCP(ctx, bucket, action)
+			if err != nil {
+				res.Err = fmt.Errorf("updating metadata of object '%s': %v", action.Source.Name, err)
+			} else {
+				res.NewObj = newObject
+			}
 		default:
 		}
 
@@ -210,14 +261,16 @@ func runActionsGCP(ctx context.Context, bucket *storage.BucketHandle, actions gc
 
 }
 
-func doDryRunExplainGCP(keyfile string, bucketName string, path string, time time.Time) error {
-	client, _, err := gcprestore.GetStorageClientFromFile(keyfile)
+func doDryRunExplainGCP(keyfile string, bucketName string, path string, timestamp time.Time) error {
+	client, ctx, err := gcprestore.GetStorageClientFromFile(keyfile)
 	if err != nil {
 		return fmt.Errorf("getting storage client for key file '%v': %w", keyfile, err)
 	}
 
 	bucket := client.Bucket(bucketName)
 
+	horizon := historyHorizonGCP(ctx, bucket, time.Now())
+
 	actions := gcp_history.FileActions{}
 
 	allGens, err := gcp_generations.OfPathByName(bucket, path)
@@ -225,10 +278,14 @@ func doDryRunExplainGCP(keyfile string, bucketName string, path string, time tim
 		return fmt.Errorf("listing contents of bucket: %w", err)
 	}
 
+	now := time.Now()
+
 	for _, fileGens := range allGens {
 		fileGens.SortByCreatedDateAsc()
-		desiredState, lastState := gcp_history.StateDiffAtTime(fileGens, time)
+		desiredState, lastState := horizon.StateDiffAtTime(fileGens, timestamp)
 		action := gcp_history.ActionForStateChange(lastState, desiredState)
+		action, metadataUnknown := gcp_history.MetadataActionFor(action, lastState, desiredState, timestamp,
+			restoreSnapshots)
 		actions = append(actions, action)
 		fmt.Printf(""+
 			"%s: %s\n"+
@@ -238,57 +295,161 @@ func doDryRunExplainGCP(keyfile string, bucketName string, path string, time tim
 			formatActionGCP(action),
 			formatStateGCP(lastState),
 			formatStateGCP(desiredState))
+		for _, w := range lifecycleWarningsGCP(horizon.Lifecycle, fileGens, action, lastState, desiredState, now) {
+			fmt.Printf("  Lifecycle warning: %s\n", w)
+		}
+		if reason := gcp_history.BlockedBy(action, lastState, now); reason != "" {
+			fmt.Printf("  Blocked: %s\n", reason)
+		}
+		if metadataUnknown {
+			fmt.Printf("  Metadata changed after the point in time, but the metadata it had then is unknown\n")
+		}
 	}
 
 	return nil
 }
 
-func doDryRunGCP(keyfile string, bucketName string, path string, time time.Time) error {
+func doDryRunGCP(keyfile string, bucketName string, path string, timestamp time.Time, summaryDepth int) error {
 
-	client, _, err := gcprestore.GetStorageClientFromFile(keyfile)
+	client, ctx, err := gcprestore.GetStorageClientFromFile(keyfile)
 	if err != nil {
 		return fmt.Errorf("getting storage client for key file '%v': %w", keyfile, err)
 	}
 
 	bucket := client.Bucket(bucketName)
 
-	var toCreate, toDelete, noAction int64
+	horizon := historyHorizonGCP(ctx, bucket, time.Now())
+	var warnings []lifecycleWarning
+	var unrecoverable []string
+	var blocked []blockedObject
+	var unknownMetadata []string
+	var metadataUpdates int
+
+	summary := brestore.NewPlanSummary(summaryDepth)
+
+	prices, err := estimate.LoadPriceTable(*priceTableFlag)
+	if err != nil {
+		return err
+	}
+	usage := estimate.NewUsage()
+	fees := newArchiveFeesGCP(prices.Providers["gs"])
+
+	listingStarted := time.Now()
 
 	allGens, err := gcp_generations.OfPathByName(bucket, path)
 	if err != nil {
 		return fmt.Errorf("listing contents of bucket: %w", err)
 	}
 
-	for _, fileGens := range allGens {
+	usage.ListingDuration = time.Since(listingStarted)
+
+	for name, fileGens := range allGens {
+		usage.ListedVersions += int64(len(fileGens))
 		fileGens.SortByCreatedDateAsc()
-		desiredState, lastState := gcp_history.StateDiffAtTime(fileGens, time)
+		desiredState, lastState := horizon.StateDiffAtTime(fileGens, timestamp)
 		action := gcp_history.ActionForStateChange(lastState, desiredState)
+		action, metadataUnknown := gcp_history.MetadataActionFor(action, lastState, desiredState, timestamp,
+			restoreSnapshots)
+		if metadataUnknown {
+			unknownMetadata = append(unknownMetadata, name)
+		}
+		if desiredState.PathStatus == gcp_history.EXPIRED {
+			summary.AddUnrecoverable(name)
+			unrecoverable = append(unrecoverable, name)
+			continue
+		}
+		if reason := gcp_history.BlockedBy(action, lastState, listingStarted); reason != "" {
+			summary.AddBlocked(name)
+			blocked = append(blocked, blockedObject{Key: name, Reason: reason})
+			continue
+		}
 		switch action.Action {
 		case gcp_history.CREATE:
-			toCreate++
+			summary.AddCreate(name, desiredState.Size)
+			usage.AddCopy(desiredState.StorageClass, desiredState.Size)
+			// the attributes of the generation are read to be copied
+			usage.AddReads(1)
 		case gcp_history.DELETE:
-			toDelete++
+			summary.AddDelete(name)
+			usage.AddDelete()
 		case gcp_history.NO_ACTION:
-			noAction++
+			summary.AddNoAction(name)
+		case gcp_history.UPDATE_METADATA:
+			// the content is already the one at the point in time
+			summary.AddNoAction(name)
+			metadataUpdates++
+		}
+
+		if (action.Action == gcp_history.CREATE || action.Action == gcp_history.DELETE) &&
+			lastState.PathStatus == gcp_history.EXISTS {
+			usage.AddReplaced(prices.Providers["gs"], lastState.StorageClass, lastState.Size,
+				time.Since(lastState.Created))
 		}
+
+		warnings = append(warnings, lifecycleWarningsGCP(horizon.Lifecycle, fileGens, action, lastState, desiredState, listingStarted)...)
+		fees.add(action, lastState, desiredState, listingStarted)
 	}
 
-	fmt.Printf("To create: %d objects\n", toCreate)
-	fmt.Printf("To delete %d objects\n", toDelete)
-	fmt.Printf("No action: %d objects\n", noAction)
+	if *estimateFlag {
+		e, err := prices.Estimate("gs", usage, *maxConcurrencyFlag)
+		if err != nil {
+			return fmt.Errorf("estimating rollback cost: %w", err)
+		}
+		printEstimate(e)
+		fmt.Printf("\n")
+	}
+
+	printLifecycleWarnings(warnings, true)
+	fees.print()
+	printUnrecoverable("gs", bucketName, unrecoverable)
+	printBlocked("gs", bucketName, blocked, true)
+	printUnknownMetadata("gs", bucketName, unknownMetadata, true)
+
+	if summaryDepth > 0 {
+		printPlanSummary("gs", bucketName, summary)
+		return nil
+	}
+
+	total := summary.Total()
+	fmt.Printf("To create: %d objects\n", total.ToCreate)
+	fmt.Printf("To delete %d objects\n", total.ToDelete)
+	fmt.Printf("No action: %d objects\n", total.NoAction)
+	fmt.Printf("Unrecoverable: %d objects\n", total.Unrecoverable)
+	fmt.Printf("Blocked: %d objects\n", total.Blocked)
+	fmt.Printf("Metadata to restore: %d objects\n", metadataUpdates)
 
 	return nil
 }
 
 func doCreateGCP(ctx context.Context, bucket *storage.BucketHandle, action gcp_history.FileAction) (*storage.ObjectAttrs, error) {
+	keys := restoreKeys.KeysFor(action.Source.Name)
 	toObject := bucket.Object(action.Source.Name)
 	fromObject := toObject.Generation(action.Source.Generation)
 
+	if len(keys.SourceCustomerKey) > 0 {
+		fromObject = fromObject.Key(keys.SourceCustomerKey)
+	}
+	if len(keys.DestinationCustomerKey) > 0 {
+		toObject = toObject.Key(keys.DestinationCustomerKey)
+	}
 	if action.GenerationPreCondition != 0 {
 		toObject = toObject.If(storage.Conditions{GenerationMatch: action.GenerationPreCondition})
 	}
 
+	// copies get the bucket defaults for the attributes not given
+	attrs, err := fromObject.Attrs(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("getting attributes of generation %d: %w", action.Source.Generation, err)
+	}
+
 	copier := toObject.CopierFrom(fromObject)
+	copier.ObjectAttrs = gcprestore.CopyAttrs(attrs, copyStorageClass)
+	// copies get the default key of the bucket, unless given the key of the generation
+	if keys.KMSKey != "" {
+		copier.DestinationKMSKeyName = keys.KMSKey
+	} else if !keys.KeysChosen() {
+		copier.DestinationKMSKeyName = gcprestore.KMSKeyName(attrs.KMSKeyName)
+	}
 	return copier.Run(ctx)
 }
 
@@ -302,9 +463,12 @@ func formatStateGCP(state gcp_history.PathState) string {
 	case gcp_history.NOT_EXISTENT:
 		return "Not Existent"
 	case gcp_history.EXISTS:
-		return fmt.Sprintf("Exists at generation #%d, md5: %x", state.Generation, state.MD5)
+		return fmt.Sprintf("Exists at generation #%d, metageneration %d, %s", state.Generation,
+			state.Metageneration, formatChecksumsGCP(state))
+	case gcp_history.EXPIRED:
+		return "History Expired, the object may have existed but its versions were deleted"
 	case gcp_history.DELETED:
-		return fmt.Sprintf("Deleted on generation #%d, md5: %x", state.Generation, state.MD5)
+		return fmt.Sprintf("Deleted on generation #%d, %s", state.Generation, formatChecksumsGCP(state))
 	default:
 		return "Unknown Status"
 	}
@@ -315,10 +479,26 @@ func formatActionGCP(action gcp_history.FileAction) string {
 	case gcp_history.DELETE:
 		return "Delete"
 	case gcp_history.CREATE:
+		if action.Checksum != "" {
+			return fmt.Sprintf("Create from #%d (content differs by %s)", action.Source.Generation, action.Checksum)
+		}
 		return fmt.Sprintf("Create from #%d", action.Source.Generation)
 	case gcp_history.NO_ACTION:
+		if action.Checksum != "" {
+			return fmt.Sprintf("No Action (same content by %s)", action.Checksum)
+		}
 		return "No Action"
+	case gcp_history.UPDATE_METADATA:
+		return fmt.Sprintf("Update Metadata (%s differs)", formatMetadataChangesGCP(action))
 	default:
 		return "Unknown Status"
 	}
 }
+
+// formatChecksumsGCP formats the checksums of a generation. Composite objects have no MD5.
+func formatChecksumsGCP(state gcp_history.PathState) string {
+	if state.Composite {
+		return fmt.Sprintf("composite, crc32c: %08x, size: %d", state.CRC32C, state.Size)
+	}
+	return fmt.Sprintf("md5: %x, crc32c: %08x", state.MD5, state.CRC32C)
+}
@@ -15,7 +15,9 @@
 package appcmds
 
 import (
+	"fmt"
 	"github.com/spf13/cobra"
+	"github.com/viltgroup/bucket-restore/internal/brestore"
 	"os"
 )
 
@@ -127,3 +129,24 @@ func rootEntryPoint(cmd *cobra.Command, args []string) error {
 	cmd.Help()
 	return nil
 }
+
+// bucketURLFromArgs returns the bucket url given as the first positional argument of a command or, if no
+// argument was given, the url given to the --bucket flag.
+func bucketURLFromArgs(args []string) (brestore.BucketURLInfo, error) {
+	url := *sourceBucketFlag
+	if len(args) > 0 {
+		url = args[0]
+	}
+
+	if url == "" {
+		return brestore.BucketURLInfo{}, fmt.Errorf("No bucket specified. Specify the bucket to which this " +
+			"action should be applied as an argument or with -b <bucket_url>.")
+	}
+
+	binfo, err := brestore.ParseBucketURL(url)
+	if err != nil {
+		return binfo, fmt.Errorf("could not parse bucket information from url: %v", err)
+	}
+
+	return binfo, nil
+}
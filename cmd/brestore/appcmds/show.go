@@ -0,0 +1,172 @@
+// Copyright 2021 VILT Group
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package appcmds
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/viltgroup/bucket-restore/internal/brestore"
+)
+
+var showExamples = "" +
+	"  Show the full timeline of an object:\n" +
+	"    brestore show s3://mybucket/path/to/file\n\n" +
+	"  Show the full timeline of an object, marking the version that was live at a point in time:\n" +
+	"    brestore show gs://mybucket/path/to/file --time \"February 21, 2021, 23:00:00 (UTC+01:00)\""
+
+func init() {
+	rootCmd.AddCommand(showCmd)
+}
+
+var showCmd = &cobra.Command{
+	Use:   "show [object_url]",
+	Short: "Shows the full timeline of an object",
+	Long: "" +
+		"Description:\n" +
+		"  Shows every version/generation of an object, from the oldest to the newest, with all its metadata. " +
+		"Metadata that changed from the previous version is highlighted with '*'. The version that was live at " +
+		"the point in time given to the --time flag, or the current one if no time is given, is marked as LIVE.",
+	Example:      showExamples,
+	Args:         cobra.MaximumNArgs(1),
+	RunE:         showEntryPoint,
+	SilenceUsage: true,
+}
+
+// showField represents a named value shown for a version.
+type showField struct {
+	Name  string
+	Value string
+}
+
+// showVersion represents a version/generation of an object in the timeline of the show command.
+type showVersion struct {
+	// Version id or generation
+	ID string
+	// Whether this version is an AWS delete marker
+	DeleteMarker bool
+	// Whether this version was live at the requested point in time
+	Live bool
+	// Times of the events of the version, in the order they are shown
+	Times []showField
+	// Attributes of the version, compared between consecutive versions
+	Attrs map[string]string
+}
+
+func showEntryPoint(cmd *cobra.Command, args []string) error {
+	binfo, err := bucketURLFromArgs(args)
+	if err != nil {
+		return err
+	}
+
+	if binfo.Prefix == "" {
+		return fmt.Errorf("No object specified. Specify the object to show with <bucket_url>/path/to/object.")
+	}
+
+	ts := time.Now()
+	if *timestampFlag != "" {
+		ts, err = brestore.ParseTimestamp(*timestampFlag)
+		if err != nil {
+			return fmt.Errorf("could not parse timestamp: %v", err)
+		}
+	}
+
+	var timeline []showVersion
+	switch binfo.Type {
+	case "s3":
+		timeline, err = showAWS(*profileFlag, binfo.BucketName, binfo.Prefix, ts)
+	case "gs":
+		timeline, err = showGCP(*keyFileFlag, binfo.BucketName, binfo.Prefix, ts)
+	}
+	if err != nil {
+		return fmt.Errorf("error performing show command: %v", err)
+	}
+
+	if len(timeline) == 0 {
+		return fmt.Errorf("no versions found for object '%s'", binfo.Prefix)
+	}
+
+	fmt.Printf("%s://%s/%s\n", binfo.Type, binfo.BucketName, binfo.Prefix)
+	fmt.Printf("%d versions, live version at %v marked as LIVE\n\n", len(timeline), ts)
+	printTimeline(timeline)
+
+	return nil
+}
+
+func printTimeline(timeline []showVersion) {
+	var previous *showVersion
+
+	for i := range timeline {
+		v := &timeline[i]
+
+		var live string
+		if v.Live {
+			live = " LIVE"
+		}
+
+		if v.DeleteMarker {
+			fmt.Printf("  Delete marker %s%s\n", v.ID, live)
+		} else {
+			fmt.Printf("  Version %s%s\n", v.ID, live)
+		}
+
+		for _, t := range v.Times {
+			fmt.Printf("      %s: %s\n", t.Name, t.Value)
+		}
+
+		if v.DeleteMarker {
+			continue
+		}
+
+		changes := make(map[string]brestore.MetadataChange)
+		if previous != nil {
+			for _, c := range brestore.DiffMetadata(previous.Attrs, v.Attrs) {
+				changes[c.Field] = c
+			}
+		}
+
+		var fields []string
+		for field := range v.Attrs {
+			fields = append(fields, field)
+		}
+		for field := range changes {
+			if _, ok := v.Attrs[field]; !ok {
+				fields = append(fields, field)
+			}
+		}
+		sort.Strings(fields)
+
+		for _, field := range fields {
+			value := v.Attrs[field]
+			if value == "" {
+				value = "(not set)"
+			}
+
+			if c, ok := changes[field]; ok {
+				old := c.Old
+				if old == "" {
+					old = "(not set)"
+				}
+				fmt.Printf("    * %s: %s (was: %s)\n", field, value, old)
+			} else {
+				fmt.Printf("      %s: %s\n", field, value)
+			}
+		}
+
+		previous = v
+	}
+}
@@ -0,0 +1,83 @@
+// Copyright 2021 VILT Group
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package appcmds
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/viltgroup/bucket-restore/internal/brestore"
+	"github.com/viltgroup/bucket-restore/internal/brestore/awsrestore"
+	"github.com/viltgroup/bucket-restore/internal/brestore/awsrestore/history"
+	"github.com/viltgroup/bucket-restore/internal/brestore/awsrestore/history/versions"
+)
+
+func showAWS(profile string, bucketName string, key string, timestamp time.Time) ([]showVersion, error) {
+	client, err := awsrestore.GetS3Client(profile)
+	if err != nil {
+		return nil, fmt.Errorf("unable to get s3 client for profile '%v': %w", profile, err)
+	}
+
+	allVersions, err := versions.OfPathByName(client, bucketName, key)
+	if err != nil {
+		return nil, fmt.Errorf("listing versions of object: %w", err)
+	}
+
+	vs := allVersions[key]
+	if len(vs) == 0 {
+		return nil, nil
+	}
+
+	vs.SortByLastModifiedAsc()
+	live := history.StateAtTime(vs, timestamp)
+
+	var timeline []showVersion
+
+	for _, v := range vs {
+		sv := showVersion{
+			ID:           v.ID,
+			DeleteMarker: v.IsDeleteMarker,
+			Live:         live.PathStatus != history.NOT_EXISTENT && live.Version.ID == v.ID,
+			Times:        []showField{{Name: "Last modified", Value: v.LastModified.String()}},
+		}
+
+		if !v.IsDeleteMarker {
+			details, err := versions.DetailsOf(client, bucketName, v)
+			if err != nil {
+				return nil, err
+			}
+
+			sv.Attrs = map[string]string{
+				"Size":                   fmt.Sprintf("%s (%d bytes)", brestore.ByteCountIECString(v.Size), v.Size),
+				"ETag":                   v.ETag,
+				"Storage class":          v.StorageClass,
+				"Content-Type":           details.ContentType,
+				"Content-Encoding":       details.ContentEncoding,
+				"Content-Disposition":    details.ContentDisposition,
+				"Content-Language":       details.ContentLanguage,
+				"Cache-Control":          details.CacheControl,
+				"Server-side encryption": details.ServerSideEncryption,
+				"KMS key":                details.SSEKMSKeyID,
+			}
+			for k, value := range details.Metadata {
+				sv.Attrs["x-amz-meta-"+k] = value
+			}
+		}
+
+		timeline = append(timeline, sv)
+	}
+
+	return timeline, nil
+}
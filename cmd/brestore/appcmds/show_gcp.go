@@ -0,0 +1,88 @@
+// Copyright 2021 VILT Group
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package appcmds
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/viltgroup/bucket-restore/internal/brestore"
+	"github.com/viltgroup/bucket-restore/internal/brestore/gcprestore"
+	gcp_history "github.com/viltgroup/bucket-restore/internal/brestore/gcprestore/history"
+	gcp_generations "github.com/viltgroup/bucket-restore/internal/brestore/gcprestore/history/generations"
+)
+
+func showGCP(keyfile string, bucketName string, name string, timestamp time.Time) ([]showVersion, error) {
+	client, _, err := gcprestore.GetStorageClientFromFile(keyfile)
+	if err != nil {
+		return nil, fmt.Errorf("getting storage client for key file '%v': %w", keyfile, err)
+	}
+
+	allGens, err := gcp_generations.OfPathByName(client.Bucket(bucketName), name)
+	if err != nil {
+		return nil, fmt.Errorf("listing generations of object: %w", err)
+	}
+
+	gens := allGens[name]
+	if len(gens) == 0 {
+		return nil, nil
+	}
+
+	gens.SortByCreatedDateAsc()
+	live := gcp_history.StateAtTime(gens, timestamp)
+
+	var timeline []showVersion
+
+	for _, g := range gens {
+		sv := showVersion{
+			ID:    fmt.Sprintf("#%d", g.Generation),
+			Live:  live.PathStatus == gcp_history.EXISTS && live.Generation == g.Generation,
+			Times: []showField{{Name: "Created", Value: g.Created.String()}},
+		}
+
+		if !g.Updated.Equal(g.Created) {
+			sv.Times = append(sv.Times, showField{
+				Name:  "Updated",
+				Value: fmt.Sprintf("%v (metageneration %d)", g.Updated, g.Metageneration),
+			})
+		}
+		if !g.Deleted.IsZero() {
+			sv.Times = append(sv.Times, showField{Name: "Deleted", Value: g.Deleted.String()})
+		}
+
+		sv.Attrs = map[string]string{
+			"Size":                fmt.Sprintf("%s (%d bytes)", brestore.ByteCountIECString(g.Size), g.Size),
+			"MD5":                 fmt.Sprintf("%x", g.MD5),
+			"CRC32C":              fmt.Sprintf("%08x", g.CRC32C),
+			"Storage class":       g.StorageClass,
+			"Content-Type":        g.ContentType,
+			"Content-Encoding":    g.ContentEncoding,
+			"Content-Disposition": g.ContentDisposition,
+			"Content-Language":    g.ContentLanguage,
+			"Cache-Control":       g.CacheControl,
+			"KMS key":             g.KMSKeyName,
+		}
+		if !g.CustomTime.IsZero() {
+			sv.Attrs["Custom time"] = g.CustomTime.String()
+		}
+		for k, value := range g.Metadata {
+			sv.Attrs["x-goog-meta-"+k] = value
+		}
+
+		timeline = append(timeline, sv)
+	}
+
+	return timeline, nil
+}
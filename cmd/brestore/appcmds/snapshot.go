@@ -0,0 +1,80 @@
+// Copyright 2021 VILT Group
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package appcmds
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+var snapshotOutputFileFlag *string
+
+var snapshotExamples = "" +
+	"  Save the metadata of the objects in a gcp storage bucket to 'snapshot-mybucket-<time>.json':\n" +
+	"    brestore snapshot gs://mybucket\n\n" +
+	"  Save the metadata of the objects inside a path to a specific file:\n" +
+	"    brestore snapshot gs://mybucket/path --file \"~/snapshots/mybucket.json\""
+
+func init() {
+	snapshotOutputFileFlag = snapshotCmd.Flags().StringP("file", "f", "",
+		"file where the snapshot is saved. Defaults to 'snapshot-<bucket>-<time>.json' in the current directory.")
+
+	rootCmd.AddCommand(snapshotCmd)
+}
+
+var snapshotCmd = &cobra.Command{
+	Use:   "snapshot [bucket_url]",
+	Short: "Saves the metadata of the objects in a gcp storage bucket",
+	Long: "" +
+		"Description:\n" +
+		"  Saves the content type, content encoding, content language, content disposition, cache control and " +
+		"custom metadata of the live objects in a gcp storage bucket to a file. Changing the metadata of an " +
+		"object doesn't create a new generation, so the earlier metadata is lost. Give the snapshots to the " +
+		"--metadata-snapshot flag of the rollback command to restore metadata changed after they were taken. " +
+		"AWS S3 creates a new version for each metadata change, so it needs no snapshots. " +
+		"No changes are made to the bucket.",
+	Example:      snapshotExamples,
+	Args:         cobra.MaximumNArgs(1),
+	RunE:         snapshotEntryPoint,
+	SilenceUsage: true,
+}
+
+func snapshotEntryPoint(cmd *cobra.Command, args []string) error {
+	binfo, err := bucketURLFromArgs(args)
+	if err != nil {
+		return err
+	}
+
+	if binfo.Type != "gs" {
+		return fmt.Errorf("the snapshot command is only supported for gcp storage buckets. AWS S3 keeps " +
+			"the earlier metadata of an object in its versions")
+	}
+
+	taken := time.Now()
+	filename := *snapshotOutputFileFlag
+	if filename == "" {
+		filename = fmt.Sprintf("snapshot-%s-%s.json", binfo.BucketName, taken.UTC().Format("20060102T150405Z"))
+	}
+
+	n, err := snapshotGCP(*keyFileFlag, binfo.BucketName, binfo.Prefix, taken, filename)
+	if err != nil {
+		return fmt.Errorf("error performing snapshot command: %v", err)
+	}
+
+	fmt.Printf("Saved the metadata of %d objects to '%s'\n", n, filename)
+	return nil
+}
@@ -0,0 +1,59 @@
+// Copyright 2021 VILT Group
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package appcmds
+
+import (
+	"fmt"
+	"time"
+
+	"cloud.google.com/go/storage"
+	"github.com/viltgroup/bucket-restore/internal/brestore/gcprestore"
+	gcp_history "github.com/viltgroup/bucket-restore/internal/brestore/gcprestore/history"
+	"google.golang.org/api/iterator"
+)
+
+// snapshotGCP saves the metadata of the live objects in a path to a file and returns the number of objects.
+func snapshotGCP(keyfile string, bucketName string, path string, taken time.Time, filename string) (int, error) {
+	client, ctx, err := gcprestore.GetStorageClientFromFile(keyfile)
+	if err != nil {
+		return 0, fmt.Errorf("getting storage client for key file '%v': %w", keyfile, err)
+	}
+
+	snapshot := gcp_history.MetadataSnapshot{Bucket: bucketName, Prefix: path, Taken: taken}
+
+	it := client.Bucket(bucketName).Objects(ctx, &storage.Query{Prefix: path})
+	for {
+		attrs, err := it.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return 0, fmt.Errorf("listing contents of bucket: %w", err)
+		}
+		snapshot.Objects = append(snapshot.Objects, gcp_history.SnapshotEntry{
+			Name:           attrs.Name,
+			Generation:     attrs.Generation,
+			Metageneration: attrs.Metageneration,
+			Updated:        attrs.Updated,
+			ObjectMetadata: gcp_history.MetadataOf(attrs),
+		})
+	}
+
+	if err := saveJSONToFile(filename, snapshot); err != nil {
+		return 0, fmt.Errorf("writing snapshot to '%s': %w", filename, err)
+	}
+
+	return len(snapshot.Objects), nil
+}
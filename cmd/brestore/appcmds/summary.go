@@ -0,0 +1,42 @@
+// Copyright 2021 VILT Group
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package appcmds
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/viltgroup/bucket-restore/internal/brestore"
+)
+
+// printPlanSummary prints a table with the counters of each group of a rollback plan summary.
+func printPlanSummary(scheme string, bucketName string, summary *brestore.PlanSummary) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+
+	fmt.Fprintf(w, "Create\tDelete\tNo Action\tUnrecoverable\tBlocked\tBytes to copy\tPath\n")
+	for _, g := range summary.Groups() {
+		fmt.Fprintf(w, "%d\t%d\t%d\t%d\t%d\t%s\t%s://%s/%s\n",
+			g.ToCreate, g.ToDelete, g.NoAction, g.Unrecoverable, g.Blocked,
+			brestore.ByteCountIECString(g.BytesToCopy), scheme, bucketName, g.Prefix)
+	}
+
+	total := summary.Total()
+	fmt.Fprintf(w, "%d\t%d\t%d\t%d\t%d\t%s\t%s\n",
+		total.ToCreate, total.ToDelete, total.NoAction, total.Unrecoverable, total.Blocked,
+		brestore.ByteCountIECString(total.BytesToCopy), "Total")
+
+	w.Flush()
+}
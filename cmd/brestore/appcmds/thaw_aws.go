@@ -0,0 +1,229 @@
+// Copyright 2021 VILT Group
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package appcmds
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/viltgroup/bucket-restore/internal/brestore/awsrestore"
+	"github.com/viltgroup/bucket-restore/internal/brestore/awsrestore/history"
+)
+
+// thawState represents the archived versions being thawed for a rollback. It's kept between runs,
+// so the rollback can be run again to copy them once they are readable.
+type thawState struct {
+	Bucket      string        `json:"bucket"`
+	Prefix      string        `json:"prefix"`
+	RestoreTime time.Time     `json:"restore_time"`
+	Thaws       []pendingThaw `json:"thaws"`
+}
+
+// pendingThaw represents an archived version being thawed.
+type pendingThaw struct {
+	Key          string    `json:"key"`
+	VersionID    string    `json:"version_id"`
+	StorageClass string    `json:"storage_class"`
+	Tier         string    `json:"tier"`
+	Requested    time.Time `json:"requested"`
+}
+
+// thawStateFile returns the path of the thaw state file of a bucket.
+func thawStateFile(bucketName string) string {
+	if *thawStateFileFlag != "" {
+		return *thawStateFileFlag
+	}
+	return fmt.Sprintf("thaw-%s.json", bucketName)
+}
+
+// loadThawState reads the thaw state file. A missing file is an empty state.
+func loadThawState(path string) (thawState, error) {
+	var state thawState
+
+	content, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return state, nil
+	}
+	if err != nil {
+		return state, fmt.Errorf("reading thaw state: %w", err)
+	}
+	if err := json.Unmarshal(content, &state); err != nil {
+		return state, fmt.Errorf("parsing thaw state '%s': %w", path, err)
+	}
+	return state, nil
+}
+
+// saveThawState writes the thaw state file, or removes it when no versions are being thawed.
+func saveThawState(path string, state thawState) error {
+	if len(state.Thaws) == 0 {
+		err := os.Remove(path)
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return fmt.Errorf("removing thaw state: %w", err)
+		}
+		return nil
+	}
+
+	if err := saveJSONToFile(path, state); err != nil {
+		return fmt.Errorf("writing thaw state: %w", err)
+	}
+	return nil
+}
+
+// thawArchivedAWS removes the copies of archived versions that can't be read yet from the actions of
+// a rollback, and starts thawing those versions. The versions being thawed are saved to the thaw state
+// file, so the rollback can be run again to copy them once they are readable.
+// Returns the actions that can run now, the versions being thawed and the errors of the versions whose
+// thaw couldn't be started, which are left out of both.
+func thawArchivedAWS(client *s3.S3, bucketName string, path string, timestamp time.Time,
+	actions history.FileActions) (history.FileActions, []pendingThaw, []error, error) {
+
+	stateFile := thawStateFile(bucketName)
+	state, err := loadThawState(stateFile)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	if len(state.Thaws) > 0 &&
+		(state.Bucket != bucketName || state.Prefix != path || !state.RestoreTime.Equal(timestamp)) {
+		return nil, nil, nil, fmt.Errorf("the thaw state file '%s' belongs to a rollback of 's3://%s/%s' to %v. "+
+			"Run that rollback again to finish it, or remove the file", stateFile, state.Bucket, state.Prefix,
+			state.RestoreTime)
+	}
+
+	previous := map[string]pendingThaw{}
+	for _, t := range state.Thaws {
+		previous[t.Key+"#"+t.VersionID] = t
+	}
+
+	var ready history.FileActions
+	var pending []pendingThaw
+	var thawErrors []error
+	now := time.Now()
+
+	for _, action := range actions {
+		if action.Action != history.CREATE || !awsrestore.MayBeArchived(action.Source.StorageClass) {
+			ready = append(ready, action)
+			continue
+		}
+
+		keys := restoreKeys.KeysFor(action.Source.Key)
+		status, err := awsrestore.ThawStatusOf(client, bucketName, action.Source.Key, action.Source.Version,
+			action.Source.StorageClass, keys.SourceCustomerKey)
+		if err != nil {
+			// the copy fails and reports the error if the version can't be read
+			ready = append(ready, action)
+			continue
+		}
+		if status.Readable() {
+			ready = append(ready, action)
+			continue
+		}
+
+		thaw, ok := previous[action.Source.Key+"#"+action.Source.Version]
+		if !ok {
+			thaw = pendingThaw{
+				Key:          action.Source.Key,
+				VersionID:    action.Source.Version,
+				StorageClass: action.Source.StorageClass,
+				Tier:         *thawTierFlag,
+				Requested:    now,
+			}
+		}
+		if !status.Ongoing {
+			if !awsrestore.ThawTierSupported(action.Source.StorageClass, status, thaw.Tier) {
+				thawErrors = append(thawErrors, fmt.Errorf("thawing '%s' version '%s': the %s tier can't thaw "+
+					"versions in %s. Use --thaw-tier Standard or Bulk", action.Source.Key, action.Source.Version,
+					thaw.Tier, archiveOf(action.Source.StorageClass, status)))
+				continue
+			}
+			err = awsrestore.Thaw(client, bucketName, action.Source.Key, action.Source.Version, status,
+				thaw.Tier, *thawDaysFlag)
+			if err != nil {
+				thawErrors = append(thawErrors, err)
+				continue
+			}
+		}
+		pending = append(pending, thaw)
+	}
+
+	// the thaws requested before a failure must be saved too
+	err = saveThawState(stateFile, thawState{
+		Bucket:      bucketName,
+		Prefix:      path,
+		RestoreTime: timestamp,
+		Thaws:       pending,
+	})
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	return ready, pending, thawErrors, nil
+}
+
+// archiveOf returns the archive a version is in: its storage class, or the archive access tier of
+// Intelligent-Tiering.
+func archiveOf(storageClass string, status awsrestore.ThawStatus) string {
+	if status.ArchiveStatus != "" {
+		return status.ArchiveStatus
+	}
+	return storageClass
+}
+
+// printPendingThaws prints the versions being thawed and how to finish the rollback.
+func printPendingThaws(bucketName string, pending []pendingThaw) {
+	if len(pending) == 0 {
+		return
+	}
+
+	byClass := map[string]int{}
+	for _, t := range pending {
+		byClass[t.StorageClass]++
+	}
+	var classes []string
+	for c, n := range byClass {
+		classes = append(classes, fmt.Sprintf("%d in %s", n, c))
+	}
+	sort.Strings(classes)
+
+	fmt.Printf("\n%d archived versions are being thawed (%s). Run the rollback again once they are readable "+
+		"to copy them.\nThe versions being thawed were saved to '%s'.\n",
+		len(pending), strings.Join(classes, ", "), thawStateFile(bucketName))
+}
+
+// printArchivedAWS prints the number of objects to create from archived versions, by storage class,
+// which must be thawed before they are copied.
+func printArchivedAWS(archived map[string]int) {
+	if len(archived) == 0 {
+		return
+	}
+
+	var classes []string
+	total := 0
+	for c, n := range archived {
+		classes = append(classes, fmt.Sprintf("%d in %s", n, c))
+		total += n
+	}
+	sort.Strings(classes)
+
+	fmt.Printf("Archived versions: %d of the objects to create are restored from archived versions (%s), which "+
+		"are thawed with the %s tier before they are copied. The rollback must be run again once they are "+
+		"readable.\n\n", total, strings.Join(classes, ", "), *thawTierFlag)
+}
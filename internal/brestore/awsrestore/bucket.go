@@ -0,0 +1,113 @@
+// Copyright 2021 VILT Group
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package awsrestore
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/s3"
+)
+
+// VersioningStatus gets the versioning status of a bucket: "Enabled", "Suspended", or the empty string if
+// versioning was never enabled.
+func VersioningStatus(client *s3.S3, bucketName string) (string, error) {
+	out, err := client.GetBucketVersioning(&s3.GetBucketVersioningInput{
+		Bucket: aws.String(bucketName),
+	})
+	if err != nil {
+		return "", fmt.Errorf("getting versioning status of bucket '%s': %w", bucketName, err)
+	}
+
+	return aws.StringValue(out.Status), nil
+}
+
+// EnableVersioning enables versioning in a bucket.
+func EnableVersioning(client *s3.S3, bucketName string) error {
+	_, err := client.PutBucketVersioning(&s3.PutBucketVersioningInput{
+		Bucket: aws.String(bucketName),
+		VersioningConfiguration: &s3.VersioningConfiguration{
+			Status: aws.String(s3.BucketVersioningStatusEnabled),
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("enabling versioning of bucket '%s': %w", bucketName, err)
+	}
+	return nil
+}
+
+// ObjectLockConfigurationOf gets the object lock configuration of a bucket, or nil if it has none.
+func ObjectLockConfigurationOf(client *s3.S3, bucketName string) (*s3.ObjectLockConfiguration, error) {
+	out, err := client.GetObjectLockConfiguration(&s3.GetObjectLockConfigurationInput{
+		Bucket: aws.String(bucketName),
+	})
+	if isErrorCode(err, "ObjectLockConfigurationNotFoundError") {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("getting object lock configuration of bucket '%s': %w", bucketName, err)
+	}
+
+	return out.ObjectLockConfiguration, nil
+}
+
+// ObjectLockEnabled checks if a bucket has S3 Object Lock enabled.
+func ObjectLockEnabled(client *s3.S3, bucketName string) (bool, error) {
+	conf, err := ObjectLockConfigurationOf(client, bucketName)
+	if err != nil {
+		return false, err
+	}
+
+	return conf != nil && aws.StringValue(conf.ObjectLockEnabled) == s3.ObjectLockEnabledEnabled, nil
+}
+
+// ReplicationOf gets the replication configuration of a bucket, or nil if it has none.
+func ReplicationOf(client *s3.S3, bucketName string) (*s3.ReplicationConfiguration, error) {
+	out, err := client.GetBucketReplication(&s3.GetBucketReplicationInput{
+		Bucket: aws.String(bucketName),
+	})
+	if isErrorCode(err, "ReplicationConfigurationNotFoundError") {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("getting replication configuration of bucket '%s': %w", bucketName, err)
+	}
+
+	return out.ReplicationConfiguration, nil
+}
+
+// isErrorCode checks if err is an AWS error with the given code.
+func isErrorCode(err error, code string) bool {
+	var aerr awserr.Error
+	return errors.As(err, &aerr) && aerr.Code() == code
+}
+
+// BucketCreationDate gets the creation date of a bucket owned by the caller's account.
+func BucketCreationDate(client *s3.S3, bucketName string) (time.Time, error) {
+	out, err := client.ListBuckets(&s3.ListBucketsInput{})
+	if err != nil {
+		return time.Time{}, fmt.Errorf("listing buckets: %w", err)
+	}
+
+	for _, b := range out.Buckets {
+		if aws.StringValue(b.Name) == bucketName {
+			return aws.TimeValue(b.CreationDate), nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("bucket '%s' not found in the buckets of the account", bucketName)
+}
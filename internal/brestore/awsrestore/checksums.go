@@ -0,0 +1,94 @@
+// Copyright 2021 VILT Group
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package awsrestore
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/s3"
+)
+
+// ChecksumAlgorithms are the algorithms of the S3 additional checksums, in order of preference.
+var ChecksumAlgorithms = []string{"SHA256", "SHA1", "CRC64NVME", "CRC32C", "CRC32"}
+
+// ContentInfo represents what is known about the content of a version of an object, besides its ETag.
+type ContentInfo struct {
+	// Server-side encryption of the version, e.g. "aws:kms"
+	ServerSideEncryption string
+	// Algorithm of the customer-provided key, if the version is encrypted with SSE-C
+	SSECustomerAlgorithm string
+	// Additional checksums of the version by algorithm, e.g. "SHA256". The checksums of multipart uploads
+	// are checksums of the checksums of the parts, and end with "-<number of parts>"
+	Checksums map[string]string
+}
+
+// ContentInfoOf gets the encryption and the additional checksums of a version of an object. Versions
+// encrypted with a customer-supplied key (SSE-C) can only be read with that key.
+func ContentInfoOf(client *s3.S3, bucketName string, key string, versionID string,
+	customerKey []byte) (ContentInfo, error) {
+
+	in := &s3.HeadObjectInput{
+		Bucket:    aws.String(bucketName),
+		Key:       aws.String(key),
+		VersionId: aws.String(versionID),
+	}
+	if len(customerKey) > 0 {
+		in.SSECustomerAlgorithm = aws.String(s3.ServerSideEncryptionAes256)
+		in.SSECustomerKey = aws.String(string(customerKey))
+	}
+
+	req, out := client.HeadObjectRequest(in)
+	// the version of the SDK in use doesn't support the checksum mode parameter
+	req.HTTPRequest.Header.Set("x-amz-checksum-mode", "ENABLED")
+
+	if err := req.Send(); err != nil {
+		return ContentInfo{}, fmt.Errorf("getting checksums of '%s' version '%s': %w", key, versionID, err)
+	}
+
+	res := ContentInfo{
+		ServerSideEncryption: aws.StringValue(out.ServerSideEncryption),
+		SSECustomerAlgorithm: aws.StringValue(out.SSECustomerAlgorithm),
+		Checksums:            map[string]string{},
+	}
+	for _, alg := range ChecksumAlgorithms {
+		if v := req.HTTPResponse.Header.Get("x-amz-checksum-" + strings.ToLower(alg)); v != "" {
+			res.Checksums[alg] = v
+		}
+	}
+
+	return res, nil
+}
+
+// DefaultEncryptionOf gets the algorithm of the default encryption of a bucket, e.g. "aws:kms",
+// or the empty string if it has none.
+func DefaultEncryptionOf(client *s3.S3, bucketName string) (string, error) {
+	out, err := client.GetBucketEncryption(&s3.GetBucketEncryptionInput{
+		Bucket: aws.String(bucketName),
+	})
+	if isErrorCode(err, "ServerSideEncryptionConfigurationNotFoundError") {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("getting default encryption of bucket '%s': %w", bucketName, err)
+	}
+
+	conf := out.ServerSideEncryptionConfiguration
+	if conf == nil || len(conf.Rules) == 0 || conf.Rules[0].ApplyServerSideEncryptionByDefault == nil {
+		return "", nil
+	}
+	return aws.StringValue(conf.Rules[0].ApplyServerSideEncryptionByDefault.SSEAlgorithm), nil
+}
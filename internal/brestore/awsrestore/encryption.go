@@ -0,0 +1,87 @@
+// Copyright 2021 VILT Group
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package awsrestore
+
+import (
+	"fmt"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/viltgroup/bucket-restore/internal/brestore/encryption"
+)
+
+// CopyEncryption represents the encryption parameters of a copy of a version of an object.
+type CopyEncryption struct {
+	// Customer key of the version, if it's encrypted with SSE-C
+	CopySourceSSECustomerAlgorithm *string
+	CopySourceSSECustomerKey       *string
+	// Customer key of the copy, if it must be encrypted with SSE-C
+	SSECustomerAlgorithm *string
+	SSECustomerKey       *string
+	// Server-side encryption of the copy and its KMS key, if encrypted with SSE-KMS
+	ServerSideEncryption *string
+	SSEKMSKeyId          *string
+}
+
+// NewCopyEncryption returns the encryption parameters of a copy of a version with the given keys.
+// Without a destination key, S3 would encrypt the copy with the default encryption of the bucket,
+// so the copy keeps the server-side encryption and KMS key of the version, read from head.
+// head is only used, and may be nil, when the keys choose the encryption of the copy.
+func NewCopyEncryption(keys encryption.Keys, head *s3.HeadObjectOutput) CopyEncryption {
+	var res CopyEncryption
+
+	if len(keys.SourceCustomerKey) > 0 {
+		res.CopySourceSSECustomerAlgorithm = aws.String(s3.ServerSideEncryptionAes256)
+		res.CopySourceSSECustomerKey = aws.String(string(keys.SourceCustomerKey))
+	}
+
+	switch {
+	case len(keys.DestinationCustomerKey) > 0:
+		res.SSECustomerAlgorithm = aws.String(s3.ServerSideEncryptionAes256)
+		res.SSECustomerKey = aws.String(string(keys.DestinationCustomerKey))
+	case keys.KMSKey != "":
+		res.ServerSideEncryption = aws.String(s3.ServerSideEncryptionAwsKms)
+		res.SSEKMSKeyId = aws.String(keys.KMSKey)
+	case head != nil && aws.StringValue(head.ServerSideEncryption) != "":
+		res.ServerSideEncryption = head.ServerSideEncryption
+		if aws.StringValue(head.ServerSideEncryption) == s3.ServerSideEncryptionAwsKms {
+			res.SSEKMSKeyId = head.SSEKMSKeyId
+		}
+	}
+
+	return res
+}
+
+// VersionHead gets the metadata of a version of an object. Versions encrypted with SSE-C can only
+// be read with their customer key.
+func VersionHead(client *s3.S3, bucketName string, key string, versionID string,
+	customerKey []byte) (*s3.HeadObjectOutput, error) {
+
+	in := &s3.HeadObjectInput{
+		Bucket:    aws.String(bucketName),
+		Key:       aws.String(key),
+		VersionId: aws.String(versionID),
+	}
+	if len(customerKey) > 0 {
+		in.SSECustomerAlgorithm = aws.String(s3.ServerSideEncryptionAes256)
+		in.SSECustomerKey = aws.String(string(customerKey))
+	}
+
+	out, err := client.HeadObject(in)
+	if err != nil {
+		return nil, fmt.Errorf("getting metadata of '%s' version '%s': %w", key, versionID, err)
+	}
+	return out, nil
+}
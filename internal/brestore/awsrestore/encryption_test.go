@@ -0,0 +1,84 @@
+// Copyright 2021 VILT Group
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package awsrestore
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/viltgroup/bucket-restore/internal/brestore/encryption"
+)
+
+func TestNewCopyEncryption(t *testing.T) {
+	keyA := bytes.Repeat([]byte{'a'}, encryption.CustomerKeySize)
+	keyB := bytes.Repeat([]byte{'b'}, encryption.CustomerKeySize)
+	kmsHead := &s3.HeadObjectOutput{
+		ServerSideEncryption: aws.String(s3.ServerSideEncryptionAwsKms),
+		SSEKMSKeyId:          aws.String("arn:aws:kms:eu-west-1:111122223333:key/source"),
+	}
+	s3Head := &s3.HeadObjectOutput{
+		ServerSideEncryption: aws.String(s3.ServerSideEncryptionAes256),
+		SSEKMSKeyId:          aws.String("ignored"),
+	}
+
+	var tests = []struct {
+		keys     encryption.Keys
+		head     *s3.HeadObjectOutput
+		expected CopyEncryption
+	}{
+		{encryption.Keys{}, nil, CopyEncryption{}},
+		{encryption.Keys{}, kmsHead, CopyEncryption{
+			ServerSideEncryption: aws.String("aws:kms"),
+			SSEKMSKeyId:          aws.String("arn:aws:kms:eu-west-1:111122223333:key/source"),
+		}},
+		{encryption.Keys{}, s3Head, CopyEncryption{ServerSideEncryption: aws.String("AES256")}},
+		{encryption.Keys{KMSKey: "alias/restored"}, kmsHead, CopyEncryption{
+			ServerSideEncryption: aws.String("aws:kms"),
+			SSEKMSKeyId:          aws.String("alias/restored"),
+		}},
+		{encryption.Keys{SourceCustomerKey: keyA, DestinationCustomerKey: keyB}, nil, CopyEncryption{
+			CopySourceSSECustomerAlgorithm: aws.String("AES256"),
+			CopySourceSSECustomerKey:       aws.String(string(keyA)),
+			SSECustomerAlgorithm:           aws.String("AES256"),
+			SSECustomerKey:                 aws.String(string(keyB)),
+		}},
+	}
+
+	for i, test := range tests {
+		res := NewCopyEncryption(test.keys, test.head)
+		if !equalCopyEncryption(res, test.expected) {
+			t.Fatalf("test %d: expected %+v | got: %+v", i, test.expected, res)
+		}
+	}
+}
+
+func equalCopyEncryption(a CopyEncryption, b CopyEncryption) bool {
+	pairs := [][2]*string{
+		{a.CopySourceSSECustomerAlgorithm, b.CopySourceSSECustomerAlgorithm},
+		{a.CopySourceSSECustomerKey, b.CopySourceSSECustomerKey},
+		{a.SSECustomerAlgorithm, b.SSECustomerAlgorithm},
+		{a.SSECustomerKey, b.SSECustomerKey},
+		{a.ServerSideEncryption, b.ServerSideEncryption},
+		{a.SSEKMSKeyId, b.SSEKMSKeyId},
+	}
+	for _, p := range pairs {
+		if (p[0] == nil) != (p[1] == nil) || aws.StringValue(p[0]) != aws.StringValue(p[1]) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,73 @@
+// Copyright 2021 VILT Group
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package history
+
+import (
+	"strings"
+
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/viltgroup/bucket-restore/internal/brestore/awsrestore"
+)
+
+// Ways of comparing the content of two versions, besides the additional checksum algorithms.
+const (
+	ETagChecksum = "ETag"
+	SizeChecksum = "size"
+)
+
+// MultipartETag checks if an ETag is of an object uploaded in parts. Such ETags depend on the part size,
+// so they are not the MD5 of the content.
+func MultipartETag(etag string) bool {
+	return strings.Contains(etag, "-")
+}
+
+// ETagIsMD5 checks if the ETag of a version is the MD5 of its content. It isn't for multipart uploads,
+// and for versions encrypted with SSE-KMS or SSE-C.
+func ETagIsMD5(etag string, info awsrestore.ContentInfo) bool {
+	return !MultipartETag(etag) &&
+		info.ServerSideEncryption != s3.ServerSideEncryptionAwsKms &&
+		info.SSECustomerAlgorithm == ""
+}
+
+// SameContent checks if two existing versions have the same content. Versions of different sizes have
+// different content. Otherwise, ETags are compared when both are the MD5 of the content, and the additional
+// checksums when they aren't. Checksums of multipart uploads with different parts can't be compared, in
+// which case the versions are assumed to be different. Also returns how the content was compared.
+func SameContent(a PathState, b PathState, ia awsrestore.ContentInfo, ib awsrestore.ContentInfo) (bool, string) {
+	if a.Size != b.Size {
+		return false, SizeChecksum
+	}
+	if a.ETag == b.ETag {
+		return true, ETagChecksum
+	}
+	if ETagIsMD5(a.ETag, ia) && ETagIsMD5(b.ETag, ib) {
+		return false, ETagChecksum
+	}
+
+	for _, alg := range awsrestore.ChecksumAlgorithms {
+		va, vb := ia.Checksums[alg], ib.Checksums[alg]
+		if va == "" || vb == "" {
+			continue
+		}
+		if va == vb {
+			return true, alg
+		}
+		if !MultipartETag(va) && !MultipartETag(vb) {
+			return false, alg
+		}
+	}
+
+	return false, ETagChecksum
+}
@@ -0,0 +1,63 @@
+// Copyright 2021 VILT Group
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package history
+
+import (
+	"testing"
+
+	"github.com/viltgroup/bucket-restore/internal/brestore/awsrestore"
+	"github.com/viltgroup/bucket-restore/internal/brestore/awsrestore/history/versions"
+)
+
+func stateWithETag(etag string, size int64) PathState {
+	return PathState{PathStatus: EXISTS, Version: versions.Version{ETag: etag, Size: size}}
+}
+
+func TestSameContent(t *testing.T) {
+	plain := awsrestore.ContentInfo{}
+	kms := awsrestore.ContentInfo{ServerSideEncryption: "aws:kms"}
+	sha := func(v string) awsrestore.ContentInfo {
+		return awsrestore.ContentInfo{Checksums: map[string]string{"SHA256": v}}
+	}
+
+	var tests = []struct {
+		a, b     PathState
+		ia, ib   awsrestore.ContentInfo
+		same     bool
+		checksum string
+	}{
+		{stateWithETag("a", 1), stateWithETag("a", 2), plain, plain, false, SizeChecksum},
+		{stateWithETag("a", 1), stateWithETag("b", 1), plain, plain, false, ETagChecksum},
+		{stateWithETag("a", 1), stateWithETag("a", 1), plain, plain, true, ETagChecksum},
+		// multipart ETags depend on the part size
+		{stateWithETag("a-2", 1), stateWithETag("b-3", 1), sha("x"), sha("x"), true, "SHA256"},
+		{stateWithETag("a-2", 1), stateWithETag("b-3", 1), sha("x"), sha("y"), false, "SHA256"},
+		// checksums of multipart uploads with different parts can't be compared
+		{stateWithETag("a-2", 1), stateWithETag("b-3", 1), sha("x-2"), sha("y-3"), false, ETagChecksum},
+		{stateWithETag("a-2", 1), stateWithETag("b-3", 1), plain, plain, false, ETagChecksum},
+		// SSE-KMS ETags are not the MD5 of the content
+		{stateWithETag("a", 1), stateWithETag("b", 1), kms, sha("x"), false, ETagChecksum},
+		{stateWithETag("a", 1), stateWithETag("b", 1),
+			awsrestore.ContentInfo{ServerSideEncryption: "aws:kms", Checksums: map[string]string{"CRC32C": "c"}},
+			awsrestore.ContentInfo{Checksums: map[string]string{"CRC32C": "c"}}, true, "CRC32C"},
+	}
+
+	for i, test := range tests {
+		same, checksum := SameContent(test.a, test.b, test.ia, test.ib)
+		if same != test.same || checksum != test.checksum {
+			t.Fatalf("test %d: expected %v by '%s' | got: %v by '%s'", i, test.same, test.checksum, same, checksum)
+		}
+	}
+}
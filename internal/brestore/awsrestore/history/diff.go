@@ -0,0 +1,112 @@
+// Copyright 2021 VILT Group
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package history
+
+import (
+	"time"
+
+	"github.com/viltgroup/bucket-restore/internal/brestore/awsrestore/history/versions"
+)
+
+// Enumeration of Changes.
+const (
+	// Represents a path with the same state at both points in time
+	UNCHANGED Change = iota
+	// Represents a path that did not exist at the first point in time and exists at the second
+	ADDED
+	// Represents a path that existed at the first point in time and does not exist at the second
+	REMOVED
+	// Represents a path that exists at both points in time, but with different versions
+	MODIFIED
+	// Represents a path that did not exist at both points in time, but was created and deleted in between
+	TRANSIENT
+)
+
+// Change represents how a path changed between two points in time.
+type Change int
+
+// String converts a Change to a string representation.
+// Implements the Stringer interface.
+func (c Change) String() string {
+	switch c {
+	case UNCHANGED:
+		return "Unchanged"
+	case ADDED:
+		return "Added"
+	case REMOVED:
+		return "Removed"
+	case MODIFIED:
+		return "Modified"
+	case TRANSIENT:
+		return "Transient"
+	default:
+		return "Unknown Change"
+	}
+}
+
+// PathChange represents the change of a path between two points in time.
+type PathChange struct {
+	// The kind of change
+	Change
+	// State of the path at the first point in time
+	From PathState
+	// State of the path at the second point in time
+	To PathState
+	// Versions and delete markers created between the two points in time
+	Window versions.Versions
+}
+
+// ChangeBetween gives the change of a file/object between two points in time, given its versions.
+// The collection of versions must refer to the same object/path.
+func ChangeBetween(vs versions.Versions, from time.Time, to time.Time) PathChange {
+	res := PathChange{
+		From: StateAtTime(vs, from),
+		To:   StateAtTime(vs, to),
+	}
+
+	for _, v := range vs {
+		// a version is part of the state at a time t only if it was created before t
+		if !v.LastModified.Before(from) && v.LastModified.Before(to) {
+			res.Window = append(res.Window, v)
+		}
+	}
+
+	existedBefore, existsAfter := res.From.PathStatus == EXISTS, res.To.PathStatus == EXISTS
+
+	switch {
+	case !existedBefore && existsAfter:
+		res.Change = ADDED
+	case existedBefore && !existsAfter:
+		res.Change = REMOVED
+	case existedBefore && existsAfter && res.From.Version.ID != res.To.Version.ID:
+		res.Change = MODIFIED
+	case !existedBefore && !existsAfter && hasObjectVersion(res.Window):
+		res.Change = TRANSIENT
+	default:
+		res.Change = UNCHANGED
+	}
+
+	return res
+}
+
+// hasObjectVersion checks if a collection of versions has at least one version that isn't a delete marker.
+func hasObjectVersion(vs versions.Versions) bool {
+	for _, v := range vs {
+		if !v.IsDeleteMarker {
+			return true
+		}
+	}
+	return false
+}
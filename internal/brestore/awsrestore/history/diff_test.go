@@ -0,0 +1,67 @@
+// Copyright 2021 VILT Group
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package history
+
+import (
+	"testing"
+	"time"
+
+	"github.com/viltgroup/bucket-restore/internal/brestore/awsrestore/history/versions"
+)
+
+func TestChangeBetween(t *testing.T) {
+	at := func(hour int) time.Time {
+		return time.Date(2021, 2, 21, hour, 0, 0, 0, time.UTC)
+	}
+	version := func(id string, hour int, etag string) versions.Version {
+		return versions.Version{Key: "a", ID: id, LastModified: at(hour), ETag: etag}
+	}
+	marker := func(id string, hour int) versions.Version {
+		return versions.Version{Key: "a", ID: id, LastModified: at(hour), IsDeleteMarker: true}
+	}
+
+	var tests = []struct {
+		vs       versions.Versions
+		from, to time.Time
+		change   Change
+		window   int
+	}{
+		// created inside the window
+		{versions.Versions{version("v1", 3, "x")}, at(2), at(4), ADDED, 1},
+		// deleted inside the window
+		{versions.Versions{version("v1", 1, "x"), marker("d1", 3)}, at(2), at(4), REMOVED, 1},
+		// modified inside the window
+		{versions.Versions{version("v1", 1, "x"), version("v2", 3, "y")}, at(2), at(4), MODIFIED, 1},
+		// unchanged
+		{versions.Versions{version("v1", 1, "x"), version("v2", 5, "y")}, at(2), at(4), UNCHANGED, 0},
+		// deleted and created again with the same content, which is a new version
+		{versions.Versions{version("v1", 1, "x"), marker("d1", 3), version("v2", 4, "x")}, at(2), at(5), MODIFIED, 2},
+		// created and deleted inside the window
+		{versions.Versions{version("v1", 3, "x"), marker("d1", 4)}, at(2), at(5), TRANSIENT, 2},
+		// a version created at the start of the window is part of it
+		{versions.Versions{version("v1", 1, "x"), version("v2", 2, "y")}, at(2), at(4), MODIFIED, 1},
+		// a version created at the end of the window is not
+		{versions.Versions{version("v1", 1, "x"), version("v2", 4, "y")}, at(2), at(4), UNCHANGED, 0},
+		{versions.Versions{}, at(2), at(4), UNCHANGED, 0},
+	}
+
+	for i, test := range tests {
+		res := ChangeBetween(test.vs, test.from, test.to)
+		if res.Change != test.change || len(res.Window) != test.window {
+			t.Fatalf("test %d: expected %v with %d versions in the window | got: %v with %d versions in the window",
+				i, test.change, test.window, res.Change, len(res.Window))
+		}
+	}
+}
@@ -0,0 +1,54 @@
+// Copyright 2021 VILT Group
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package history
+
+import (
+	"github.com/viltgroup/bucket-restore/internal/brestore/awsrestore/history/versions"
+	"github.com/viltgroup/bucket-restore/internal/brestore/timeline"
+)
+
+// Events returns the changes made to a file/object, given its versions.
+// The collection of versions must refer to the same object/path.
+func Events(vs versions.Versions) timeline.Events {
+	var res timeline.Events
+
+	vs.SortByLastModifiedAsc()
+
+	var live bool
+	var liveSize int64
+
+	for _, v := range vs {
+		e := timeline.Event{Key: v.Key, Time: v.LastModified, Version: v.ID}
+
+		switch {
+		case v.IsDeleteMarker && !live:
+			// a delete marker on top of a deleted object doesn't change anything
+			continue
+		case v.IsDeleteMarker:
+			e.Kind, e.PreviousSize = timeline.DELETE, liveSize
+			live, liveSize = false, 0
+		case live:
+			e.Kind, e.Size, e.PreviousSize = timeline.OVERWRITE, v.Size, liveSize
+			liveSize = v.Size
+		default:
+			e.Kind, e.Size = timeline.WRITE, v.Size
+			live, liveSize = true, v.Size
+		}
+
+		res = append(res, e)
+	}
+
+	return res
+}
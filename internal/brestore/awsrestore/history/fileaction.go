@@ -54,6 +54,8 @@ type FileOperand struct {
 	Version string
 	// Size of the file in bytes
 	Size int64
+	// Storage class of the version of the file
+	StorageClass string
 }
 
 // String converts an FileOperand to a string.
@@ -97,12 +99,19 @@ type FileAction struct {
 	// Unmodified since pre-condition. The action should only be applied if the current
 	// version of the object was not modified since this time.
 	UnmodifiedPreCondition time.Time
+	// How the content of the current and the desired versions was compared, if both exist
+	Checksum string
 }
 
 // ActionForStateChange determines the action that should be taken to transition
 // a file from a state to another.
 func ActionForStateChange(from PathState, to PathState) FileAction {
-	source := FileOperand{Key: to.Key, Version: to.Version.ID, Size: from.Size}
+	source := FileOperand{Key: to.Key, Version: to.Version.ID, Size: from.Size, StorageClass: to.StorageClass}
+
+	// the state of objects with expired history is unknown, so they are left as they are
+	if to.PathStatus == EXPIRED {
+		return FileAction{Action: NO_ACTION, Source: source}
+	}
 
 	switch from.PathStatus {
 	case DELETED:
@@ -116,10 +125,15 @@ func ActionForStateChange(from PathState, to PathState) FileAction {
 			source.Version = from.Version.ID
 			return FileAction{Action: DELETE, Source: source, UnmodifiedPreCondition: from.LastModified}
 		}
-		if to.Version != from.Version && to.ETag != from.ETag {
-			return FileAction{Action: CREATE, Source: source, UnmodifiedPreCondition: from.LastModified}
+		if to.Version == from.Version {
+			return FileAction{Action: NO_ACTION, Source: source}
 		}
-		return FileAction{Action: NO_ACTION, Source: source}
+		if to.ETag != from.ETag {
+			source.Size = to.Size
+			return FileAction{Action: CREATE, Source: source, UnmodifiedPreCondition: from.LastModified,
+				Checksum: ETagChecksum}
+		}
+		return FileAction{Action: NO_ACTION, Source: source, Checksum: ETagChecksum}
 	default:
 		return FileAction{Action: NO_ACTION, Source: source}
 	}
@@ -28,6 +28,9 @@ const (
 	EXISTS
 	// Represents a file that existed but has been deleted
 	DELETED
+	// Represents a file with no known state because the older part of its history was deleted,
+	// e.g. by lifecycle rules, so it's unknown whether it existed
+	EXPIRED
 )
 
 // PathStatus represents the status of a file in its history.
@@ -43,6 +46,8 @@ func (fs PathStatus) String() string {
 		return "Exists"
 	case DELETED:
 		return "Deleted"
+	case EXPIRED:
+		return "History Expired"
 	default:
 		return "Unknown Status"
 	}
@@ -53,6 +58,9 @@ type PathState struct {
 	// Status of the file in its lifetime
 	PathStatus
 	versions.Version
+	// Whether the object may have had a different state, written to the null version that follows this state
+	// and later overwritten in place by it. S3 keeps only the last write to the null version
+	NullOverwritten bool
 }
 
 // StateAtTime gives the state of a file/object at a certain point in time, given its versions.
@@ -81,7 +89,8 @@ func StateDiffAtTime(versions versions.Versions, t time.Time) (PathState, PathSt
 	}
 
 	if t.Before(firstVersion.LastModified) {
-		return PathState{PathStatus: NOT_EXISTENT, Version: firstVersion}, lastVersionState
+		return PathState{PathStatus: NOT_EXISTENT, Version: firstVersion, NullOverwritten: firstVersion.IsNull()},
+			lastVersionState
 	}
 
 	var res PathState
@@ -89,6 +98,7 @@ func StateDiffAtTime(versions versions.Versions, t time.Time) (PathState, PathSt
 	for i := nGens - 1; i >= 0; i-- {
 		if t.After(versions[i].LastModified) {
 			res = StateOfVersionAtTime(versions[i], t)
+			res.NullOverwritten = i+1 < nGens && versions[i+1].IsNull()
 			break
 		}
 	}
@@ -0,0 +1,58 @@
+// Copyright 2021 VILT Group
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package history
+
+import (
+	"testing"
+	"time"
+
+	"github.com/viltgroup/bucket-restore/internal/brestore/awsrestore/history/versions"
+)
+
+func TestStateDiffAtTimeNullVersions(t *testing.T) {
+	at := func(hour int) time.Time {
+		return time.Date(2021, 2, 21, hour, 0, 0, 0, time.UTC)
+	}
+	version := func(id string, hour int) versions.Version {
+		return versions.Version{Key: "a", ID: id, LastModified: at(hour)}
+	}
+
+	var tests = []struct {
+		vs              versions.Versions
+		t               time.Time
+		status          PathStatus
+		id              string
+		nullOverwritten bool
+	}{
+		{versions.Versions{version("v1", 1), version("null", 3)}, at(2), EXISTS, "v1", true},
+		{versions.Versions{version("v1", 1), version("null", 3)}, at(4), EXISTS, "null", false},
+		{versions.Versions{version("null", 1), version("v2", 3)}, at(2), EXISTS, "null", false},
+		{versions.Versions{version("null", 3)}, at(2), NOT_EXISTENT, "null", true},
+		{versions.Versions{version("v1", 3)}, at(2), NOT_EXISTENT, "v1", false},
+	}
+
+	for i, test := range tests {
+		state, _ := StateDiffAtTime(test.vs, test.t)
+		if state.PathStatus != test.status || state.ID != test.id || state.NullOverwritten != test.nullOverwritten {
+			t.Fatalf("test %d: expected %v at %s (null overwritten: %v) | got: %v at %s (null overwritten: %v)",
+				i, test.status, test.id, test.nullOverwritten, state.PathStatus, state.ID, state.NullOverwritten)
+		}
+	}
+
+	horizon := HistoryHorizon{Now: at(5)}
+	if state, _ := horizon.StateDiffAtTime(versions.Versions{version("null", 3)}, at(2)); state.PathStatus != EXPIRED {
+		t.Fatalf("expected history before a null version to be expired | got: %v", state.PathStatus)
+	}
+}
@@ -0,0 +1,69 @@
+// Copyright 2021 VILT Group
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package history
+
+import (
+	"time"
+
+	"github.com/viltgroup/bucket-restore/internal/brestore/awsrestore"
+	"github.com/viltgroup/bucket-restore/internal/brestore/awsrestore/history/versions"
+)
+
+// HistoryHorizon represents what limits how far back the history of the objects of a bucket goes.
+// A zero HistoryHorizon never considers the history of an object expired.
+type HistoryHorizon struct {
+	// Lifecycle rules of the bucket, which may have deleted noncurrent versions
+	Lifecycle awsrestore.Lifecycle
+	// Creation time of the bucket. No object existed before it
+	BucketCreated time.Time
+	// Time when the versions were listed
+	Now time.Time
+}
+
+// Expired checks if the versions of an object that were live at t may have been deleted, in which case it's
+// unknown whether the object existed at t. This happens when t is before the oldest known version and either
+// that version is a delete marker or a null version, which may replace an older version, or the lifecycle
+// rules would already have deleted an older version replaced by it. The collection of versions must refer to the same object/path.
+func (h HistoryHorizon) Expired(vs versions.Versions, t time.Time) bool {
+	if h.Now.IsZero() || len(vs) == 0 {
+		return false
+	}
+
+	vs.SortByLastModifiedAsc()
+	first := vs[0]
+
+	if !t.Before(first.LastModified) || t.Before(h.BucketCreated) {
+		return false
+	}
+
+	// delete markers hide an older version, and null versions may have overwritten one in place
+	if first.IsDeleteMarker || first.IsNull() {
+		return true
+	}
+
+	// an older version became noncurrent, at the latest, when the oldest known version was created
+	at, _, ok := h.Lifecycle.NoncurrentExpiry(first.Key, first.LastModified)
+	return ok && !at.After(h.Now)
+}
+
+// StateDiffAtTime is like the StateDiffAtTime function, but gives the EXPIRED status to objects whose
+// versions at t may have been deleted.
+func (h HistoryHorizon) StateDiffAtTime(vs versions.Versions, t time.Time) (PathState, PathState) {
+	res, last := StateDiffAtTime(vs, t)
+	if res.PathStatus == NOT_EXISTENT && h.Expired(vs, t) {
+		res.PathStatus = EXPIRED
+	}
+	return res, last
+}
@@ -0,0 +1,72 @@
+// Copyright 2021 VILT Group
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package history
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/viltgroup/bucket-restore/internal/brestore/awsrestore"
+	"github.com/viltgroup/bucket-restore/internal/brestore/awsrestore/history/versions"
+)
+
+func TestHistoryHorizonExpired(t *testing.T) {
+	day := func(d int) time.Time {
+		return time.Date(2021, 2, d, 0, 0, 0, 0, time.UTC)
+	}
+	version := versions.Versions{{Key: "a", ID: "v1", LastModified: day(10)}}
+	marker := versions.Versions{{Key: "a", ID: "d1", LastModified: day(10), IsDeleteMarker: true}}
+	null := versions.Versions{{Key: "a", ID: versions.NullVersionID, LastModified: day(10)}}
+	lc := awsrestore.Lifecycle{Rules: []*s3.LifecycleRule{{
+		ID:                          aws.String("noncurrent"),
+		Status:                      aws.String(s3.ExpirationStatusEnabled),
+		Filter:                      &s3.LifecycleRuleFilter{Prefix: aws.String("")},
+		NoncurrentVersionExpiration: &s3.NoncurrentVersionExpiration{NoncurrentDays: aws.Int64(7)},
+	}}}
+
+	var tests = []struct {
+		horizon  HistoryHorizon
+		vs       versions.Versions
+		t        time.Time
+		expected bool
+	}{
+		// the oldest version is a delete marker or a null version
+		{HistoryHorizon{Now: day(20)}, marker, day(5), true},
+		{HistoryHorizon{Now: day(20)}, null, day(5), true},
+		// the rule deleted the versions replaced by the oldest version on day 17
+		{HistoryHorizon{Lifecycle: lc, Now: day(20)}, version, day(5), true},
+		{HistoryHorizon{Lifecycle: lc, Now: day(12)}, version, day(5), false},
+		// t after the oldest version
+		{HistoryHorizon{Lifecycle: lc, Now: day(20)}, version, day(11), false},
+		// t before or after the creation of the bucket
+		{HistoryHorizon{Lifecycle: lc, Now: day(20), BucketCreated: day(6)}, version, day(5), false},
+		{HistoryHorizon{Lifecycle: lc, Now: day(20), BucketCreated: day(4)}, version, day(5), true},
+		{HistoryHorizon{Now: day(20), BucketCreated: day(6)}, marker, day(5), false},
+		// a zero horizon, as with --delete-expired-history, never expires
+		{HistoryHorizon{Lifecycle: lc}, marker, day(5), false},
+		// no lifecycle
+		{HistoryHorizon{Now: day(20)}, version, day(5), false},
+		{HistoryHorizon{Lifecycle: lc, Now: day(20)}, versions.Versions{}, day(5), false},
+	}
+
+	for i, test := range tests {
+		res := test.horizon.Expired(test.vs, test.t)
+		if res != test.expected {
+			t.Fatalf("test %d: expected %v | got: %v", i, test.expected, res)
+		}
+	}
+}
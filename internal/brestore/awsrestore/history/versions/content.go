@@ -0,0 +1,41 @@
+// Copyright 2021 VILT Group
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package versions
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/s3"
+)
+
+// ContentOf downloads the content of a version. The returned reader must be closed by the caller.
+func ContentOf(client *s3.S3, bucketName string, v Version) (io.ReadCloser, error) {
+	if v.IsDeleteMarker {
+		return nil, fmt.Errorf("version '%s' of '%s' is a delete marker and has no content", v.ID, v.Key)
+	}
+
+	out, err := client.GetObject(&s3.GetObjectInput{
+		Bucket:    aws.String(bucketName),
+		Key:       aws.String(v.Key),
+		VersionId: aws.String(v.ID),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("downloading version '%s' of '%s': %w", v.ID, v.Key, err)
+	}
+
+	return out.Body, nil
+}
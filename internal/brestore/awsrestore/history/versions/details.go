@@ -0,0 +1,63 @@
+// Copyright 2021 VILT Group
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package versions
+
+import (
+	"fmt"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/s3"
+)
+
+// Details represents the metadata of a version that is not returned when listing the versions of objects.
+type Details struct {
+	ContentType          string
+	ContentEncoding      string
+	ContentDisposition   string
+	ContentLanguage      string
+	CacheControl         string
+	ServerSideEncryption string
+	SSEKMSKeyID          string
+	// User defined metadata
+	Metadata map[string]string
+}
+
+// DetailsOf fetches the metadata of a version that is not returned when listing versions.
+// Delete markers have no metadata, so an empty Details is returned for them.
+func DetailsOf(client *s3.S3, bucketName string, v Version) (Details, error) {
+	if v.IsDeleteMarker {
+		return Details{}, nil
+	}
+
+	head, err := client.HeadObject(&s3.HeadObjectInput{
+		Bucket:    aws.String(bucketName),
+		Key:       aws.String(v.Key),
+		VersionId: aws.String(v.ID),
+	})
+	if err != nil {
+		return Details{}, fmt.Errorf("getting details of version '%s' of '%s': %w", v.ID, v.Key, err)
+	}
+
+	return Details{
+		ContentType:          aws.StringValue(head.ContentType),
+		ContentEncoding:      aws.StringValue(head.ContentEncoding),
+		ContentDisposition:   aws.StringValue(head.ContentDisposition),
+		ContentLanguage:      aws.StringValue(head.ContentLanguage),
+		CacheControl:         aws.StringValue(head.CacheControl),
+		ServerSideEncryption: aws.StringValue(head.ServerSideEncryption),
+		SSEKMSKeyID:          aws.StringValue(head.SSEKMSKeyId),
+		Metadata:             aws.StringValueMap(head.Metadata),
+	}, nil
+}
@@ -16,11 +16,16 @@ package versions
 
 import (
 	"fmt"
+	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/service/s3"
 	"strings"
 	"time"
 )
 
+// NullVersionID is the id of the version of an object written while versioning was not enabled or was
+// suspended. An object has at most one null version, which is overwritten in place by the next such write.
+const NullVersionID = "null"
+
 // Version represents a version or delete marker of an object in AWS
 type Version struct {
 	Key            string
@@ -30,6 +35,12 @@ type Version struct {
 	IsDeleteMarker bool
 	ETag           string
 	Size           int64
+	StorageClass   string
+}
+
+// IsNull checks if the version is the null version of the object.
+func (v *Version) IsNull() bool {
+	return v.ID == NullVersionID
 }
 
 // String converts a Version into a string.
@@ -84,6 +95,7 @@ func FromAWSVersion(obj *s3.ObjectVersion) Version {
 		IsDeleteMarker: false,
 		ETag:           strings.Trim(*obj.ETag, "\""),
 		Size:           *obj.Size,
+		StorageClass:   aws.StringValue(obj.StorageClass),
 	}
 }
 
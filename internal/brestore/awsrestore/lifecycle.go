@@ -0,0 +1,148 @@
+// Copyright 2021 VILT Group
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package awsrestore
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/s3"
+)
+
+// Lifecycle represents the lifecycle rules of a bucket.
+type Lifecycle struct {
+	Rules []*s3.LifecycleRule
+}
+
+// LifecycleOf gets the lifecycle rules of a bucket. Buckets without a lifecycle configuration have no rules.
+func LifecycleOf(client *s3.S3, bucketName string) (Lifecycle, error) {
+	out, err := client.GetBucketLifecycleConfiguration(&s3.GetBucketLifecycleConfigurationInput{
+		Bucket: aws.String(bucketName),
+	})
+	if isErrorCode(err, "NoSuchLifecycleConfiguration") {
+		return Lifecycle{}, nil
+	}
+	if err != nil {
+		return Lifecycle{}, fmt.Errorf("getting lifecycle configuration of bucket '%s': %w", bucketName, err)
+	}
+
+	return Lifecycle{Rules: out.Rules}, nil
+}
+
+// PutLifecycle replaces the lifecycle rules of a bucket.
+func PutLifecycle(client *s3.S3, bucketName string, rules []*s3.LifecycleRule) error {
+	_, err := client.PutBucketLifecycleConfiguration(&s3.PutBucketLifecycleConfigurationInput{
+		Bucket:                 aws.String(bucketName),
+		LifecycleConfiguration: &s3.BucketLifecycleConfiguration{Rules: rules},
+	})
+	if err != nil {
+		return fmt.Errorf("setting lifecycle configuration of bucket '%s': %w", bucketName, err)
+	}
+	return nil
+}
+
+// NoncurrentExpiry returns when a version of key that became noncurrent at noncurrentSince will be permanently
+// deleted by the lifecycle rules, and the id of the rule that deletes it first. The last value is false if
+// no rule deletes noncurrent versions of the key. Rules filtered by tags are assumed to apply to all objects.
+func (l Lifecycle) NoncurrentExpiry(key string, noncurrentSince time.Time) (time.Time, string, bool) {
+	var res time.Time
+	var ruleID string
+
+	for _, rule := range l.Rules {
+		if aws.StringValue(rule.Status) != s3.ExpirationStatusEnabled || rule.NoncurrentVersionExpiration == nil {
+			continue
+		}
+		if !strings.HasPrefix(key, rulePrefix(rule)) {
+			continue
+		}
+
+		days := aws.Int64Value(rule.NoncurrentVersionExpiration.NoncurrentDays)
+		expiry := expiryDate(noncurrentSince, days)
+		if res.IsZero() || expiry.Before(res) {
+			res, ruleID = expiry, aws.StringValue(rule.ID)
+		}
+	}
+
+	return res, ruleID, !res.IsZero()
+}
+
+// NoncurrentRules describes the enabled rules that permanently delete noncurrent versions.
+func (l Lifecycle) NoncurrentRules() []string {
+	var res []string
+	for _, rule := range l.Rules {
+		if aws.StringValue(rule.Status) != s3.ExpirationStatusEnabled || rule.NoncurrentVersionExpiration == nil {
+			continue
+		}
+		res = append(res, fmt.Sprintf("rule '%s' deletes noncurrent versions of '%s*' after %d days",
+			aws.StringValue(rule.ID), rulePrefix(rule),
+			aws.Int64Value(rule.NoncurrentVersionExpiration.NoncurrentDays)))
+	}
+	return res
+}
+
+// NoncurrentExpirationRuleID is the id of the lifecycle rule added by brestore to delete noncurrent versions.
+const NoncurrentExpirationRuleID = "brestore-noncurrent-expiration"
+
+// WithNoncurrentExpiration returns the rules of the lifecycle with a rule that deletes the noncurrent
+// versions of all objects after the given number of days. A rule previously added by brestore is replaced.
+// The last value is false if the lifecycle already has that rule.
+func (l Lifecycle) WithNoncurrentExpiration(days int64) ([]*s3.LifecycleRule, bool) {
+	rule := &s3.LifecycleRule{
+		ID:     aws.String(NoncurrentExpirationRuleID),
+		Status: aws.String(s3.ExpirationStatusEnabled),
+		Filter: &s3.LifecycleRuleFilter{Prefix: aws.String("")},
+		NoncurrentVersionExpiration: &s3.NoncurrentVersionExpiration{
+			NoncurrentDays: aws.Int64(days),
+		},
+	}
+
+	var res []*s3.LifecycleRule
+	for _, r := range l.Rules {
+		if aws.StringValue(r.ID) != NoncurrentExpirationRuleID {
+			res = append(res, r)
+			continue
+		}
+		if aws.StringValue(r.Status) == s3.ExpirationStatusEnabled && r.NoncurrentVersionExpiration != nil &&
+			aws.Int64Value(r.NoncurrentVersionExpiration.NoncurrentDays) == days {
+			return l.Rules, false
+		}
+	}
+
+	return append(res, rule), true
+}
+
+// rulePrefix returns the key prefix a rule applies to, from the deprecated prefix field or from its filter.
+func rulePrefix(rule *s3.LifecycleRule) string {
+	if rule.Filter == nil {
+		return aws.StringValue(rule.Prefix)
+	}
+	if rule.Filter.And != nil {
+		return aws.StringValue(rule.Filter.And.Prefix)
+	}
+	return aws.StringValue(rule.Filter.Prefix)
+}
+
+// expiryDate returns when S3 expires an object after the given number of days from t.
+// S3 rounds the expiry to the next midnight UTC.
+func expiryDate(t time.Time, days int64) time.Time {
+	expiry := t.UTC().Add(time.Duration(days) * 24 * time.Hour)
+	midnight := expiry.Truncate(24 * time.Hour)
+	if midnight.Equal(expiry) {
+		return midnight
+	}
+	return midnight.Add(24 * time.Hour)
+}
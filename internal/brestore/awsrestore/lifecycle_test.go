@@ -0,0 +1,96 @@
+// Copyright 2021 VILT Group
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package awsrestore
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/s3"
+)
+
+func noncurrentRule(id string, prefix string, days int64, status string) *s3.LifecycleRule {
+	return &s3.LifecycleRule{
+		ID:                          aws.String(id),
+		Status:                      aws.String(status),
+		Filter:                      &s3.LifecycleRuleFilter{Prefix: aws.String(prefix)},
+		NoncurrentVersionExpiration: &s3.NoncurrentVersionExpiration{NoncurrentDays: aws.Int64(days)},
+	}
+}
+
+func TestNoncurrentExpiry(t *testing.T) {
+	lc := Lifecycle{Rules: []*s3.LifecycleRule{
+		noncurrentRule("logs", "logs/", 7, s3.ExpirationStatusEnabled),
+		noncurrentRule("all", "", 30, s3.ExpirationStatusEnabled),
+		noncurrentRule("disabled", "", 1, s3.ExpirationStatusDisabled),
+	}}
+
+	since := time.Date(2021, 2, 21, 9, 30, 0, 0, time.UTC)
+
+	var tests = []struct {
+		key      string
+		expected time.Time
+		rule     string
+	}{
+		{"logs/a.log", time.Date(2021, 3, 1, 0, 0, 0, 0, time.UTC), "logs"},
+		{"data/a.csv", time.Date(2021, 3, 24, 0, 0, 0, 0, time.UTC), "all"},
+	}
+
+	for _, test := range tests {
+		at, rule, ok := lc.NoncurrentExpiry(test.key, since)
+		if !ok || !at.Equal(test.expected) || rule != test.rule {
+			t.Fatalf("NoncurrentExpiry(%s): expected %v by '%s' | got: %v by '%s' (%v)",
+				test.key, test.expected, test.rule, at, rule, ok)
+		}
+	}
+
+	if _, _, ok := (Lifecycle{}).NoncurrentExpiry("a", since); ok {
+		t.Fatalf("expected no expiry without rules")
+	}
+}
+
+func TestNoncurrentRules(t *testing.T) {
+	lc := Lifecycle{Rules: []*s3.LifecycleRule{
+		noncurrentRule("logs", "logs/", 7, s3.ExpirationStatusEnabled),
+		noncurrentRule("disabled", "", 1, s3.ExpirationStatusDisabled),
+	}}
+
+	rules := lc.NoncurrentRules()
+	expected := "rule 'logs' deletes noncurrent versions of 'logs/*' after 7 days"
+	if len(rules) != 1 || rules[0] != expected {
+		t.Fatalf("expected [%s] | got: %v", expected, rules)
+	}
+}
+
+func TestWithNoncurrentExpiration(t *testing.T) {
+	lc := Lifecycle{Rules: []*s3.LifecycleRule{
+		noncurrentRule("logs", "logs/", 7, s3.ExpirationStatusEnabled),
+	}}
+
+	rules, changed := lc.WithNoncurrentExpiration(90)
+	if !changed || len(rules) != 2 || aws.StringValue(rules[1].ID) != NoncurrentExpirationRuleID {
+		t.Fatalf("expected a rule to be added | got: %v (%v)", rules, changed)
+	}
+
+	if _, changed := (Lifecycle{Rules: rules}).WithNoncurrentExpiration(90); changed {
+		t.Fatalf("expected no change when the rule already exists")
+	}
+
+	rules, changed = (Lifecycle{Rules: rules}).WithNoncurrentExpiration(30)
+	if !changed || len(rules) != 2 || aws.Int64Value(rules[1].NoncurrentVersionExpiration.NoncurrentDays) != 30 {
+		t.Fatalf("expected the rule to be replaced | got: %v (%v)", rules, changed)
+	}
+}
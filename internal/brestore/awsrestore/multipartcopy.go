@@ -0,0 +1,406 @@
+// Copyright 2021 VILT Group
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package awsrestore
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/viltgroup/bucket-restore/internal/brestore/encryption"
+)
+
+// Limits of the multipart uploads of S3.
+const (
+	MinPartSize = 5 * 1024 * 1024
+	MaxPartSize = 5 * 1024 * 1024 * 1024
+	MaxParts    = 10000
+)
+
+// DefaultPartConcurrency is the number of parts of an object copied at the same time by default.
+const DefaultPartConcurrency = 10
+
+// CopyPartSize returns the size of the parts of a multipart copy of an object with the given size.
+// If partSize is 0, the object is divided evenly by the concurrency. The size is adjusted to the limits of
+// S3, and increased if needed so the object doesn't have more than MaxParts parts.
+func CopyPartSize(size int64, partSize int64, concurrency int) int64 {
+	if partSize <= 0 {
+		if concurrency <= 0 {
+			concurrency = DefaultPartConcurrency
+		}
+		partSize = (size + int64(concurrency) - 1) / int64(concurrency)
+	}
+
+	if minSize := (size + MaxParts - 1) / MaxParts; partSize < minSize {
+		partSize = minSize
+	}
+	if partSize < MinPartSize {
+		partSize = MinPartSize
+	}
+	if partSize > MaxPartSize {
+		partSize = MaxPartSize
+	}
+	return partSize
+}
+
+// CopyParts returns the number of parts of a multipart copy of an object with the given size.
+func CopyParts(size int64, partSize int64, concurrency int) int64 {
+	ps := CopyPartSize(size, partSize, concurrency)
+	return (size + ps - 1) / ps
+}
+
+// MultipartCopy copies a version of an object over the object in parts, which is required for objects
+// larger than 5 GiB. Unlike a copy in a single request, the metadata, content headers and tags of the
+// version are copied explicitly.
+type MultipartCopy struct {
+	Client     *s3.S3
+	BucketName string
+	Key        string
+	// Version of the object to copy
+	VersionID string
+	// Size of the version to copy
+	Size int64
+	// Size of each part. If 0, it's chosen from the size of the version and the concurrency
+	PartSize int64
+	// Number of parts copied at the same time
+	Concurrency int
+	// Storage class of the copy. If empty, the storage class of the version is kept
+	StorageClass string
+	// Encryption keys of the version and the copy. If the copy has no keys, the encryption of the version is kept
+	Keys encryption.Keys
+	// Object Lock retention of the copy. Ignored if it has no mode
+	ObjectLock ObjectLock
+	// Algorithm of the additional checksum S3 computes for the copy, e.g. "SHA256". Ignored if empty
+	ChecksumAlgorithm string
+	// The copy is aborted if the object was modified after this time. Ignored if zero
+	UnmodifiedSince time.Time
+	// Called after each part is copied, with the number of parts copied so far
+	OnPart func(copied int64, parts int64)
+}
+
+// Run runs the multipart copy. Returns the id and ETag of the version created by the copy.
+func (c MultipartCopy) Run() (string, string, error) {
+	head, err := VersionHead(c.Client, c.BucketName, c.Key, c.VersionID, c.Keys.SourceCustomerKey)
+	if err != nil {
+		return "", "", err
+	}
+	enc := NewCopyEncryption(c.Keys, head)
+
+	tagging, err := c.Client.GetObjectTagging(&s3.GetObjectTaggingInput{
+		Bucket:    aws.String(c.BucketName),
+		Key:       aws.String(c.Key),
+		VersionId: aws.String(c.VersionID),
+	})
+	if err != nil {
+		return "", "", fmt.Errorf("getting tags of version: %w", err)
+	}
+
+	req, upload := c.Client.CreateMultipartUploadRequest(&s3.CreateMultipartUploadInput{
+		Bucket:                    aws.String(c.BucketName),
+		Key:                       aws.String(c.Key),
+		CacheControl:              head.CacheControl,
+		ContentDisposition:        head.ContentDisposition,
+		ContentEncoding:           head.ContentEncoding,
+		ContentLanguage:           head.ContentLanguage,
+		ContentType:               head.ContentType,
+		Expires:                   parseExpires(head.Expires),
+		Metadata:                  head.Metadata,
+		WebsiteRedirectLocation:   head.WebsiteRedirectLocation,
+		Tagging:                   encodeTags(tagging.TagSet),
+		StorageClass:              CopyStorageClass(aws.StringValue(head.StorageClass), c.StorageClass),
+		SSECustomerAlgorithm:      enc.SSECustomerAlgorithm,
+		SSECustomerKey:            enc.SSECustomerKey,
+		ServerSideEncryption:      enc.ServerSideEncryption,
+		SSEKMSKeyId:               enc.SSEKMSKeyId,
+		ObjectLockMode:            c.ObjectLock.ModeParam(),
+		ObjectLockRetainUntilDate: c.ObjectLock.RetainUntilParam(),
+	})
+	// the version of the SDK in use doesn't support the checksum algorithm parameter
+	if c.ChecksumAlgorithm != "" {
+		req.HTTPRequest.Header.Set("x-amz-checksum-algorithm", c.ChecksumAlgorithm)
+	}
+	if err := req.Send(); err != nil {
+		return "", "", fmt.Errorf("starting multipart upload: %w", err)
+	}
+
+	parts, checksums, err := c.copyParts(aws.StringValue(upload.UploadId), enc)
+	if err == nil {
+		err = c.checkUnmodified()
+	}
+	if err != nil {
+		c.Client.AbortMultipartUpload(&s3.AbortMultipartUploadInput{
+			Bucket:   aws.String(c.BucketName),
+			Key:      aws.String(c.Key),
+			UploadId: upload.UploadId,
+		})
+		return "", "", err
+	}
+
+	req, out := c.Client.CompleteMultipartUploadRequest(&s3.CompleteMultipartUploadInput{
+		Bucket:          aws.String(c.BucketName),
+		Key:             aws.String(c.Key),
+		UploadId:        upload.UploadId,
+		MultipartUpload: &s3.CompletedMultipartUpload{Parts: parts},
+	})
+	// uploads with an additional checksum are completed with the checksums of their parts, which the version
+	// of the SDK in use can't send
+	if c.ChecksumAlgorithm != "" {
+		body, err := completeMultipartUploadBody(parts, checksums, c.ChecksumAlgorithm)
+		if err != nil {
+			return "", "", err
+		}
+		req.Handlers.Build.PushBack(func(r *request.Request) {
+			r.SetBufferBody(body)
+		})
+	}
+	if err := req.Send(); err != nil {
+		return "", "", fmt.Errorf("completing multipart upload: %w", err)
+	}
+
+	return aws.StringValue(out.VersionId), aws.StringValue(out.ETag), nil
+}
+
+// copyParts copies the parts of the version concurrently, stopping at the first error. Returns the parts and,
+// if the copy has an additional checksum, the checksum of each part by part number.
+func (c MultipartCopy) copyParts(uploadID string, enc CopyEncryption) ([]*s3.CompletedPart, map[int64]string,
+	error) {
+
+	partSize := CopyPartSize(c.Size, c.PartSize, c.Concurrency)
+	nParts := (c.Size + partSize - 1) / partSize
+
+	concurrency := c.Concurrency
+	if concurrency <= 0 {
+		concurrency = DefaultPartConcurrency
+	}
+
+	var unmodifiedSince *time.Time
+	if !c.UnmodifiedSince.IsZero() {
+		unmodifiedSince = &c.UnmodifiedSince
+	}
+
+	source := fmt.Sprintf("%s/%s?versionId=%s", c.BucketName, url.QueryEscape(c.Key), url.QueryEscape(c.VersionID))
+
+	partNumbers := make(chan int64)
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	var parts []*s3.CompletedPart
+	checksums := map[int64]string{}
+	var firstErr error
+
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for n := range partNumbers {
+				first := (n - 1) * partSize
+				last := first + partSize - 1
+				if last >= c.Size {
+					last = c.Size - 1
+				}
+
+				req, out := c.Client.UploadPartCopyRequest(&s3.UploadPartCopyInput{
+					Bucket:                      aws.String(c.BucketName),
+					Key:                         aws.String(c.Key),
+					UploadId:                    aws.String(uploadID),
+					PartNumber:                  aws.Int64(n),
+					CopySource:                  aws.String(source),
+					CopySourceRange:             aws.String(fmt.Sprintf("bytes=%d-%d", first, last)),
+					CopySourceIfUnmodifiedSince: unmodifiedSince,
+					// parts of uploads encrypted with SSE-C must be sent with the key of the upload
+					CopySourceSSECustomerAlgorithm: enc.CopySourceSSECustomerAlgorithm,
+					CopySourceSSECustomerKey:       enc.CopySourceSSECustomerKey,
+					SSECustomerAlgorithm:           enc.SSECustomerAlgorithm,
+					SSECustomerKey:                 enc.SSECustomerKey,
+				})
+				var checksum string
+				if c.ChecksumAlgorithm != "" {
+					req.HTTPRequest.Header.Set("x-amz-checksum-algorithm", c.ChecksumAlgorithm)
+					// the version of the SDK in use doesn't parse the checksum of the part
+					req.Handlers.Unmarshal.PushFront(func(r *request.Request) {
+						body, err := io.ReadAll(r.HTTPResponse.Body)
+						if err != nil {
+							r.Error = err
+							return
+						}
+						r.HTTPResponse.Body = io.NopCloser(bytes.NewReader(body))
+						checksum, r.Error = parseCopyPartChecksum(body, c.ChecksumAlgorithm)
+					})
+				}
+				err := req.Send()
+
+				mu.Lock()
+				if err != nil && firstErr == nil {
+					firstErr = fmt.Errorf("copying part %d of %d: %w", n, nParts, err)
+				}
+				if err == nil {
+					parts = append(parts, &s3.CompletedPart{ETag: out.CopyPartResult.ETag, PartNumber: aws.Int64(n)})
+					checksums[n] = checksum
+					if c.OnPart != nil {
+						c.OnPart(int64(len(parts)), nParts)
+					}
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+
+	for n := int64(1); n <= nParts; n++ {
+		mu.Lock()
+		failed := firstErr != nil
+		mu.Unlock()
+		if failed {
+			break
+		}
+		partNumbers <- n
+	}
+	close(partNumbers)
+	wg.Wait()
+
+	if firstErr != nil {
+		return nil, nil, firstErr
+	}
+
+	sort.Slice(parts, func(i, j int) bool {
+		return aws.Int64Value(parts[i].PartNumber) < aws.Int64Value(parts[j].PartNumber)
+	})
+	return parts, checksums, nil
+}
+
+// parseCopyPartChecksum returns the additional checksum with the given algorithm from the body of the
+// response of UploadPartCopy.
+func parseCopyPartChecksum(body []byte, algorithm string) (string, error) {
+	var result struct {
+		Fields []struct {
+			XMLName xml.Name
+			Value   string `xml:",chardata"`
+		} `xml:",any"`
+	}
+	if err := xml.Unmarshal(body, &result); err != nil {
+		return "", fmt.Errorf("parsing copy part result: %w", err)
+	}
+
+	for _, f := range result.Fields {
+		if f.XMLName.Local == "Checksum"+algorithm {
+			return f.Value, nil
+		}
+	}
+	return "", fmt.Errorf("copy part result has no %s checksum", algorithm)
+}
+
+// completedPart is a part of the body of CompleteMultipartUpload, with its additional checksum.
+type completedPart struct {
+	ETag       string
+	PartNumber int64
+	Checksum   checksumElement
+}
+
+// checksumElement is an additional checksum element, named after its algorithm, e.g. ChecksumSHA256.
+type checksumElement struct {
+	Algorithm string
+	Value     string
+}
+
+func (e checksumElement) MarshalXML(enc *xml.Encoder, start xml.StartElement) error {
+	return enc.EncodeElement(e.Value, xml.StartElement{Name: xml.Name{Local: "Checksum" + e.Algorithm}})
+}
+
+// completeMultipartUploadBody returns the body of CompleteMultipartUpload for parts with the given additional
+// checksums by part number.
+func completeMultipartUploadBody(parts []*s3.CompletedPart, checksums map[int64]string,
+	algorithm string) ([]byte, error) {
+
+	body := struct {
+		XMLName xml.Name        `xml:"http://s3.amazonaws.com/doc/2006-03-01/ CompleteMultipartUpload"`
+		Parts   []completedPart `xml:"Part"`
+	}{}
+	for _, p := range parts {
+		n := aws.Int64Value(p.PartNumber)
+		body.Parts = append(body.Parts, completedPart{
+			ETag:       aws.StringValue(p.ETag),
+			PartNumber: n,
+			Checksum:   checksumElement{Algorithm: algorithm, Value: checksums[n]},
+		})
+	}
+
+	res, err := xml.Marshal(body)
+	if err != nil {
+		return nil, fmt.Errorf("building the list of parts: %w", err)
+	}
+	return res, nil
+}
+
+// checkUnmodified checks that the object wasn't modified after UnmodifiedSince while the parts were copied.
+// The object is listed instead of read, since reading objects encrypted with SSE-C needs their key.
+func (c MultipartCopy) checkUnmodified() error {
+	if c.UnmodifiedSince.IsZero() {
+		return nil
+	}
+
+	// keys are listed in order and their versions from the newest, so the first version listed is
+	// the current version of the object, or a delete marker if it was deleted
+	out, err := c.Client.ListObjectVersions(&s3.ListObjectVersionsInput{
+		Bucket:  aws.String(c.BucketName),
+		Prefix:  aws.String(c.Key),
+		MaxKeys: aws.Int64(1),
+	})
+	if err != nil {
+		return fmt.Errorf("checking if object was modified: %w", err)
+	}
+	if len(out.DeleteMarkers) > 0 && aws.StringValue(out.DeleteMarkers[0].Key) == c.Key {
+		return fmt.Errorf("object was deleted during the copy")
+	}
+	if len(out.Versions) == 0 || aws.StringValue(out.Versions[0].Key) != c.Key {
+		return fmt.Errorf("object was deleted during the copy")
+	}
+	if lastModified := aws.TimeValue(out.Versions[0].LastModified); lastModified.After(c.UnmodifiedSince) {
+		return fmt.Errorf("object was modified during the copy, at %v", lastModified)
+	}
+	return nil
+}
+
+// parseExpires parses the value of the Expires header returned by HeadObject, which the SDK in use
+// doesn't parse. Invalid dates are ignored.
+func parseExpires(expires *string) *time.Time {
+	if expires == nil {
+		return nil
+	}
+	t, err := time.Parse(time.RFC1123, *expires)
+	if err != nil {
+		return nil
+	}
+	return &t
+}
+
+// encodeTags encodes tags as URL query parameters, as expected by the tagging header.
+func encodeTags(tags []*s3.Tag) *string {
+	if len(tags) == 0 {
+		return nil
+	}
+
+	values := url.Values{}
+	for _, t := range tags {
+		values.Add(aws.StringValue(t.Key), aws.StringValue(t.Value))
+	}
+	return aws.String(strings.ReplaceAll(values.Encode(), "+", "%20"))
+}
@@ -0,0 +1,142 @@
+// Copyright 2021 VILT Group
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package awsrestore
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/s3"
+)
+
+func TestCopyPartSize(t *testing.T) {
+	const gib = 1024 * 1024 * 1024
+	const mib = 1024 * 1024
+
+	var tests = []struct {
+		size        int64
+		partSize    int64
+		concurrency int
+		expected    int64
+		parts       int64
+	}{
+		{6 * gib, 0, 10, (6*gib + 9) / 10, 10},
+		{6 * gib, 0, 0, (6*gib + 9) / 10, 10},
+		{6 * gib, 512 * mib, 10, 512 * mib, 12},
+		{6 * gib, 1 * mib, 10, MinPartSize, 1229},
+		{6 * gib, 0, 1, MaxPartSize, 2},
+		// no more than 10000 parts
+		{100000 * 6 * mib, 5 * mib, 10, 60 * mib, 10000},
+	}
+
+	for _, test := range tests {
+		got := CopyPartSize(test.size, test.partSize, test.concurrency)
+		if got != test.expected {
+			t.Fatalf("CopyPartSize(%d, %d, %d): expected %d | got: %d",
+				test.size, test.partSize, test.concurrency, test.expected, got)
+		}
+		if parts := CopyParts(test.size, test.partSize, test.concurrency); parts != test.parts {
+			t.Fatalf("CopyParts(%d, %d, %d): expected %d | got: %d",
+				test.size, test.partSize, test.concurrency, test.parts, parts)
+		}
+	}
+}
+
+func TestEncodeTags(t *testing.T) {
+	tags := []*s3.Tag{
+		{Key: aws.String("team"), Value: aws.String("data eng")},
+		{Key: aws.String("env"), Value: aws.String("prod&dev")},
+	}
+
+	expected := "env=prod%26dev&team=data%20eng"
+	if got := aws.StringValue(encodeTags(tags)); got != expected {
+		t.Fatalf("expected %s | got: %s", expected, got)
+	}
+	if encodeTags(nil) != nil {
+		t.Fatalf("expected no tagging header without tags")
+	}
+}
+
+func TestMultipartCopyChecksumAlgorithm(t *testing.T) {
+	var mu sync.Mutex
+	algorithms := map[string]string{}
+	var completeBody string
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		q := r.URL.Query()
+		has := func(param string) bool {
+			_, ok := q[param]
+			return ok
+		}
+		switch {
+		case r.Method == http.MethodHead:
+			w.Header().Set("Content-Type", "text/plain")
+		case r.Method == http.MethodGet && has("tagging"):
+			io.WriteString(w, `<Tagging><TagSet></TagSet></Tagging>`)
+		case r.Method == http.MethodPost && has("uploads"):
+			algorithms["create"] = r.Header.Get("x-amz-checksum-algorithm")
+			io.WriteString(w, `<InitiateMultipartUploadResult><UploadId>upload</UploadId></InitiateMultipartUploadResult>`)
+		case r.Method == http.MethodPut && has("partNumber"):
+			algorithms["part"] = r.Header.Get("x-amz-checksum-algorithm")
+			io.WriteString(w, `<CopyPartResult><ETag>"part"</ETag><ChecksumSHA256>c3VtMQ==</ChecksumSHA256></CopyPartResult>`)
+		case r.Method == http.MethodPost && has("uploadId"):
+			body, _ := io.ReadAll(r.Body)
+			completeBody = string(body)
+			io.WriteString(w, `<CompleteMultipartUploadResult><ETag>"copy"</ETag></CompleteMultipartUploadResult>`)
+		default:
+			w.WriteHeader(http.StatusNotImplemented)
+		}
+	}))
+	defer server.Close()
+
+	sess := session.Must(session.NewSession(&aws.Config{
+		Endpoint:         aws.String(server.URL),
+		Region:           aws.String("us-east-1"),
+		Credentials:      credentials.NewStaticCredentials("id", "secret", ""),
+		S3ForcePathStyle: aws.Bool(true),
+	}))
+
+	c := MultipartCopy{
+		Client:            s3.New(sess),
+		BucketName:        "bucket",
+		Key:               "key",
+		VersionID:         "v1",
+		Size:              MinPartSize,
+		ChecksumAlgorithm: "SHA256",
+	}
+	if _, _, err := c.Run(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	for _, call := range []string{"create", "part"} {
+		if algorithms[call] != "SHA256" {
+			t.Fatalf("%s: expected checksum algorithm SHA256 | got: %q", call, algorithms[call])
+		}
+	}
+	expected := "<Part><ETag>&#34;part&#34;</ETag><PartNumber>1</PartNumber><ChecksumSHA256>c3VtMQ==</ChecksumSHA256></Part>"
+	if !strings.Contains(completeBody, expected) {
+		t.Fatalf("expected parts %s | got: %s", expected, completeBody)
+	}
+}
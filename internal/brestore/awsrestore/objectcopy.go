@@ -0,0 +1,96 @@
+// Copyright 2021 VILT Group
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package awsrestore
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/s3"
+)
+
+// StorageClasses are the storage classes that copies of objects can be written to.
+var StorageClasses = []string{
+	s3.StorageClassStandard,
+	s3.StorageClassReducedRedundancy,
+	s3.StorageClassStandardIa,
+	s3.StorageClassOnezoneIa,
+	s3.StorageClassIntelligentTiering,
+	s3.StorageClassGlacier,
+	s3.StorageClassDeepArchive,
+	"GLACIER_IR",
+}
+
+// NormalizeStorageClass converts a storage class given by the user to the name used by S3.
+// Returns false if the storage class is not one of StorageClasses.
+func NormalizeStorageClass(storageClass string) (string, bool) {
+	sc := strings.ToUpper(strings.TrimSpace(storageClass))
+	for _, c := range StorageClasses {
+		if c == sc {
+			return sc, true
+		}
+	}
+	return "", false
+}
+
+// CopyStorageClass returns the storage class of the copy of a version with the given storage class.
+// S3 writes copies to the STANDARD class unless told otherwise, so the class of the version is kept
+// explicitly unless override is given. Copies of archived versions go to STANDARD instead, since a
+// copy written back to an archive class can't be read until it is thawed again.
+func CopyStorageClass(versionStorageClass string, override string) *string {
+	if override != "" {
+		return aws.String(override)
+	}
+	if versionStorageClass == "" || ArchivedStorageClass(versionStorageClass) {
+		return nil
+	}
+	return aws.String(versionStorageClass)
+}
+
+// CopyACL copies the ACL of a version of an object to another version of the same object.
+// Copies don't keep the ACL of their source, which is replaced by the default private ACL.
+// Nothing is done in buckets with ACLs disabled, where the bucket owner owns every object.
+func CopyACL(client *s3.S3, bucketName string, key string, fromVersionID string, toVersionID string) error {
+	acl, err := client.GetObjectAcl(&s3.GetObjectAclInput{
+		Bucket:    aws.String(bucketName),
+		Key:       aws.String(key),
+		VersionId: aws.String(fromVersionID),
+	})
+	if isErrorCode(err, "AccessControlListNotSupported") {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("getting ACL of version '%s': %w", fromVersionID, err)
+	}
+
+	_, err = client.PutObjectAcl(&s3.PutObjectAclInput{
+		Bucket:    aws.String(bucketName),
+		Key:       aws.String(key),
+		VersionId: aws.String(toVersionID),
+		AccessControlPolicy: &s3.AccessControlPolicy{
+			Grants: acl.Grants,
+			Owner:  acl.Owner,
+		},
+	})
+	if isErrorCode(err, "AccessControlListNotSupported") {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("setting ACL of version '%s': %w", toVersionID, err)
+	}
+
+	return nil
+}
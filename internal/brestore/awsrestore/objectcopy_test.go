@@ -0,0 +1,62 @@
+// Copyright 2021 VILT Group
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package awsrestore
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+)
+
+func TestCopyStorageClass(t *testing.T) {
+	var tests = []struct {
+		versionStorageClass string
+		override            string
+		expected            *string
+	}{
+		{"STANDARD_IA", "", aws.String("STANDARD_IA")},
+		{"STANDARD_IA", "GLACIER_IR", aws.String("GLACIER_IR")},
+		{"", "", nil},
+		{"GLACIER", "", nil},
+		{"DEEP_ARCHIVE", "", nil},
+		{"DEEP_ARCHIVE", "DEEP_ARCHIVE", aws.String("DEEP_ARCHIVE")},
+	}
+
+	for i, test := range tests {
+		res := CopyStorageClass(test.versionStorageClass, test.override)
+		if aws.StringValue(res) != aws.StringValue(test.expected) || (res == nil) != (test.expected == nil) {
+			t.Fatalf("test %d: expected %v | got: %v", i, aws.StringValue(test.expected), aws.StringValue(res))
+		}
+	}
+}
+
+func TestNormalizeStorageClass(t *testing.T) {
+	var tests = []struct {
+		storageClass string
+		expected     string
+		ok           bool
+	}{
+		{"standard_ia", "STANDARD_IA", true},
+		{"GLACIER_IR", "GLACIER_IR", true},
+		{"COLDLINE", "", false},
+	}
+
+	for i, test := range tests {
+		res, ok := NormalizeStorageClass(test.storageClass)
+		if res != test.expected || ok != test.ok {
+			t.Fatalf("test %d: expected %q (%v) | got: %q (%v)", i, test.expected, test.ok, res, ok)
+		}
+	}
+}
@@ -0,0 +1,99 @@
+// Copyright 2021 VILT Group
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package awsrestore
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/s3"
+)
+
+// ObjectLockModes are the retention modes of S3 Object Lock.
+var ObjectLockModes = []string{s3.ObjectLockModeGovernance, s3.ObjectLockModeCompliance}
+
+// ObjectLock represents the S3 Object Lock retention and legal hold of a version of an object.
+type ObjectLock struct {
+	// Retention mode, GOVERNANCE or COMPLIANCE, or the empty string if the version has no retention
+	Mode string
+	// Time until which the version is retained
+	RetainUntil time.Time
+	// Whether the version has a legal hold
+	LegalHold bool
+}
+
+// ObjectLockOf gets the retention and legal hold of a version of an object in a bucket with Object Lock.
+func ObjectLockOf(client *s3.S3, bucketName string, key string, versionID string) (ObjectLock, error) {
+	var res ObjectLock
+
+	retention, err := client.GetObjectRetention(&s3.GetObjectRetentionInput{
+		Bucket:    aws.String(bucketName),
+		Key:       aws.String(key),
+		VersionId: aws.String(versionID),
+	})
+	if err != nil && !isErrorCode(err, "NoSuchObjectLockConfiguration") {
+		return res, fmt.Errorf("getting retention of '%s' version '%s': %w", key, versionID, err)
+	}
+	if err == nil && retention.Retention != nil {
+		res.Mode = aws.StringValue(retention.Retention.Mode)
+		res.RetainUntil = aws.TimeValue(retention.Retention.RetainUntilDate)
+	}
+
+	hold, err := client.GetObjectLegalHold(&s3.GetObjectLegalHoldInput{
+		Bucket:    aws.String(bucketName),
+		Key:       aws.String(key),
+		VersionId: aws.String(versionID),
+	})
+	if err != nil && !isErrorCode(err, "NoSuchObjectLockConfiguration") {
+		return res, fmt.Errorf("getting legal hold of '%s' version '%s': %w", key, versionID, err)
+	}
+	if err == nil && hold.LegalHold != nil {
+		res.LegalHold = aws.StringValue(hold.LegalHold.Status) == s3.ObjectLockLegalHoldStatusOn
+	}
+
+	return res, nil
+}
+
+// BlockedBy returns why the version can't be deleted at the given time, or the empty string if it can.
+func (l ObjectLock) BlockedBy(now time.Time) string {
+	var reasons []string
+	if l.LegalHold {
+		reasons = append(reasons, "legal hold")
+	}
+	if l.Mode != "" && l.RetainUntil.After(now) {
+		reasons = append(reasons, fmt.Sprintf("%s retention until %v", strings.ToLower(l.Mode), l.RetainUntil))
+	}
+	return strings.Join(reasons, ", ")
+}
+
+// ModeParam returns the retention mode as a parameter of the requests that create objects,
+// or nil if there's no retention.
+func (l ObjectLock) ModeParam() *string {
+	if l.Mode == "" {
+		return nil
+	}
+	return aws.String(l.Mode)
+}
+
+// RetainUntilParam returns the retention date as a parameter of the requests that create objects,
+// or nil if there's no retention.
+func (l ObjectLock) RetainUntilParam() *time.Time {
+	if l.Mode == "" {
+		return nil
+	}
+	return aws.Time(l.RetainUntil)
+}
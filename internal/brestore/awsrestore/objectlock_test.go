@@ -0,0 +1,45 @@
+// Copyright 2021 VILT Group
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package awsrestore
+
+import (
+	"testing"
+	"time"
+)
+
+func TestObjectLockBlockedBy(t *testing.T) {
+	now := time.Date(2021, 2, 21, 0, 0, 0, 0, time.UTC)
+	later := now.Add(24 * time.Hour)
+
+	var tests = []struct {
+		lock     ObjectLock
+		expected string
+	}{
+		{ObjectLock{}, ""},
+		{ObjectLock{Mode: "GOVERNANCE", RetainUntil: later}, "governance retention until " + later.String()},
+		{ObjectLock{Mode: "COMPLIANCE", RetainUntil: later}, "compliance retention until " + later.String()},
+		{ObjectLock{Mode: "COMPLIANCE", RetainUntil: now.Add(-time.Hour)}, ""},
+		{ObjectLock{LegalHold: true}, "legal hold"},
+		{ObjectLock{Mode: "GOVERNANCE", RetainUntil: later, LegalHold: true},
+			"legal hold, governance retention until " + later.String()},
+	}
+
+	for i, test := range tests {
+		res := test.lock.BlockedBy(now)
+		if res != test.expected {
+			t.Fatalf("test %d: expected %q | got: %q", i, test.expected, res)
+		}
+	}
+}
@@ -0,0 +1,70 @@
+// Copyright 2021 VILT Group
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package awsrestore
+
+import (
+	"fmt"
+	"net/url"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/s3"
+)
+
+// impossibleETag is an ETag no object has, used as a precondition that always fails.
+const impossibleETag = "\"brestore-permission-check\""
+
+// CanListVersions checks if the caller is allowed to list the versions of the objects under a path of a bucket.
+// It also returns a version found in the path, or nil if there are none, to check other permissions with.
+func CanListVersions(client *s3.S3, bucketName string, path string) (bool, *s3.ObjectVersion, error) {
+	out, err := client.ListObjectVersions(&s3.ListObjectVersionsInput{
+		Bucket:  aws.String(bucketName),
+		Prefix:  aws.String(path),
+		MaxKeys: aws.Int64(100),
+	})
+	if isErrorCode(err, "AccessDenied") {
+		return false, nil, nil
+	}
+	if err != nil {
+		return false, nil, fmt.Errorf("listing versions of bucket '%s': %w", bucketName, err)
+	}
+
+	if len(out.Versions) == 0 {
+		return true, nil, nil
+	}
+	return true, out.Versions[0], nil
+}
+
+// CanCopyVersion checks if the caller is allowed to copy a version of an object over the object, as done by
+// a rollback, without changing it. The copy is made with a precondition that always fails, and S3 checks the
+// permissions before the preconditions.
+func CanCopyVersion(client *s3.S3, bucketName string, key string, versionID string) (bool, error) {
+	_, err := client.CopyObject(&s3.CopyObjectInput{
+		Bucket:            aws.String(bucketName),
+		Key:               aws.String(key),
+		CopySource:        aws.String(fmt.Sprintf("%s/%s?versionId=%s", bucketName, url.QueryEscape(key), url.QueryEscape(versionID))),
+		CopySourceIfMatch: aws.String(impossibleETag),
+	})
+	if isErrorCode(err, "PreconditionFailed") {
+		return true, nil
+	}
+	if isErrorCode(err, "AccessDenied") {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("checking copy of '%s' version '%s': %w", key, versionID, err)
+	}
+
+	return false, fmt.Errorf("copy of '%s' version '%s' with an impossible precondition succeeded", key, versionID)
+}
@@ -0,0 +1,141 @@
+// Copyright 2021 VILT Group
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package awsrestore
+
+import (
+	"fmt"
+	"regexp"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/s3"
+)
+
+// ThawTiers are the retrieval tiers of a restore of an archived version, from the fastest to the cheapest.
+var ThawTiers = []string{s3.TierExpedited, s3.TierStandard, s3.TierBulk}
+
+// DefaultThawDays is the default number of days a thawed version stays readable.
+const DefaultThawDays = 7
+
+// ArchivedStorageClass checks if versions of a storage class are archived, and can't be read or copied
+// until they are restored.
+func ArchivedStorageClass(storageClass string) bool {
+	return storageClass == s3.StorageClassGlacier || storageClass == s3.StorageClassDeepArchive
+}
+
+// MayBeArchived checks if versions of a storage class may be archived. Versions in the archive access
+// tiers of Intelligent-Tiering are listed as INTELLIGENT_TIERING, and are only known to be archived
+// by reading their metadata.
+func MayBeArchived(storageClass string) bool {
+	return ArchivedStorageClass(storageClass) || storageClass == s3.StorageClassIntelligentTiering
+}
+
+// ThawStatus represents whether an archived version can be read.
+type ThawStatus struct {
+	// Whether the version is archived
+	Archived bool
+	// Archive access tier of a version in Intelligent-Tiering, e.g. "ARCHIVE_ACCESS"
+	ArchiveStatus string
+	// Whether a restore of the version is in progress
+	Ongoing bool
+	// Time when the restored copy of the version expires, if it was restored
+	Expiry time.Time
+}
+
+// Readable checks if the version can be read, either because it's not archived or it was restored.
+func (s ThawStatus) Readable() bool {
+	return !s.Archived || (!s.Ongoing && !s.Expiry.IsZero())
+}
+
+// ThawStatusOf gets the thaw status of a version of an object, given its storage class.
+// Versions encrypted with SSE-C can only be read with their customer key.
+func ThawStatusOf(client *s3.S3, bucketName string, key string, versionID string, storageClass string,
+	customerKey []byte) (ThawStatus, error) {
+
+	in := &s3.HeadObjectInput{
+		Bucket:    aws.String(bucketName),
+		Key:       aws.String(key),
+		VersionId: aws.String(versionID),
+	}
+	if len(customerKey) > 0 {
+		in.SSECustomerAlgorithm = aws.String(s3.ServerSideEncryptionAes256)
+		in.SSECustomerKey = aws.String(string(customerKey))
+	}
+
+	req, out := client.HeadObjectRequest(in)
+	if err := req.Send(); err != nil {
+		return ThawStatus{}, fmt.Errorf("getting thaw status of '%s' version '%s': %w", key, versionID, err)
+	}
+
+	// the version of the SDK in use doesn't support the archive status header
+	return newThawStatus(storageClass, req.HTTPResponse.Header.Get("x-amz-archive-status"),
+		aws.StringValue(out.Restore)), nil
+}
+
+// restoreHeader matches the x-amz-restore header, e.g:
+// ongoing-request="false", expiry-date="Fri, 21 Dec 2012 00:00:00 GMT"
+var restoreHeader = regexp.MustCompile(`ongoing-request="(true|false)"(?:,\s*expiry-date="([^"]+)")?`)
+
+// newThawStatus returns the thaw status of a version with the given storage class, archive status and
+// restore headers.
+func newThawStatus(storageClass string, archiveStatus string, restore string) ThawStatus {
+	res := ThawStatus{
+		Archived:      ArchivedStorageClass(storageClass) || archiveStatus != "",
+		ArchiveStatus: archiveStatus,
+	}
+
+	m := restoreHeader.FindStringSubmatch(restore)
+	if m == nil {
+		return res
+	}
+	res.Ongoing = m[1] == "true"
+	if m[2] != "" {
+		res.Expiry, _ = time.Parse(time.RFC1123, m[2])
+	}
+
+	return res
+}
+
+// ThawTierSupported checks if a retrieval tier can thaw a version with the given storage class and
+// thaw status. The Expedited tier is only available for versions in the GLACIER storage class.
+func ThawTierSupported(storageClass string, status ThawStatus, tier string) bool {
+	return tier != s3.TierExpedited || (storageClass == s3.StorageClassGlacier && status.ArchiveStatus == "")
+}
+
+// Thaw starts a restore of an archived version, which keeps a readable copy of it for the given days.
+// Versions in the archive access tiers of Intelligent-Tiering are moved back to the frequent access tier
+// instead, so days is ignored for them.
+func Thaw(client *s3.S3, bucketName string, key string, versionID string, status ThawStatus, tier string,
+	days int64) error {
+
+	req := &s3.RestoreRequest{GlacierJobParameters: &s3.GlacierJobParameters{Tier: aws.String(tier)}}
+	if status.ArchiveStatus == "" {
+		req.Days = aws.Int64(days)
+	}
+
+	_, err := client.RestoreObject(&s3.RestoreObjectInput{
+		Bucket:         aws.String(bucketName),
+		Key:            aws.String(key),
+		VersionId:      aws.String(versionID),
+		RestoreRequest: req,
+	})
+	if isErrorCode(err, "RestoreAlreadyInProgress") {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("thawing '%s' version '%s': %w", key, versionID, err)
+	}
+	return nil
+}
@@ -0,0 +1,74 @@
+// Copyright 2021 VILT Group
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package awsrestore
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNewThawStatus(t *testing.T) {
+	expiry := time.Date(2012, 12, 21, 0, 0, 0, 0, time.UTC)
+
+	var tests = []struct {
+		storageClass  string
+		archiveStatus string
+		restore       string
+		expected      ThawStatus
+		readable      bool
+	}{
+		{"STANDARD", "", "", ThawStatus{}, true},
+		{"GLACIER", "", "", ThawStatus{Archived: true}, false},
+		{"DEEP_ARCHIVE", "", `ongoing-request="true"`, ThawStatus{Archived: true, Ongoing: true}, false},
+		{"GLACIER", "", `ongoing-request="false", expiry-date="Fri, 21 Dec 2012 00:00:00 GMT"`,
+			ThawStatus{Archived: true, Expiry: expiry}, true},
+		{"INTELLIGENT_TIERING", "", "", ThawStatus{}, true},
+		{"INTELLIGENT_TIERING", "ARCHIVE_ACCESS", "",
+			ThawStatus{Archived: true, ArchiveStatus: "ARCHIVE_ACCESS"}, false},
+	}
+
+	for i, test := range tests {
+		res := newThawStatus(test.storageClass, test.archiveStatus, test.restore)
+		if res.Archived != test.expected.Archived || res.ArchiveStatus != test.expected.ArchiveStatus ||
+			res.Ongoing != test.expected.Ongoing || !res.Expiry.Equal(test.expected.Expiry) {
+			t.Fatalf("test %d: expected %+v | got: %+v", i, test.expected, res)
+		}
+		if res.Readable() != test.readable {
+			t.Fatalf("test %d: expected readable %v | got: %v", i, test.readable, res.Readable())
+		}
+	}
+}
+
+func TestThawTierSupported(t *testing.T) {
+	var tests = []struct {
+		storageClass string
+		status       ThawStatus
+		tier         string
+		expected     bool
+	}{
+		{"GLACIER", ThawStatus{Archived: true}, "Expedited", true},
+		{"DEEP_ARCHIVE", ThawStatus{Archived: true}, "Expedited", false},
+		{"DEEP_ARCHIVE", ThawStatus{Archived: true}, "Standard", true},
+		{"INTELLIGENT_TIERING", ThawStatus{Archived: true, ArchiveStatus: "ARCHIVE_ACCESS"}, "Expedited", false},
+		{"INTELLIGENT_TIERING", ThawStatus{Archived: true, ArchiveStatus: "DEEP_ARCHIVE_ACCESS"}, "Bulk", true},
+	}
+
+	for i, test := range tests {
+		res := ThawTierSupported(test.storageClass, test.status, test.tier)
+		if res != test.expected {
+			t.Fatalf("test %d: expected %v | got: %v", i, test.expected, res)
+		}
+	}
+}
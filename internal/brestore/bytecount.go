@@ -14,7 +14,12 @@
 
 package brestore
 
-import "fmt"
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
 
 // ByteCountString takes the size in bytes of some piece of data and returns
 // a human-friendly string representing that size using the SI (decimal) numformat.
@@ -47,3 +52,40 @@ func ByteCountIECString(b int64) string {
 	return fmt.Sprintf("%.1f %ciB",
 		float64(b)/float64(div), "KMGTPE"[exp])
 }
+
+// byteUnits maps the unit suffixes accepted by ParseByteCount to their number of bytes.
+var byteUnits = map[string]int64{
+	"":    1,
+	"b":   1,
+	"kb":  1000,
+	"mb":  1000 * 1000,
+	"gb":  1000 * 1000 * 1000,
+	"tb":  1000 * 1000 * 1000 * 1000,
+	"kib": 1 << 10,
+	"mib": 1 << 20,
+	"gib": 1 << 30,
+	"tib": 1 << 40,
+}
+
+// ParseByteCount parses a size with an optional SI or IEC unit into a number of bytes.
+// e.g: "512", "10 KB", "1.5GiB"
+func ParseByteCount(s string) (int64, error) {
+	s = strings.TrimSpace(s)
+
+	i := strings.IndexFunc(s, func(r rune) bool { return !unicode.IsDigit(r) && r != '.' })
+	if i < 0 {
+		i = len(s)
+	}
+
+	unit, ok := byteUnits[strings.ToLower(strings.TrimSpace(s[i:]))]
+	if !ok {
+		return 0, fmt.Errorf("unknown unit in size '%s'", s)
+	}
+
+	n, err := strconv.ParseFloat(s[:i], 64)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("could not parse size '%s'", s)
+	}
+
+	return int64(n * float64(unit)), nil
+}
@@ -0,0 +1,165 @@
+// Copyright 2021 VILT Group
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package encryption
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// CustomerKeySize is the size in bytes of the AES-256 keys supplied by the customer to AWS SSE-C and GCP CSEK.
+const CustomerKeySize = 32
+
+// Rule represents the encryption keys of the objects under a prefix.
+type Rule struct {
+	// Prefix of the keys/names of the objects the rule applies to. The empty prefix applies to every object
+	Prefix string `json:"prefix"`
+	// Base64-encoded AES-256 key the versions under the prefix are encrypted with (AWS SSE-C or GCP CSEK)
+	SourceCustomerKey string `json:"source_customer_key,omitempty"`
+	// Base64-encoded AES-256 key to encrypt the restored copies with. Defaults to the source customer key,
+	// unless a KMS key is given
+	DestinationCustomerKey string `json:"destination_customer_key,omitempty"`
+	// KMS key to encrypt the restored copies with: an AWS KMS key id or ARN, or a GCP Cloud KMS key name
+	KMSKey string `json:"kms_key,omitempty"`
+}
+
+// Keys represents the keys used to copy a version of an object.
+type Keys struct {
+	// Key the version is encrypted with, if it was encrypted with a customer-supplied key
+	SourceCustomerKey []byte
+	// Key to encrypt the copy with, if it must be encrypted with a customer-supplied key
+	DestinationCustomerKey []byte
+	// KMS key to encrypt the copy with
+	KMSKey string
+}
+
+// KeysChosen checks if the encryption of the copy was chosen. If not, the copy keeps the encryption of
+// the version it was copied from.
+func (k Keys) KeysChosen() bool {
+	return len(k.DestinationCustomerKey) > 0 || k.KMSKey != ""
+}
+
+// KeyMap maps the keys/names of objects to the encryption keys used to copy their versions.
+type KeyMap struct {
+	prefixes []string
+	keys     []Keys
+}
+
+// NewKeyMap creates a key map from a list of rules. Objects get the keys of the rule with the longest
+// prefix that matches them.
+func NewKeyMap(rules []Rule) (KeyMap, error) {
+	var res KeyMap
+	seen := map[string]bool{}
+
+	for _, r := range rules {
+		if seen[r.Prefix] {
+			return KeyMap{}, fmt.Errorf("more than one rule for prefix '%s'", r.Prefix)
+		}
+		seen[r.Prefix] = true
+
+		keys, err := r.keys()
+		if err != nil {
+			return KeyMap{}, fmt.Errorf("rule for prefix '%s': %w", r.Prefix, err)
+		}
+		res.prefixes = append(res.prefixes, r.Prefix)
+		res.keys = append(res.keys, keys)
+	}
+
+	return res, nil
+}
+
+// LoadRules reads the rules of a key map from a JSON file, in the form {"rules": [...]}.
+func LoadRules(path string) ([]Rule, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading encryption keys: %w", err)
+	}
+
+	var file struct {
+		Rules []Rule `json:"rules"`
+	}
+	if err := json.Unmarshal(content, &file); err != nil {
+		return nil, fmt.Errorf("parsing encryption keys '%s': %w", path, err)
+	}
+
+	return file.Rules, nil
+}
+
+// KeysFor returns the keys used to copy the versions of an object. Objects not matched by any rule
+// get no keys.
+func (m KeyMap) KeysFor(key string) Keys {
+	best := -1
+	for i, p := range m.prefixes {
+		if strings.HasPrefix(key, p) && (best < 0 || len(p) > len(m.prefixes[best])) {
+			best = i
+		}
+	}
+	if best < 0 {
+		return Keys{}
+	}
+	return m.keys[best]
+}
+
+// Empty checks if the key map has no rules.
+func (m KeyMap) Empty() bool {
+	return len(m.prefixes) == 0
+}
+
+// keys decodes and validates the keys of a rule.
+func (r Rule) keys() (Keys, error) {
+	var res Keys
+	var err error
+
+	if r.DestinationCustomerKey != "" && r.KMSKey != "" {
+		return res, fmt.Errorf("a destination customer key and a KMS key can't be used together")
+	}
+
+	res.SourceCustomerKey, err = decodeCustomerKey(r.SourceCustomerKey)
+	if err != nil {
+		return res, fmt.Errorf("source customer key: %w", err)
+	}
+	res.DestinationCustomerKey, err = decodeCustomerKey(r.DestinationCustomerKey)
+	if err != nil {
+		return res, fmt.Errorf("destination customer key: %w", err)
+	}
+	res.KMSKey = r.KMSKey
+
+	// copies of versions encrypted with a customer key stay encrypted with it, unless told otherwise
+	if !res.KeysChosen() {
+		res.DestinationCustomerKey = res.SourceCustomerKey
+	}
+
+	return res, nil
+}
+
+// decodeCustomerKey decodes a base64-encoded AES-256 key. The empty string is no key.
+func decodeCustomerKey(key string) ([]byte, error) {
+	if key == "" {
+		return nil, nil
+	}
+
+	res, err := base64.StdEncoding.DecodeString(key)
+	if err != nil {
+		return nil, fmt.Errorf("not base64-encoded: %w", err)
+	}
+	if len(res) != CustomerKeySize {
+		return nil, fmt.Errorf("must be a %d-byte AES-256 key, got %d bytes", CustomerKeySize, len(res))
+	}
+
+	return res, nil
+}
@@ -0,0 +1,79 @@
+// Copyright 2021 VILT Group
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package encryption
+
+import (
+	"bytes"
+	"encoding/base64"
+	"testing"
+)
+
+func TestKeysFor(t *testing.T) {
+	keyA := bytes.Repeat([]byte{'a'}, CustomerKeySize)
+	keyB := bytes.Repeat([]byte{'b'}, CustomerKeySize)
+
+	m, err := NewKeyMap([]Rule{
+		{Prefix: "", KMSKey: "default-kms"},
+		{Prefix: "secure/", SourceCustomerKey: base64.StdEncoding.EncodeToString(keyA)},
+		{Prefix: "secure/rotated/", SourceCustomerKey: base64.StdEncoding.EncodeToString(keyA),
+			DestinationCustomerKey: base64.StdEncoding.EncodeToString(keyB)},
+		{Prefix: "reports/", KMSKey: "reports-kms"},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var tests = []struct {
+		key      string
+		expected Keys
+	}{
+		{"file.txt", Keys{KMSKey: "default-kms"}},
+		{"secure/file.txt", Keys{SourceCustomerKey: keyA, DestinationCustomerKey: keyA}},
+		{"secure/rotated/file.txt", Keys{SourceCustomerKey: keyA, DestinationCustomerKey: keyB}},
+		{"reports/2021.csv", Keys{KMSKey: "reports-kms"}},
+	}
+
+	for i, test := range tests {
+		res := m.KeysFor(test.key)
+		if !bytes.Equal(res.SourceCustomerKey, test.expected.SourceCustomerKey) ||
+			!bytes.Equal(res.DestinationCustomerKey, test.expected.DestinationCustomerKey) ||
+			res.KMSKey != test.expected.KMSKey {
+			t.Fatalf("test %d: expected %+v | got: %+v", i, test.expected, res)
+		}
+	}
+
+	if res := (KeyMap{}).KeysFor("file.txt"); res.KeysChosen() || res.SourceCustomerKey != nil {
+		t.Fatalf("expected no keys for empty key map | got: %+v", res)
+	}
+}
+
+func TestNewKeyMapErrors(t *testing.T) {
+	key := base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{'a'}, CustomerKeySize))
+
+	var tests = []struct {
+		rules []Rule
+	}{
+		{[]Rule{{Prefix: "a/", SourceCustomerKey: "not base64!"}}},
+		{[]Rule{{Prefix: "a/", SourceCustomerKey: base64.StdEncoding.EncodeToString([]byte("short"))}}},
+		{[]Rule{{Prefix: "a/", DestinationCustomerKey: key, KMSKey: "kms"}}},
+		{[]Rule{{Prefix: "a/", KMSKey: "kms"}, {Prefix: "a/", KMSKey: "other"}}},
+	}
+
+	for i, test := range tests {
+		if _, err := NewKeyMap(test.rules); err == nil {
+			t.Fatalf("test %d: expected error for rules %+v", i, test.rules)
+		}
+	}
+}
@@ -0,0 +1,224 @@
+// Copyright 2021 VILT Group
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package estimate
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// ListPageSize is the number of versions/generations returned by each list request.
+const ListPageSize = 1000
+
+const bytesPerGB = 1000 * 1000 * 1000
+
+const daysPerMonth = 30
+
+// Usage represents the requests and data a rollback needs to perform.
+type Usage struct {
+	// Number of versions/generations listed
+	ListedVersions int64
+	// Time taken to list all the versions/generations
+	ListingDuration time.Duration
+	// Number of single request copies
+	Copies int64
+	// Number of multipart copies
+	MultipartCopies int64
+	// Number of requests used by the multipart copies, including the requests to start and complete the copy
+	MultipartCopyRequests int64
+	// Number of delete requests
+	Deletes int64
+	// Number of requests that read the metadata, tags or ACL of versions, billed as GET requests
+	Reads int64
+	// Number of requests billed like copies that don't copy data, e.g. to set the ACL of a copy
+	Updates int64
+	// Bytes copied, indexed by the storage class of the source of the copy
+	CopiedBytes map[string]int64
+	// Bytes that may be charged for early deletion, indexed by storage class
+	EarlyDeletedBytes map[string]int64
+	// Sum of the size in GB multiplied by the days remaining until the minimum storage duration, by storage class
+	earlyDeletedGBDays map[string]float64
+}
+
+// NewUsage creates an empty Usage.
+func NewUsage() *Usage {
+	return &Usage{
+		CopiedBytes:        make(map[string]int64),
+		EarlyDeletedBytes:  make(map[string]int64),
+		earlyDeletedGBDays: make(map[string]float64),
+	}
+}
+
+// AddCopy registers a copy of size bytes from an object of the given storage class using a single request.
+func (u *Usage) AddCopy(storageClass string, size int64) {
+	u.Copies++
+	u.CopiedBytes[storageClass] += size
+}
+
+// AddMultipartCopy registers a copy of size bytes from an object of the given storage class split into parts.
+func (u *Usage) AddMultipartCopy(storageClass string, size int64, parts int64) {
+	u.MultipartCopies++
+	// one request to create the upload, one per part and one to complete it
+	u.MultipartCopyRequests += parts + 2
+	u.CopiedBytes[storageClass] += size
+}
+
+// AddDelete registers a delete request.
+func (u *Usage) AddDelete() {
+	u.Deletes++
+}
+
+// AddReads registers n requests that read the metadata, tags or ACL of versions.
+func (u *Usage) AddReads(n int64) {
+	u.Reads += n
+}
+
+// AddUpdates registers n requests billed like copies that don't copy data.
+func (u *Usage) AddUpdates(n int64) {
+	u.Updates += n
+}
+
+// AddReplaced registers an object that stops being the live version of a path, either because
+// it's deleted or overwritten. Objects of classes with a minimum storage duration can be charged
+// for the remaining days if their data is removed before that duration.
+func (u *Usage) AddReplaced(prices ProviderPrices, storageClass string, size int64, age time.Duration) {
+	class, ok := prices.StorageClasses[storageClass]
+	if !ok || class.MinStorageDays == 0 {
+		return
+	}
+
+	remainingDays := float64(class.MinStorageDays) - age.Hours()/24
+	if remainingDays <= 0 {
+		return
+	}
+
+	u.EarlyDeletedBytes[storageClass] += size
+	u.earlyDeletedGBDays[storageClass] += float64(size) / bytesPerGB * remainingDays
+}
+
+// ListRequests returns the number of list requests needed to list all the versions/generations.
+func (u *Usage) ListRequests() int64 {
+	pages := (u.ListedVersions + ListPageSize - 1) / ListPageSize
+	if pages == 0 {
+		pages = 1
+	}
+	return pages
+}
+
+// Estimate represents the estimated cost and duration of a rollback.
+type Estimate struct {
+	// Currency of the costs
+	Currency string
+	// Number of list requests
+	ListRequests int64
+	// Number of copy requests
+	CopyRequests int64
+	// Number of requests used by multipart copies
+	MultipartCopyRequests int64
+	// Number of delete requests
+	DeleteRequests int64
+	// Number of requests that read the metadata, tags or ACL of versions
+	ReadRequests int64
+	// Number of requests billed like copies that don't copy data
+	UpdateRequests int64
+	// Cost of all requests
+	RequestsCost float64
+	// Bytes copied, indexed by the storage class of the source
+	CopiedBytes map[string]int64
+	// Cost of reading data from storage classes with retrieval fees
+	RetrievalCost float64
+	// Maximum cost of deleting or overwriting data before its minimum storage duration.
+	// This is only charged if the data is actually removed, which doesn't happen in buckets with versioning.
+	EarlyDeletionCost float64
+	// Estimated wall-clock time of the rollback
+	Duration time.Duration
+	// Storage classes found in the usage that are not in the price table
+	UnknownClasses []string
+}
+
+// TotalCost returns the sum of all costs of the estimate.
+func (e Estimate) TotalCost() float64 {
+	return e.RequestsCost + e.RetrievalCost + e.EarlyDeletionCost
+}
+
+// Estimate calculates the cost of a rollback for the provider with the given bucket url scheme, and the time
+// it takes when running the given number of actions concurrently.
+// The duration of each request is estimated from the duration of the list requests.
+func (t PriceTable) Estimate(provider string, u *Usage, concurrency int) (Estimate, error) {
+	prices, ok := t.Providers[provider]
+	if !ok {
+		return Estimate{}, fmt.Errorf("price table has no prices for provider '%s'", provider)
+	}
+
+	res := Estimate{
+		Currency:              t.Currency,
+		ListRequests:          u.ListRequests(),
+		CopyRequests:          u.Copies,
+		MultipartCopyRequests: u.MultipartCopyRequests,
+		DeleteRequests:        u.Deletes,
+		ReadRequests:          u.Reads,
+		UpdateRequests:        u.Updates,
+		CopiedBytes:           u.CopiedBytes,
+	}
+
+	res.RequestsCost = float64(res.ListRequests)/1000*prices.ListPer1000 +
+		float64(res.CopyRequests+res.MultipartCopyRequests+res.UpdateRequests)/1000*prices.CopyPer1000 +
+		float64(res.DeleteRequests)/1000*prices.DeletePer1000 +
+		float64(res.ReadRequests)/1000*prices.ReadPer1000
+
+	unknown := make(map[string]bool)
+
+	for storageClass, bytes := range u.CopiedBytes {
+		class, ok := prices.StorageClasses[storageClass]
+		if !ok {
+			unknown[storageClass] = true
+			continue
+		}
+		res.RetrievalCost += float64(bytes) / bytesPerGB * class.RetrievalPerGB
+	}
+
+	for storageClass, gbDays := range u.earlyDeletedGBDays {
+		res.EarlyDeletionCost += gbDays * prices.StorageClasses[storageClass].StorageGBMonth / daysPerMonth
+	}
+
+	for storageClass := range unknown {
+		res.UnknownClasses = append(res.UnknownClasses, storageClass)
+	}
+	sort.Strings(res.UnknownClasses)
+
+	res.Duration = u.ListingDuration + u.actionsDuration(concurrency)
+
+	return res, nil
+}
+
+// actionsDuration estimates the time taken to run all the requests of the actions, assuming each request
+// takes as long as a list request did.
+func (u *Usage) actionsDuration(concurrency int) time.Duration {
+	actions := u.Copies + u.MultipartCopies + u.Deletes
+	if actions == 0 {
+		return 0
+	}
+
+	if concurrency < 1 || actions <= 4 {
+		// rollbacks with only a few actions run them sequentially
+		concurrency = 1
+	}
+
+	perRequest := u.ListingDuration / time.Duration(u.ListRequests())
+	requests := u.Copies + u.MultipartCopyRequests + u.Deletes + u.Reads + u.Updates
+
+	return perRequest * time.Duration((requests+int64(concurrency)-1)/int64(concurrency))
+}
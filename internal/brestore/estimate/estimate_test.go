@@ -0,0 +1,105 @@
+// Copyright 2021 VILT Group
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package estimate
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func testPriceTable() PriceTable {
+	return PriceTable{
+		Currency: "USD",
+		Providers: map[string]ProviderPrices{
+			"s3": {
+				ListPer1000:   1,
+				CopyPer1000:   10,
+				DeletePer1000: 0,
+				ReadPer1000:   2,
+				StorageClasses: map[string]ClassPrices{
+					"STANDARD": {StorageGBMonth: 0.03},
+					"GLACIER":  {StorageGBMonth: 0.3, RetrievalPerGB: 0.5, MinStorageDays: 90},
+				},
+			},
+		},
+	}
+}
+
+func floatEquals(a, b float64) bool {
+	return math.Abs(a-b) < 1e-9
+}
+
+func TestEstimate(t *testing.T) {
+	table := testPriceTable()
+
+	u := NewUsage()
+	u.ListedVersions = 2500
+	u.ListingDuration = 3 * time.Second
+	u.AddCopy("STANDARD", 1000)
+	u.AddCopy("GLACIER", 2*bytesPerGB)
+	u.AddMultipartCopy("STANDARD", 10*bytesPerGB, 8)
+	u.AddDelete()
+	u.AddDelete()
+	u.AddReads(3)
+	u.AddUpdates(2)
+	u.AddReplaced(table.Providers["s3"], "GLACIER", bytesPerGB, 60*24*time.Hour)
+	u.AddReplaced(table.Providers["s3"], "GLACIER", bytesPerGB, 100*24*time.Hour)
+	u.AddReplaced(table.Providers["s3"], "STANDARD", bytesPerGB, 0)
+
+	e, err := table.Estimate("s3", u, 2)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if e.ListRequests != 3 || e.CopyRequests != 2 || e.MultipartCopyRequests != 10 || e.DeleteRequests != 2 ||
+		e.ReadRequests != 3 || e.UpdateRequests != 2 {
+		t.Fatalf("unexpected request counts: %+v", e)
+	}
+
+	if !floatEquals(e.RequestsCost, 3.0/1000+14.0/1000*10+3.0/1000*2) {
+		t.Fatalf("unexpected requests cost: %v", e.RequestsCost)
+	}
+
+	if !floatEquals(e.RetrievalCost, 1) {
+		t.Fatalf("unexpected retrieval cost: %v", e.RetrievalCost)
+	}
+
+	// 1 GB for the remaining 30 days of the minimum storage duration
+	if !floatEquals(e.EarlyDeletionCost, 0.3) {
+		t.Fatalf("unexpected early deletion cost: %v", e.EarlyDeletionCost)
+	}
+
+	// 1s per request, 19 requests with 2 running concurrently, plus the listing time
+	if e.Duration != 3*time.Second+10*time.Second {
+		t.Fatalf("unexpected duration: %v", e.Duration)
+	}
+}
+
+func TestEstimateUnknownProvider(t *testing.T) {
+	_, err := testPriceTable().Estimate("gs", NewUsage(), 1)
+	if err == nil {
+		t.Fatalf("expected error for provider without prices")
+	}
+}
+
+func TestDefaultPriceTable(t *testing.T) {
+	table := DefaultPriceTable()
+	for _, provider := range []string{"s3", "gs"} {
+		if _, ok := table.Providers[provider]; !ok {
+			t.Fatalf("default price table has no prices for '%s'", provider)
+		}
+	}
+}
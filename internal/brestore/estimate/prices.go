@@ -0,0 +1,87 @@
+// Copyright 2021 VILT Group
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package estimate
+
+import (
+	// embed blank import to allow go:embed directive to inject the default price table
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+//go:embed default_prices.json
+var defaultPrices []byte
+
+// ClassPrices represents the prices and restrictions of a storage class.
+type ClassPrices struct {
+	// Price of storing one GB during a month
+	StorageGBMonth float64 `json:"storage_gb_month"`
+	// Price of reading one GB of data stored in this class
+	RetrievalPerGB float64 `json:"retrieval_per_gb"`
+	// Minimum number of days the data is charged for, even if it's deleted earlier
+	MinStorageDays int64 `json:"min_storage_days"`
+}
+
+// ProviderPrices represents the prices of the requests and storage classes of a cloud provider.
+type ProviderPrices struct {
+	// Price of 1000 list requests
+	ListPer1000 float64 `json:"list_per_1000"`
+	// Price of 1000 copy requests. Each part of a multipart copy is also a copy request
+	CopyPer1000 float64 `json:"copy_per_1000"`
+	// Price of 1000 delete requests
+	DeletePer1000 float64 `json:"delete_per_1000"`
+	// Price of 1000 requests that read metadata, e.g. HEAD and GET requests
+	ReadPer1000 float64 `json:"read_per_1000"`
+	// Prices for each storage class, indexed by the name of the class
+	StorageClasses map[string]ClassPrices `json:"storage_classes"`
+}
+
+// PriceTable represents the prices used to estimate the cost of a rollback.
+type PriceTable struct {
+	// Currency in which all the prices are given
+	Currency string `json:"currency"`
+	// Prices for each provider, indexed by the bucket url scheme ("s3" or "gs")
+	Providers map[string]ProviderPrices `json:"providers"`
+}
+
+// DefaultPriceTable returns the price table bundled with brestore.
+func DefaultPriceTable() PriceTable {
+	var table PriceTable
+	if err := json.Unmarshal(defaultPrices, &table); err != nil {
+		panic(fmt.Sprintf("parsing default price table: %v", err))
+	}
+	return table
+}
+
+// LoadPriceTable reads a price table from a JSON file.
+// If the path is the empty string, the default price table is returned.
+func LoadPriceTable(path string) (PriceTable, error) {
+	if path == "" {
+		return DefaultPriceTable(), nil
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return PriceTable{}, fmt.Errorf("reading price table: %w", err)
+	}
+
+	var table PriceTable
+	if err := json.Unmarshal(content, &table); err != nil {
+		return PriceTable{}, fmt.Errorf("parsing price table '%s': %w", path, err)
+	}
+
+	return table, nil
+}
@@ -0,0 +1,162 @@
+// Copyright 2021 VILT Group
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package brestore
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Enumeration of DeleteMarkerModes.
+const (
+	// Represents a search that matches both object versions and delete markers
+	INCLUDE_DELETE_MARKERS DeleteMarkerMode = iota
+	// Represents a search that only matches object versions
+	EXCLUDE_DELETE_MARKERS
+	// Represents a search that only matches delete markers
+	ONLY_DELETE_MARKERS
+)
+
+// DeleteMarkerMode represents how a search treats delete markers.
+type DeleteMarkerMode int
+
+// ParseDeleteMarkerMode converts 'include', 'exclude' or 'only' into a DeleteMarkerMode.
+func ParseDeleteMarkerMode(s string) (DeleteMarkerMode, error) {
+	switch strings.ToLower(s) {
+	case "include":
+		return INCLUDE_DELETE_MARKERS, nil
+	case "exclude":
+		return EXCLUDE_DELETE_MARKERS, nil
+	case "only":
+		return ONLY_DELETE_MARKERS, nil
+	}
+	return INCLUDE_DELETE_MARKERS, fmt.Errorf("unknown delete marker mode '%s'. Must be one of 'include', 'exclude' or 'only'", s)
+}
+
+// VersionFilter represents the criteria used to search the versions/generations of objects.
+// Zero values match everything.
+type VersionFilter struct {
+	// ETag or MD5 checksum, in hex or base64
+	Checksum string
+	// Minimum size in bytes
+	MinSize int64
+	// Maximum size in bytes. Zero means no maximum
+	MaxSize int64
+	// Start (inclusive) and end (exclusive) of the modification time window
+	Since, Until time.Time
+	// Glob pattern matched against the whole key. See KeyPattern
+	KeyPattern *KeyPattern
+	// How delete markers are matched
+	DeleteMarkers DeleteMarkerMode
+}
+
+// VersionCandidate represents the attributes of a version/generation that a VersionFilter checks.
+type VersionCandidate struct {
+	Key          string
+	Size         int64
+	Time         time.Time
+	DeleteMarker bool
+	// Checksums of the content in lowercase hex, e.g. ETag and MD5
+	Checksums []string
+}
+
+// Matches checks if a version/generation meets all the criteria of the filter.
+// Size and checksum criteria never match delete markers.
+func (f VersionFilter) Matches(c VersionCandidate) bool {
+	switch {
+	case c.DeleteMarker && f.DeleteMarkers == EXCLUDE_DELETE_MARKERS:
+		return false
+	case !c.DeleteMarker && f.DeleteMarkers == ONLY_DELETE_MARKERS:
+		return false
+	case !f.Since.IsZero() && c.Time.Before(f.Since):
+		return false
+	case !f.Until.IsZero() && !c.Time.Before(f.Until):
+		return false
+	case f.KeyPattern != nil && !f.KeyPattern.Matches(c.Key):
+		return false
+	}
+
+	if f.MinSize > 0 || f.MaxSize > 0 || f.Checksum != "" {
+		if c.DeleteMarker || c.Size < f.MinSize || (f.MaxSize > 0 && c.Size > f.MaxSize) {
+			return false
+		}
+	}
+
+	if f.Checksum != "" {
+		return hasChecksum(c.Checksums, f.Checksum)
+	}
+
+	return true
+}
+
+// hasChecksum checks if a checksum, in hex or base64, is one of the given hex checksums.
+func hasChecksum(checksums []string, checksum string) bool {
+	checksum = strings.Trim(checksum, "\"")
+	if raw, err := base64.StdEncoding.DecodeString(checksum); err == nil && len(raw) == 16 {
+		checksum = hex.EncodeToString(raw)
+	}
+	checksum = strings.ToLower(checksum)
+
+	for _, c := range checksums {
+		if strings.ToLower(c) == checksum {
+			return true
+		}
+	}
+	return false
+}
+
+// KeyPattern represents a glob pattern for keys. '*' matches any sequence of characters except '/',
+// '**' matches any sequence of characters including '/' and '?' matches any single character except '/'.
+type KeyPattern struct {
+	re *regexp.Regexp
+}
+
+// NewKeyPattern compiles a glob pattern for keys.
+func NewKeyPattern(pattern string) (*KeyPattern, error) {
+	var sb strings.Builder
+	sb.WriteString("^")
+
+	for i := 0; i < len(pattern); i++ {
+		switch {
+		case strings.HasPrefix(pattern[i:], "**"):
+			sb.WriteString(".*")
+			i++
+		case pattern[i] == '*':
+			sb.WriteString("[^/]*")
+		case pattern[i] == '?':
+			sb.WriteString("[^/]")
+		default:
+			sb.WriteString(regexp.QuoteMeta(pattern[i : i+1]))
+		}
+	}
+
+	sb.WriteString("$")
+
+	re, err := regexp.Compile(sb.String())
+	if err != nil {
+		return nil, fmt.Errorf("invalid key pattern '%s': %w", pattern, err)
+	}
+
+	return &KeyPattern{re: re}, nil
+}
+
+// Matches checks if the whole key matches the pattern.
+func (p *KeyPattern) Matches(key string) bool {
+	return p.re.MatchString(key)
+}
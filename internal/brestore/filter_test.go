@@ -0,0 +1,102 @@
+// Copyright 2021 VILT Group
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package brestore
+
+import (
+	"testing"
+	"time"
+)
+
+func TestKeyPattern(t *testing.T) {
+	var tests = []struct {
+		pattern  string
+		key      string
+		expected bool
+	}{
+		{"logs/*.gz", "logs/a.gz", true},
+		{"logs/*.gz", "logs/2021/a.gz", false},
+		{"logs/**.gz", "logs/2021/a.gz", true},
+		{"**/config.yaml", "app/prod/config.yaml", true},
+		{"file?.txt", "file1.txt", true},
+		{"file?.txt", "file/.txt", false},
+		{"a.b", "axb", false},
+	}
+
+	for _, test := range tests {
+		p, err := NewKeyPattern(test.pattern)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if res := p.Matches(test.key); res != test.expected {
+			t.Fatalf("pattern '%s' with key '%s': expected %v | got: %v", test.pattern, test.key, test.expected, res)
+		}
+	}
+}
+
+func TestVersionFilter(t *testing.T) {
+	day := time.Date(2021, 2, 21, 0, 0, 0, 0, time.UTC)
+	version := VersionCandidate{
+		Key:       "data/big.bin",
+		Size:      2 << 30,
+		Time:      day.Add(time.Hour),
+		Checksums: []string{"9e107d9d372bb6826bd81d3542a419d6"},
+	}
+	marker := VersionCandidate{Key: "data/big.bin", Time: day.Add(2 * time.Hour), DeleteMarker: true}
+
+	var tests = []struct {
+		filter   VersionFilter
+		expected [2]bool
+	}{
+		{VersionFilter{}, [2]bool{true, true}},
+		{VersionFilter{DeleteMarkers: EXCLUDE_DELETE_MARKERS}, [2]bool{true, false}},
+		{VersionFilter{DeleteMarkers: ONLY_DELETE_MARKERS}, [2]bool{false, true}},
+		{VersionFilter{MinSize: 1 << 30}, [2]bool{true, false}},
+		{VersionFilter{MaxSize: 1 << 30}, [2]bool{false, false}},
+		{VersionFilter{Since: day, Until: day.Add(90 * time.Minute)}, [2]bool{true, false}},
+		{VersionFilter{Checksum: "\"9E107D9D372BB6826BD81D3542A419D6\""}, [2]bool{true, false}},
+		{VersionFilter{Checksum: "nhB9nTcrtoJr2B01QqQZ1g=="}, [2]bool{true, false}},
+		{VersionFilter{Checksum: "d41d8cd98f00b204e9800998ecf8427e"}, [2]bool{false, false}},
+	}
+
+	for i, test := range tests {
+		res := [2]bool{test.filter.Matches(version), test.filter.Matches(marker)}
+		if res != test.expected {
+			t.Fatalf("test %d: expected %v | got: %v", i, test.expected, res)
+		}
+	}
+}
+
+func TestParseByteCount(t *testing.T) {
+	var tests = []struct {
+		s        string
+		expected int64
+	}{
+		{"512", 512},
+		{"10 KB", 10000},
+		{"1.5GiB", 3 << 29},
+		{"1gb", 1000 * 1000 * 1000},
+	}
+
+	for _, test := range tests {
+		res, err := ParseByteCount(test.s)
+		if err != nil || res != test.expected {
+			t.Fatalf("ParseByteCount(%q): expected %d | got: %d (%v)", test.s, test.expected, res, err)
+		}
+	}
+
+	if _, err := ParseByteCount("10 XB"); err == nil {
+		t.Fatalf("expected error for unknown unit")
+	}
+}
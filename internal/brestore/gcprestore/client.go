@@ -19,6 +19,8 @@ import (
 	"context"
 	"fmt"
 	"google.golang.org/api/option"
+	htransport "google.golang.org/api/transport/http"
+	"net/http"
 )
 
 // GetStorageClientFromFile creates a new storage client with the given credentials file.
@@ -42,3 +44,22 @@ func GetStorageClientFromFile(keyFile string) (*storage.Client, context.Context,
 
 	return client, ctx, nil
 }
+
+// GetHTTPClientFromFile creates a new http client, authorized with the given credentials file and scope,
+// to make requests to the storage JSON API that the storage client doesn't support.
+// If the path to the key file is the empty string, the default credentials are used.
+func GetHTTPClientFromFile(keyFile string, scope string) (*http.Client, context.Context, error) {
+	ctx := context.Background()
+
+	opts := []option.ClientOption{option.WithScopes(scope)}
+	if keyFile != "" {
+		opts = append(opts, option.WithCredentialsFile(keyFile))
+	}
+
+	client, _, err := htransport.NewClient(ctx, opts...)
+	if err != nil {
+		return nil, ctx, fmt.Errorf("creating http client: %w", err)
+	}
+
+	return client, ctx, nil
+}
@@ -0,0 +1,70 @@
+// Copyright 2021 VILT Group
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package history
+
+import (
+	"testing"
+	"time"
+
+	"cloud.google.com/go/storage"
+	"github.com/viltgroup/bucket-restore/internal/brestore/gcprestore/history/generations"
+)
+
+func TestChangeBetween(t *testing.T) {
+	at := func(hour int) time.Time {
+		return time.Date(2021, 2, 21, hour, 0, 0, 0, time.UTC)
+	}
+	// a deleted hour of 0 means the generation is live
+	generation := func(gen int64, created int, deleted int, md5 string) generations.Generation {
+		attrs := &storage.ObjectAttrs{Name: "a", Generation: gen, Created: at(created), MD5: []byte(md5)}
+		if deleted > 0 {
+			attrs.Deleted = at(deleted)
+		}
+		return generations.FromObjectAttrs(attrs)
+	}
+
+	var tests = []struct {
+		gens     generations.Generations
+		from, to time.Time
+		change   Change
+		window   int
+	}{
+		// created inside the window
+		{generations.Generations{generation(1, 3, 0, "x")}, at(2), at(4), ADDED, 1},
+		// deleted inside the window
+		{generations.Generations{generation(1, 1, 3, "x")}, at(2), at(4), REMOVED, 0},
+		// modified inside the window
+		{generations.Generations{generation(1, 1, 3, "x"), generation(2, 3, 0, "y")}, at(2), at(4), MODIFIED, 1},
+		// unchanged
+		{generations.Generations{generation(1, 1, 5, "x"), generation(2, 5, 0, "y")}, at(2), at(4), UNCHANGED, 0},
+		// deleted and created again with the same content, which is a new generation
+		{generations.Generations{generation(1, 1, 3, "x"), generation(2, 4, 0, "x")}, at(2), at(5), MODIFIED, 1},
+		// created and deleted inside the window
+		{generations.Generations{generation(1, 3, 4, "x")}, at(2), at(5), TRANSIENT, 1},
+		// a generation created at the start of the window is part of it
+		{generations.Generations{generation(1, 1, 2, "x"), generation(2, 2, 0, "y")}, at(2), at(4), MODIFIED, 1},
+		// a generation created at the end of the window is not
+		{generations.Generations{generation(1, 1, 4, "x"), generation(2, 4, 0, "y")}, at(2), at(4), UNCHANGED, 0},
+		{generations.Generations{}, at(2), at(4), UNCHANGED, 0},
+	}
+
+	for i, test := range tests {
+		res := ChangeBetween(test.gens, test.from, test.to)
+		if res.Change != test.change || len(res.Window) != test.window {
+			t.Fatalf("test %d: expected %v with %d generations in the window | got: %v with %d generations in the window",
+				i, test.change, test.window, res.Change, len(res.Window))
+		}
+	}
+}
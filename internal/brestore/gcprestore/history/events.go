@@ -0,0 +1,70 @@
+// Copyright 2021 VILT Group
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package history
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/viltgroup/bucket-restore/internal/brestore/gcprestore/history/generations"
+	"github.com/viltgroup/bucket-restore/internal/brestore/timeline"
+)
+
+// replacementTolerance is the maximum difference between the deletion time of a generation and the creation
+// time of the next one for the deletion to be considered a consequence of the object being overwritten.
+const replacementTolerance = time.Second
+
+// Events returns the changes made to a file/object, given its generations.
+// The collection of generations must refer to the same object/path.
+func Events(gens generations.Generations) timeline.Events {
+	var res timeline.Events
+
+	gens.SortByCreatedDateAsc()
+
+	for i, g := range gens {
+		e := timeline.Event{
+			Kind:    timeline.WRITE,
+			Key:     g.Name,
+			Time:    g.Created,
+			Size:    g.Size,
+			Version: strconv.FormatInt(g.Generation, 10),
+		}
+
+		if i > 0 && replacedBy(gens[i-1], g) {
+			e.Kind, e.PreviousSize = timeline.OVERWRITE, gens[i-1].Size
+		}
+		res = append(res, e)
+
+		if !g.Deleted.IsZero() && (i == len(gens)-1 || !replacedBy(g, gens[i+1])) {
+			res = append(res, timeline.Event{
+				Kind:         timeline.DELETE,
+				Key:          g.Name,
+				Time:         g.Deleted,
+				PreviousSize: g.Size,
+				Version:      strconv.FormatInt(g.Generation, 10),
+			})
+		}
+	}
+
+	return res
+}
+
+// replacedBy checks if a generation stopped being live because the next generation was created.
+func replacedBy(g generations.Generation, next generations.Generation) bool {
+	if g.Deleted.IsZero() {
+		return true
+	}
+	return !g.Deleted.Before(next.Created.Add(-replacementTolerance))
+}
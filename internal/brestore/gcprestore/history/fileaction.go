@@ -15,7 +15,6 @@
 package history
 
 import (
-	"bytes"
 	"sort"
 )
 
@@ -24,6 +23,7 @@ const (
 	CREATE Action = iota
 	DELETE
 	NO_ACTION
+	UPDATE_METADATA
 )
 
 // Action represents an action to be taken.
@@ -39,6 +39,8 @@ func (a Action) String() string {
 		return "Delete"
 	case NO_ACTION:
 		return "No Action"
+	case UPDATE_METADATA:
+		return "Update Metadata"
 	default:
 		return "Unknown Action"
 	}
@@ -89,6 +91,14 @@ type FileAction struct {
 	// version of the object matches this generation. A value of 0 in this field
 	// means the pre-condition should be ignored
 	GenerationPreCondition int64
+	// Metageneration pre-condition of metadata updates. The update should only be applied if the metadata
+	// of the current generation wasn't changed since
+	MetagenerationPreCondition int64
+	// Checksum used to compare the content of the current and the desired generations, if both exist
+	Checksum string
+	// Metadata to set on the current generation by a metadata update, and the metadata it replaces
+	Metadata        *ObjectMetadata
+	CurrentMetadata ObjectMetadata
 }
 
 // ActionForStateChange determines the action that should be taken to transition
@@ -96,6 +106,11 @@ type FileAction struct {
 func ActionForStateChange(from PathState, to PathState) FileAction {
 	source := FileOperand{Name: to.Name, Generation: to.Generation}
 
+	// the state of objects with expired history is unknown, so they are left as they are
+	if to.PathStatus == EXPIRED {
+		return FileAction{Action: NO_ACTION, Source: source}
+	}
+
 	switch from.PathStatus {
 	case DELETED:
 		if to.PathStatus == EXISTS {
@@ -107,10 +122,14 @@ func ActionForStateChange(from PathState, to PathState) FileAction {
 			source.Generation = from.Generation
 			return FileAction{Action: DELETE, Source: source, GenerationPreCondition: from.Generation}
 		}
-		if to.Generation != from.Generation && !bytes.Equal(to.MD5, from.MD5) {
-			return FileAction{Action: CREATE, Source: source, GenerationPreCondition: from.Generation}
+		if to.Generation == from.Generation {
+			return FileAction{Action: NO_ACTION, Source: source}
 		}
-		return FileAction{Action: NO_ACTION, Source: source}
+		same, checksum := SameContent(from, to)
+		if !same {
+			return FileAction{Action: CREATE, Source: source, GenerationPreCondition: from.Generation, Checksum: checksum}
+		}
+		return FileAction{Action: NO_ACTION, Source: source, Checksum: checksum}
 	default:
 		return FileAction{Action: NO_ACTION, Source: source}
 	}
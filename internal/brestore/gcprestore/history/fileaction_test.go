@@ -0,0 +1,52 @@
+// Copyright 2021 VILT Group
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package history
+
+import (
+	"testing"
+)
+
+func TestActionForStateChangeChecksums(t *testing.T) {
+	md5A, md5B := []byte{0xa}, []byte{0xb}
+
+	var tests = []struct {
+		from     PathState
+		to       PathState
+		action   Action
+		checksum string
+	}{
+		{PathState{PathStatus: EXISTS, Generation: 2, MD5: md5A, CRC32C: 1, Size: 5},
+			PathState{PathStatus: EXISTS, Generation: 1, MD5: md5B, CRC32C: 1, Size: 5}, CREATE, MD5Checksum},
+		{PathState{PathStatus: EXISTS, Generation: 2, MD5: md5A, CRC32C: 1, Size: 5},
+			PathState{PathStatus: EXISTS, Generation: 1, MD5: md5A, CRC32C: 1, Size: 5}, NO_ACTION, MD5Checksum},
+		// composite objects have no MD5
+		{PathState{PathStatus: EXISTS, Generation: 2, CRC32C: 1, Size: 5, Composite: true},
+			PathState{PathStatus: EXISTS, Generation: 1, CRC32C: 2, Size: 5, Composite: true}, CREATE, CRC32CChecksum},
+		{PathState{PathStatus: EXISTS, Generation: 2, CRC32C: 1, Size: 5, Composite: true},
+			PathState{PathStatus: EXISTS, Generation: 1, CRC32C: 1, Size: 6, Composite: true}, CREATE, CRC32CChecksum},
+		{PathState{PathStatus: EXISTS, Generation: 2, CRC32C: 1, Size: 5, Composite: true},
+			PathState{PathStatus: EXISTS, Generation: 1, MD5: md5A, CRC32C: 1, Size: 5}, NO_ACTION, CRC32CChecksum},
+		{PathState{PathStatus: EXISTS, Generation: 1, CRC32C: 1, Size: 5, Composite: true},
+			PathState{PathStatus: EXISTS, Generation: 1, CRC32C: 1, Size: 5, Composite: true}, NO_ACTION, ""},
+	}
+
+	for i, test := range tests {
+		action := ActionForStateChange(test.from, test.to)
+		if action.Action != test.action || action.Checksum != test.checksum {
+			t.Fatalf("test %d: expected %v by '%s' | got: %v by '%s'",
+				i, test.action, test.checksum, action.Action, action.Checksum)
+		}
+	}
+}
@@ -15,6 +15,7 @@
 package history
 
 import (
+	"bytes"
 	"github.com/viltgroup/bucket-restore/internal/brestore/gcprestore/history/generations"
 	"time"
 )
@@ -27,6 +28,9 @@ const (
 	EXISTS
 	// Represents a file that existed but has been deleted
 	DELETED
+	// Represents a file with no known state because the older part of its history was deleted,
+	// e.g. by lifecycle rules, so it's unknown whether it existed
+	EXPIRED
 )
 
 // PathStatus represents the status of a file in its history.
@@ -42,6 +46,8 @@ func (fs PathStatus) String() string {
 		return "Exists"
 	case DELETED:
 		return "Deleted"
+	case EXPIRED:
+		return "History Expired"
 	default:
 		return "Unknown Status"
 	}
@@ -55,8 +61,46 @@ type PathState struct {
 	Generation int64
 	// Name of the file/directory. Typically this is actually the full path of the object
 	Name string
-	// MD5 checksum of the object
+	// MD5 checksum of the object. Composite objects have none
 	MD5 []byte
+	// CRC32C checksum of the object
+	CRC32C uint32
+	// Whether the object is composed of other objects, e.g. by a parallel composite upload.
+	// The storage client doesn't return the component count, so objects without MD5 are composite
+	Composite bool
+	// Size of the object in bytes
+	Size int64
+	// Storage class of the object
+	StorageClass string
+	// Time when the generation was created
+	Created time.Time
+	// Metageneration of the generation, incremented each time its metadata is changed
+	Metageneration int64
+	// Time when the metadata of the generation was last changed
+	Updated time.Time
+	// Metadata of the generation, as it is now
+	Metadata ObjectMetadata
+	// Holds on the generation, which prevent it from being deleted or replaced while set
+	EventBasedHold bool
+	TemporaryHold  bool
+	// Time until which the retention policy of the bucket prevents the generation from being deleted or replaced
+	RetentionExpirationTime time.Time
+}
+
+// Checksums used to compare the content of two states.
+const (
+	MD5Checksum    = "md5"
+	CRC32CChecksum = "crc32c"
+)
+
+// SameContent checks if two states have the same content by comparing their MD5 checksums or, when one
+// of them has no MD5, such as composite objects, their CRC32C checksums and sizes.
+// Also returns the checksum compared.
+func SameContent(a PathState, b PathState) (bool, string) {
+	if len(a.MD5) == 0 || len(b.MD5) == 0 {
+		return a.CRC32C == b.CRC32C && a.Size == b.Size, CRC32CChecksum
+	}
+	return bytes.Equal(a.MD5, b.MD5), MD5Checksum
 }
 
 // StateAtTime gives the state of a file/object at a certain point in time, given its generations.
@@ -102,7 +146,7 @@ func StateDiffAtTime(gens generations.Generations, t time.Time) (PathState, Path
 
 // StateOfGeneration returns the last known path state of a generation.
 func StateOfGeneration(g generations.Generation) PathState {
-	res := PathState{Generation: g.Generation, Name: g.Name, MD5: g.MD5}
+	res := pathStateOfGeneration(g)
 
 	if !g.Deleted.IsZero() {
 		res.PathStatus = DELETED
@@ -115,7 +159,7 @@ func StateOfGeneration(g generations.Generation) PathState {
 
 // StateOfGenerationAtTime returns the path state of a generation at a given point in time.
 func StateOfGenerationAtTime(g generations.Generation, t time.Time) PathState {
-	res := PathState{Generation: g.Generation, Name: g.Name, MD5: g.MD5}
+	res := pathStateOfGeneration(g)
 
 	if !g.Deleted.IsZero() && g.Deleted.Before(t) {
 		res.PathStatus = DELETED
@@ -125,3 +169,25 @@ func StateOfGenerationAtTime(g generations.Generation, t time.Time) PathState {
 
 	return res
 }
+
+// pathStateOfGeneration returns a path state with the attributes of a generation and no status.
+func pathStateOfGeneration(g generations.Generation) PathState {
+	return PathState{
+		Generation:   g.Generation,
+		Name:         g.Name,
+		MD5:          g.MD5,
+		CRC32C:       g.CRC32C,
+		Composite:    len(g.MD5) == 0,
+		Size:         g.Size,
+		StorageClass: g.StorageClass,
+		Created:      g.Created,
+
+		Metageneration: g.Metageneration,
+		Updated:        g.Updated,
+		Metadata:       MetadataOf(g.ObjectAttrs),
+
+		EventBasedHold:          g.EventBasedHold,
+		TemporaryHold:           g.TemporaryHold,
+		RetentionExpirationTime: g.RetentionExpirationTime,
+	}
+}
@@ -0,0 +1,34 @@
+// Copyright 2021 VILT Group
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package generations
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"cloud.google.com/go/storage"
+)
+
+// ContentOf downloads the content of a generation. Objects stored with gzip content encoding are decompressed.
+// The returned reader must be closed by the caller.
+func ContentOf(bucket *storage.BucketHandle, g Generation) (io.ReadCloser, error) {
+	r, err := bucket.Object(g.Name).Generation(g.Generation).NewReader(context.Background())
+	if err != nil {
+		return nil, fmt.Errorf("downloading generation '%d' of '%s': %w", g.Generation, g.Name, err)
+	}
+
+	return r, nil
+}
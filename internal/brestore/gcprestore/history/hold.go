@@ -0,0 +1,43 @@
+// Copyright 2021 VILT Group
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package history
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// BlockedBy returns why an action can't be applied to an object in its current state, or the empty string
+// if it can. Deleting or replacing the live generation of an object fails while it has an event-based or
+// temporary hold, or until the retention policy of the bucket expires for it. Metadata can still be updated.
+func BlockedBy(action FileAction, from PathState, now time.Time) string {
+	if action.Action == NO_ACTION || action.Action == UPDATE_METADATA || from.PathStatus != EXISTS {
+		return ""
+	}
+
+	var reasons []string
+	if from.EventBasedHold {
+		reasons = append(reasons, "event-based hold")
+	}
+	if from.TemporaryHold {
+		reasons = append(reasons, "temporary hold")
+	}
+	if from.RetentionExpirationTime.After(now) {
+		reasons = append(reasons, fmt.Sprintf("retained until %v", from.RetentionExpirationTime))
+	}
+
+	return strings.Join(reasons, ", ")
+}
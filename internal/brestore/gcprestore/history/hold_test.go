@@ -0,0 +1,47 @@
+// Copyright 2021 VILT Group
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package history
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBlockedBy(t *testing.T) {
+	now := time.Date(2021, 2, 21, 0, 0, 0, 0, time.UTC)
+	later := now.Add(24 * time.Hour)
+
+	var tests = []struct {
+		action   Action
+		from     PathState
+		expected string
+	}{
+		{CREATE, PathState{PathStatus: EXISTS}, ""},
+		{CREATE, PathState{PathStatus: EXISTS, EventBasedHold: true}, "event-based hold"},
+		{DELETE, PathState{PathStatus: EXISTS, TemporaryHold: true, RetentionExpirationTime: later},
+			"temporary hold, retained until " + later.String()},
+		{DELETE, PathState{PathStatus: EXISTS, RetentionExpirationTime: now.Add(-time.Hour)}, ""},
+		{NO_ACTION, PathState{PathStatus: EXISTS, EventBasedHold: true}, ""},
+		{UPDATE_METADATA, PathState{PathStatus: EXISTS, TemporaryHold: true}, ""},
+		{CREATE, PathState{PathStatus: DELETED, EventBasedHold: true}, ""},
+	}
+
+	for i, test := range tests {
+		res := BlockedBy(FileAction{Action: test.action}, test.from, now)
+		if res != test.expected {
+			t.Fatalf("test %d: expected %q | got: %q", i, test.expected, res)
+		}
+	}
+}
@@ -0,0 +1,69 @@
+// Copyright 2021 VILT Group
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package history
+
+import (
+	"time"
+
+	"github.com/viltgroup/bucket-restore/internal/brestore/gcprestore"
+	"github.com/viltgroup/bucket-restore/internal/brestore/gcprestore/history/generations"
+)
+
+// HistoryHorizon represents what limits how far back the history of the objects of a bucket goes.
+// A zero HistoryHorizon never considers the history of an object expired.
+type HistoryHorizon struct {
+	// Lifecycle rules of the bucket, which may have deleted noncurrent generations
+	Lifecycle gcprestore.Lifecycle
+	// Creation time of the bucket. No object existed before it
+	BucketCreated time.Time
+	// Time when the generations were listed
+	Now time.Time
+}
+
+// Expired checks if the generations of an object that were live at t may have been deleted, in which case
+// it's unknown whether the object existed at t. This happens when t is before the oldest known generation
+// and the lifecycle rules would already have deleted an older generation replaced by it.
+// The collection of generations must refer to the same object/path.
+func (h HistoryHorizon) Expired(gens generations.Generations, t time.Time) bool {
+	if h.Now.IsZero() || len(gens) == 0 {
+		return false
+	}
+
+	gens.SortByCreatedDateAsc()
+	first := gens[0]
+
+	if !t.Before(first.Created) || t.Before(h.BucketCreated) {
+		return false
+	}
+
+	// the creation of a deleted generation is unknown, so it's assumed to be as old as possible,
+	// and it became noncurrent, at the latest, when the oldest known generation was created
+	at, _, ok := h.Lifecycle.ArchivedExpiry(gcprestore.ArchivedGeneration{
+		NoncurrentSince: first.Created,
+		StorageClass:    first.StorageClass,
+		NewerVersions:   int64(len(gens)),
+	})
+	return ok && !at.After(h.Now)
+}
+
+// StateDiffAtTime is like the StateDiffAtTime function, but gives the EXPIRED status to objects whose
+// generations at t may have been deleted.
+func (h HistoryHorizon) StateDiffAtTime(gens generations.Generations, t time.Time) (PathState, PathState) {
+	res, last := StateDiffAtTime(gens, t)
+	if res.PathStatus == NOT_EXISTENT && h.Expired(gens, t) {
+		res.PathStatus = EXPIRED
+	}
+	return res, last
+}
@@ -0,0 +1,69 @@
+// Copyright 2021 VILT Group
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package history
+
+import (
+	"testing"
+	"time"
+
+	"cloud.google.com/go/storage"
+	"github.com/viltgroup/bucket-restore/internal/brestore/gcprestore"
+	"github.com/viltgroup/bucket-restore/internal/brestore/gcprestore/history/generations"
+)
+
+func TestHistoryHorizonExpired(t *testing.T) {
+	day := func(d int) time.Time {
+		return time.Date(2021, 2, d, 0, 0, 0, 0, time.UTC)
+	}
+	live := generations.Generations{generations.FromObjectAttrs(
+		&storage.ObjectAttrs{Name: "a", Generation: 1, Created: day(10), StorageClass: "STANDARD"})}
+	deleted := generations.Generations{generations.FromObjectAttrs(
+		&storage.ObjectAttrs{Name: "a", Generation: 1, Created: day(10), Deleted: day(11), StorageClass: "STANDARD"})}
+	lc := gcprestore.Lifecycle{Lifecycle: storage.Lifecycle{Rules: []storage.LifecycleRule{{
+		Action:    storage.LifecycleAction{Type: storage.DeleteAction},
+		Condition: storage.LifecycleCondition{Liveness: storage.Archived, DaysSinceNoncurrentTime: 7},
+	}}}}
+
+	var tests = []struct {
+		horizon  HistoryHorizon
+		gens     generations.Generations
+		t        time.Time
+		expected bool
+	}{
+		// GCP Storage has no delete markers nor null versions, so a deleted oldest generation hides nothing
+		{HistoryHorizon{Now: day(20)}, deleted, day(5), false},
+		// the rule deleted the generations replaced by the oldest generation on day 17
+		{HistoryHorizon{Lifecycle: lc, Now: day(20)}, live, day(5), true},
+		{HistoryHorizon{Lifecycle: lc, Now: day(20)}, deleted, day(5), true},
+		{HistoryHorizon{Lifecycle: lc, Now: day(12)}, live, day(5), false},
+		// t after the oldest generation
+		{HistoryHorizon{Lifecycle: lc, Now: day(20)}, live, day(11), false},
+		// t before or after the creation of the bucket
+		{HistoryHorizon{Lifecycle: lc, Now: day(20), BucketCreated: day(6)}, live, day(5), false},
+		{HistoryHorizon{Lifecycle: lc, Now: day(20), BucketCreated: day(4)}, live, day(5), true},
+		// a zero horizon, as with --delete-expired-history, never expires
+		{HistoryHorizon{Lifecycle: lc}, live, day(5), false},
+		// no lifecycle
+		{HistoryHorizon{Now: day(20)}, live, day(5), false},
+		{HistoryHorizon{Lifecycle: lc, Now: day(20)}, generations.Generations{}, day(5), false},
+	}
+
+	for i, test := range tests {
+		res := test.horizon.Expired(test.gens, test.t)
+		if res != test.expected {
+			t.Fatalf("test %d: expected %v | got: %v", i, test.expected, res)
+		}
+	}
+}
@@ -0,0 +1,191 @@
+// Copyright 2021 VILT Group
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package history
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"cloud.google.com/go/storage"
+)
+
+// ObjectMetadata represents the attributes of a generation that can be changed without creating a new
+// generation. Changing them increments the metageneration of the object.
+type ObjectMetadata struct {
+	ContentType        string            `json:"content_type,omitempty"`
+	ContentLanguage    string            `json:"content_language,omitempty"`
+	ContentEncoding    string            `json:"content_encoding,omitempty"`
+	ContentDisposition string            `json:"content_disposition,omitempty"`
+	CacheControl       string            `json:"cache_control,omitempty"`
+	Metadata           map[string]string `json:"metadata,omitempty"`
+}
+
+// MetadataOf returns the metadata in the attributes of a generation.
+func MetadataOf(attrs *storage.ObjectAttrs) ObjectMetadata {
+	return ObjectMetadata{
+		ContentType:        attrs.ContentType,
+		ContentLanguage:    attrs.ContentLanguage,
+		ContentEncoding:    attrs.ContentEncoding,
+		ContentDisposition: attrs.ContentDisposition,
+		CacheControl:       attrs.CacheControl,
+		Metadata:           attrs.Metadata,
+	}
+}
+
+// Equal checks if two sets of metadata are the same. A nil and an empty map of custom metadata are the same.
+func (m ObjectMetadata) Equal(o ObjectMetadata) bool {
+	if m.ContentType != o.ContentType || m.ContentLanguage != o.ContentLanguage ||
+		m.ContentEncoding != o.ContentEncoding || m.ContentDisposition != o.ContentDisposition ||
+		m.CacheControl != o.CacheControl || len(m.Metadata) != len(o.Metadata) {
+		return false
+	}
+	for k, v := range m.Metadata {
+		if ov, ok := o.Metadata[k]; !ok || ov != v {
+			return false
+		}
+	}
+	return true
+}
+
+// ToUpdate returns the update that sets the metadata of an object to this one. Fields this one doesn't have
+// are deleted. Updates merge custom metadata with the one of the object, so keys this one doesn't have are
+// only deleted if the update has no custom metadata at all.
+func (m ObjectMetadata) ToUpdate() storage.ObjectAttrsToUpdate {
+	res := storage.ObjectAttrsToUpdate{
+		ContentType:        m.ContentType,
+		ContentLanguage:    m.ContentLanguage,
+		ContentEncoding:    m.ContentEncoding,
+		ContentDisposition: m.ContentDisposition,
+		CacheControl:       m.CacheControl,
+		Metadata:           m.Metadata,
+	}
+	if len(res.Metadata) == 0 {
+		res.Metadata = map[string]string{}
+	}
+	return res
+}
+
+// RemovesKeys checks if setting the metadata of an object to this one deletes any of its custom metadata keys.
+func (m ObjectMetadata) RemovesKeys(current ObjectMetadata) bool {
+	for k := range current.Metadata {
+		if _, ok := m.Metadata[k]; !ok {
+			return true
+		}
+	}
+	return false
+}
+
+// MetadataChangedSince checks if the metadata of a generation was changed after a point in time.
+// A generation whose metageneration is 1 still has the metadata it was created with.
+func MetadataChangedSince(s PathState, t time.Time) bool {
+	return s.Metageneration > 1 && s.Updated.After(t)
+}
+
+// SnapshotEntry represents the metadata of an object in a snapshot.
+type SnapshotEntry struct {
+	Name           string    `json:"name"`
+	Generation     int64     `json:"generation"`
+	Metageneration int64     `json:"metageneration"`
+	Updated        time.Time `json:"updated"`
+	ObjectMetadata
+}
+
+// MetadataSnapshot represents the metadata of the live objects of a bucket at a point in time, saved by the
+// snapshot command so that metadata changed in place can be restored later.
+type MetadataSnapshot struct {
+	Bucket  string          `json:"bucket"`
+	Prefix  string          `json:"prefix"`
+	Taken   time.Time       `json:"taken"`
+	Objects []SnapshotEntry `json:"objects"`
+}
+
+// LoadSnapshot reads a snapshot saved by the snapshot command.
+func LoadSnapshot(path string) (MetadataSnapshot, error) {
+	var res MetadataSnapshot
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return res, fmt.Errorf("reading metadata snapshot: %w", err)
+	}
+	if err := json.Unmarshal(content, &res); err != nil {
+		return res, fmt.Errorf("parsing metadata snapshot '%s': %w", path, err)
+	}
+
+	return res, nil
+}
+
+// MetadataSnapshots represents a collection of snapshots of the same bucket.
+type MetadataSnapshots []MetadataSnapshot
+
+// MetadataAt returns the metadata a generation of an object had at a point in time, if any of the snapshots
+// knows it. The metadata in a snapshot entry didn't change between its update time and the time the snapshot
+// was taken, so it's known for any point in time in between.
+func (ss MetadataSnapshots) MetadataAt(name string, generation int64, t time.Time) (ObjectMetadata, bool) {
+	for _, s := range ss {
+		if t.After(s.Taken) {
+			continue
+		}
+		for _, e := range s.Objects {
+			if e.Name == name && e.Generation == generation && !e.Updated.After(t) {
+				return e.ObjectMetadata, true
+			}
+		}
+	}
+	return ObjectMetadata{}, false
+}
+
+// MetadataActionFor determines whether the metadata of an object that needs no other action should be restored
+// to the one it had at a point in time. The metadata of the desired generation is taken from the generation
+// itself, if it didn't change since then, or from the snapshots. The second value is true when the metadata
+// changed after the point in time but can't be recovered.
+func MetadataActionFor(action FileAction, from PathState, to PathState, t time.Time,
+	snapshots MetadataSnapshots) (FileAction, bool) {
+	if action.Action != NO_ACTION || from.PathStatus != EXISTS || to.PathStatus != EXISTS {
+		return action, false
+	}
+
+	var desired ObjectMetadata
+	var ok bool
+
+	if to.Generation == from.Generation {
+		if !MetadataChangedSince(from, t) {
+			return action, false
+		}
+		desired, ok = snapshots.MetadataAt(to.Name, to.Generation, t)
+	} else if !MetadataChangedSince(to, t) {
+		desired, ok = to.Metadata, true
+	} else {
+		desired, ok = snapshots.MetadataAt(to.Name, to.Generation, t)
+	}
+
+	if !ok {
+		return action, true
+	}
+	if desired.Equal(from.Metadata) {
+		return action, false
+	}
+
+	return FileAction{
+		Action:                     UPDATE_METADATA,
+		Source:                     FileOperand{Name: from.Name, Generation: from.Generation},
+		GenerationPreCondition:     from.Generation,
+		MetagenerationPreCondition: from.Metageneration,
+		Checksum:                   action.Checksum,
+		Metadata:                   &desired,
+		CurrentMetadata:            from.Metadata,
+	}, false
+}
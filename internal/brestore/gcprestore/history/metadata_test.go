@@ -0,0 +1,171 @@
+// Copyright 2021 VILT Group
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package history
+
+import (
+	"testing"
+	"time"
+)
+
+func TestObjectMetadataEqual(t *testing.T) {
+	var tests = []struct {
+		a, b     ObjectMetadata
+		expected bool
+	}{
+		{ObjectMetadata{}, ObjectMetadata{}, true},
+		{ObjectMetadata{Metadata: map[string]string{}}, ObjectMetadata{}, true},
+		{ObjectMetadata{ContentType: "text/html"}, ObjectMetadata{ContentType: "text/plain"}, false},
+		{ObjectMetadata{Metadata: map[string]string{"a": "1"}}, ObjectMetadata{Metadata: map[string]string{"a": "1"}}, true},
+		{ObjectMetadata{Metadata: map[string]string{"a": "1"}}, ObjectMetadata{Metadata: map[string]string{"a": "2"}}, false},
+		{ObjectMetadata{Metadata: map[string]string{"a": ""}}, ObjectMetadata{Metadata: map[string]string{"b": ""}}, false},
+	}
+
+	for i, test := range tests {
+		res := test.a.Equal(test.b)
+		if res != test.expected {
+			t.Fatalf("test %d: expected %v | got: %v", i, test.expected, res)
+		}
+	}
+}
+
+func TestObjectMetadataRemovesKeys(t *testing.T) {
+	var tests = []struct {
+		m, current ObjectMetadata
+		expected   bool
+	}{
+		{ObjectMetadata{}, ObjectMetadata{}, false},
+		{ObjectMetadata{Metadata: map[string]string{"a": "1"}}, ObjectMetadata{}, false},
+		{ObjectMetadata{Metadata: map[string]string{"a": "1"}}, ObjectMetadata{Metadata: map[string]string{"a": "2"}}, false},
+		{ObjectMetadata{Metadata: map[string]string{"a": "1"}}, ObjectMetadata{Metadata: map[string]string{"b": "2"}}, true},
+		{ObjectMetadata{}, ObjectMetadata{Metadata: map[string]string{"b": "2"}}, true},
+	}
+
+	for i, test := range tests {
+		res := test.m.RemovesKeys(test.current)
+		if res != test.expected {
+			t.Fatalf("test %d: expected %v | got: %v", i, test.expected, res)
+		}
+	}
+}
+
+func TestMetadataSnapshotsMetadataAt(t *testing.T) {
+	ts := time.Date(2021, 2, 21, 0, 0, 0, 0, time.UTC)
+	snapshots := MetadataSnapshots{
+		{Taken: ts.Add(-time.Hour), Objects: []SnapshotEntry{
+			{Name: "a", Generation: 1, Updated: ts.Add(-2 * time.Hour), ObjectMetadata: ObjectMetadata{ContentType: "old"}},
+		}},
+		{Taken: ts.Add(time.Hour), Objects: []SnapshotEntry{
+			{Name: "a", Generation: 1, Updated: ts.Add(-time.Minute), ObjectMetadata: ObjectMetadata{ContentType: "text/html"}},
+			{Name: "b", Generation: 2, Updated: ts.Add(time.Minute), ObjectMetadata: ObjectMetadata{ContentType: "text/html"}},
+		}},
+	}
+
+	var tests = []struct {
+		name       string
+		generation int64
+		expected   string
+		expectedOk bool
+	}{
+		{"a", 1, "text/html", true},
+		{"a", 2, "", false},
+		{"b", 2, "", false},
+		{"c", 1, "", false},
+	}
+
+	for i, test := range tests {
+		res, ok := snapshots.MetadataAt(test.name, test.generation, ts)
+		if ok != test.expectedOk || res.ContentType != test.expected {
+			t.Fatalf("test %d: expected %q, %v | got: %q, %v", i, test.expected, test.expectedOk, res.ContentType, ok)
+		}
+	}
+}
+
+func TestMetadataActionFor(t *testing.T) {
+	ts := time.Date(2021, 2, 21, 0, 0, 0, 0, time.UTC)
+	before, after := ts.Add(-time.Hour), ts.Add(time.Hour)
+	html, plain := ObjectMetadata{ContentType: "text/html"}, ObjectMetadata{ContentType: "text/plain"}
+	snapshots := MetadataSnapshots{
+		{Taken: after, Objects: []SnapshotEntry{
+			{Name: "a", Generation: 1, Metageneration: 1, Updated: before, ObjectMetadata: html},
+			{Name: "a", Generation: 3, Metageneration: 2, Updated: before, ObjectMetadata: plain},
+		}},
+	}
+
+	var tests = []struct {
+		action           Action
+		from, to         PathState
+		expected         Action
+		expectedMetadata string
+		expectedUnknown  bool
+	}{
+		// metadata of the current generation changed after the point in time, recovered from the snapshot
+		{NO_ACTION,
+			PathState{PathStatus: EXISTS, Name: "a", Generation: 1, Metageneration: 3, Updated: after, Metadata: plain},
+			PathState{PathStatus: EXISTS, Name: "a", Generation: 1, Metageneration: 3, Updated: after, Metadata: plain},
+			UPDATE_METADATA, "text/html", false},
+		// metadata of the current generation changed, but not after the point in time
+		{NO_ACTION,
+			PathState{PathStatus: EXISTS, Name: "a", Generation: 1, Metageneration: 3, Updated: before, Metadata: plain},
+			PathState{PathStatus: EXISTS, Name: "a", Generation: 1, Metageneration: 3, Updated: before, Metadata: plain},
+			NO_ACTION, "", false},
+		// metadata of the current generation changed after the point in time, with no snapshot of it
+		{NO_ACTION,
+			PathState{PathStatus: EXISTS, Name: "a", Generation: 2, Metageneration: 2, Updated: after, Metadata: plain},
+			PathState{PathStatus: EXISTS, Name: "a", Generation: 2, Metageneration: 2, Updated: after, Metadata: plain},
+			NO_ACTION, "", true},
+		// same content uploaded again with other metadata, recovered from the desired generation
+		{NO_ACTION,
+			PathState{PathStatus: EXISTS, Name: "a", Generation: 4, Metageneration: 1, Updated: after, Metadata: plain},
+			PathState{PathStatus: EXISTS, Name: "a", Generation: 2, Metageneration: 1, Updated: after, Metadata: html},
+			UPDATE_METADATA, "text/html", false},
+		// same content uploaded again, with the same metadata
+		{NO_ACTION,
+			PathState{PathStatus: EXISTS, Name: "a", Generation: 4, Metageneration: 1, Updated: after, Metadata: html},
+			PathState{PathStatus: EXISTS, Name: "a", Generation: 2, Metageneration: 1, Updated: after, Metadata: html},
+			NO_ACTION, "", false},
+		// desired generation changed after the point in time, recovered from the snapshot
+		{NO_ACTION,
+			PathState{PathStatus: EXISTS, Name: "a", Generation: 4, Metageneration: 1, Updated: after, Metadata: html},
+			PathState{PathStatus: EXISTS, Name: "a", Generation: 3, Metageneration: 3, Updated: after, Metadata: html},
+			UPDATE_METADATA, "text/plain", false},
+		// other actions restore the metadata with the content
+		{CREATE,
+			PathState{PathStatus: EXISTS, Name: "a", Generation: 1, Metageneration: 3, Updated: after, Metadata: plain},
+			PathState{PathStatus: EXISTS, Name: "a", Generation: 5, Metageneration: 1, Updated: before, Metadata: html},
+			CREATE, "", false},
+		{NO_ACTION,
+			PathState{PathStatus: DELETED, Name: "a", Generation: 1, Metageneration: 3, Updated: after, Metadata: plain},
+			PathState{PathStatus: DELETED, Name: "a", Generation: 1, Metageneration: 3, Updated: after, Metadata: plain},
+			NO_ACTION, "", false},
+	}
+
+	for i, test := range tests {
+		res, unknown := MetadataActionFor(FileAction{Action: test.action}, test.from, test.to, ts, snapshots)
+		if res.Action != test.expected || unknown != test.expectedUnknown {
+			t.Fatalf("test %d: expected %v, %v | got: %v, %v", i, test.expected, test.expectedUnknown,
+				res.Action, unknown)
+		}
+		if res.Action != UPDATE_METADATA {
+			continue
+		}
+		if res.Metadata.ContentType != test.expectedMetadata ||
+			res.GenerationPreCondition != test.from.Generation ||
+			res.MetagenerationPreCondition != test.from.Metageneration {
+			t.Fatalf("test %d: expected %q on #%d/%d | got: %q on #%d/%d", i, test.expectedMetadata,
+				test.from.Generation, test.from.Metageneration, res.Metadata.ContentType,
+				res.GenerationPreCondition, res.MetagenerationPreCondition)
+		}
+	}
+}
@@ -0,0 +1,185 @@
+// Copyright 2021 VILT Group
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gcprestore
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"cloud.google.com/go/storage"
+)
+
+// ArchivedGeneration represents the attributes of a noncurrent generation checked by lifecycle conditions.
+type ArchivedGeneration struct {
+	Created         time.Time
+	NoncurrentSince time.Time
+	CustomTime      time.Time
+	StorageClass    string
+	// Number of generations of the same object newer than this one
+	NewerVersions int64
+}
+
+// Lifecycle represents the lifecycle rules of a bucket.
+type Lifecycle struct {
+	storage.Lifecycle
+}
+
+// ArchivedExpiry returns when a noncurrent generation will be deleted by the lifecycle rules, and a description
+// of the rule that deletes it first. The last value is false if no rule deletes the generation.
+// Generations that already meet all conditions of a rule are deleted at the time the last condition was met,
+// which may be in the past if the rule didn't run yet.
+func (l Lifecycle) ArchivedExpiry(g ArchivedGeneration) (time.Time, string, bool) {
+	var res time.Time
+	var description string
+
+	for i, rule := range l.Rules {
+		if rule.Action.Type != storage.DeleteAction {
+			continue
+		}
+
+		expiry, ok := archivedDeleteTime(rule.Condition, g)
+		if !ok {
+			continue
+		}
+
+		if description == "" || expiry.Before(res) {
+			res, description = expiry, describeRule(i, rule.Condition)
+		}
+	}
+
+	return res, description, description != ""
+}
+
+// ArchivedRules describes the delete rules that may delete noncurrent generations.
+func (l Lifecycle) ArchivedRules() []string {
+	var res []string
+	for i, rule := range l.Rules {
+		if rule.Action.Type != storage.DeleteAction || rule.Condition.Liveness == storage.Live {
+			continue
+		}
+		res = append(res, describeRule(i, rule.Condition))
+	}
+	return res
+}
+
+// WithArchivedDeletion returns the lifecycle with a rule that deletes noncurrent generations the given
+// number of days after they become noncurrent. The last value is false if the lifecycle already has that rule.
+func (l Lifecycle) WithArchivedDeletion(days int64) (storage.Lifecycle, bool) {
+	rule := storage.LifecycleRule{
+		Action: storage.LifecycleAction{Type: storage.DeleteAction},
+		Condition: storage.LifecycleCondition{
+			Liveness:                storage.Archived,
+			DaysSinceNoncurrentTime: days,
+		},
+	}
+
+	for _, r := range l.Rules {
+		if reflect.DeepEqual(r, rule) {
+			return l.Lifecycle, false
+		}
+	}
+
+	rules := append([]storage.LifecycleRule{}, l.Rules...)
+	return storage.Lifecycle{Rules: append(rules, rule)}, true
+}
+
+// archivedDeleteTime returns when all the conditions of a delete rule are met by a noncurrent generation.
+func archivedDeleteTime(c storage.LifecycleCondition, g ArchivedGeneration) (time.Time, bool) {
+	if c.Liveness == storage.Live {
+		return time.Time{}, false
+	}
+	if len(c.MatchesStorageClasses) > 0 && !containsString(c.MatchesStorageClasses, g.StorageClass) {
+		return time.Time{}, false
+	}
+	if c.NumNewerVersions > 0 && g.NewerVersions < c.NumNewerVersions {
+		return time.Time{}, false
+	}
+	if !c.CreatedBefore.IsZero() && !g.Created.Before(c.CreatedBefore) {
+		return time.Time{}, false
+	}
+	if !c.NoncurrentTimeBefore.IsZero() && !g.NoncurrentSince.Before(c.NoncurrentTimeBefore) {
+		return time.Time{}, false
+	}
+	if (!c.CustomTimeBefore.IsZero() || c.DaysSinceCustomTime > 0) && g.CustomTime.IsZero() {
+		return time.Time{}, false
+	}
+	if !c.CustomTimeBefore.IsZero() && !g.CustomTime.Before(c.CustomTimeBefore) {
+		return time.Time{}, false
+	}
+
+	// the generation is deleted once the last of the age conditions is met
+	res := g.NoncurrentSince
+	if c.AgeInDays > 0 {
+		res = latest(res, g.Created.Add(days(c.AgeInDays)))
+	}
+	if c.DaysSinceNoncurrentTime > 0 {
+		res = latest(res, g.NoncurrentSince.Add(days(c.DaysSinceNoncurrentTime)))
+	}
+	if c.DaysSinceCustomTime > 0 {
+		res = latest(res, g.CustomTime.Add(days(c.DaysSinceCustomTime)))
+	}
+
+	return res, true
+}
+
+// describeRule returns a description of a lifecycle rule with its position and conditions.
+func describeRule(i int, c storage.LifecycleCondition) string {
+	var conditions []string
+	if c.AgeInDays > 0 {
+		conditions = append(conditions, fmt.Sprintf("age: %d", c.AgeInDays))
+	}
+	if c.NumNewerVersions > 0 {
+		conditions = append(conditions, fmt.Sprintf("numNewerVersions: %d", c.NumNewerVersions))
+	}
+	if c.DaysSinceNoncurrentTime > 0 {
+		conditions = append(conditions, fmt.Sprintf("daysSinceNoncurrentTime: %d", c.DaysSinceNoncurrentTime))
+	}
+	if c.DaysSinceCustomTime > 0 {
+		conditions = append(conditions, fmt.Sprintf("daysSinceCustomTime: %d", c.DaysSinceCustomTime))
+	}
+	if !c.CreatedBefore.IsZero() {
+		conditions = append(conditions, fmt.Sprintf("createdBefore: %s", c.CreatedBefore.Format("2006-01-02")))
+	}
+	if !c.NoncurrentTimeBefore.IsZero() {
+		conditions = append(conditions, fmt.Sprintf("noncurrentTimeBefore: %s", c.NoncurrentTimeBefore.Format("2006-01-02")))
+	}
+	if len(c.MatchesStorageClasses) > 0 {
+		conditions = append(conditions, fmt.Sprintf("matchesStorageClass: %s", strings.Join(c.MatchesStorageClasses, ",")))
+	}
+
+	return fmt.Sprintf("delete rule #%d (%s)", i+1, strings.Join(conditions, ", "))
+}
+
+func days(n int64) time.Duration {
+	return time.Duration(n) * 24 * time.Hour
+}
+
+func latest(a time.Time, b time.Time) time.Time {
+	if b.After(a) {
+		return b
+	}
+	return a
+}
+
+func containsString(values []string, s string) bool {
+	for _, v := range values {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,93 @@
+// Copyright 2021 VILT Group
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gcprestore
+
+import (
+	"testing"
+	"time"
+
+	"cloud.google.com/go/storage"
+)
+
+func deleteRule(c storage.LifecycleCondition) storage.LifecycleRule {
+	return storage.LifecycleRule{Action: storage.LifecycleAction{Type: storage.DeleteAction}, Condition: c}
+}
+
+func TestArchivedExpiry(t *testing.T) {
+	created := time.Date(2021, 2, 1, 0, 0, 0, 0, time.UTC)
+	noncurrent := time.Date(2021, 2, 21, 0, 0, 0, 0, time.UTC)
+
+	var tests = []struct {
+		rule          storage.LifecycleRule
+		newerVersions int64
+		expected      time.Time
+		ok            bool
+	}{
+		{deleteRule(storage.LifecycleCondition{NumNewerVersions: 2}), 1, time.Time{}, false},
+		{deleteRule(storage.LifecycleCondition{NumNewerVersions: 2}), 2, noncurrent, true},
+		{deleteRule(storage.LifecycleCondition{DaysSinceNoncurrentTime: 7}), 1, noncurrent.Add(7 * 24 * time.Hour), true},
+		{deleteRule(storage.LifecycleCondition{AgeInDays: 30, Liveness: storage.Archived}), 1, created.Add(30 * 24 * time.Hour), true},
+		{deleteRule(storage.LifecycleCondition{AgeInDays: 1, Liveness: storage.Live}), 1, time.Time{}, false},
+		{deleteRule(storage.LifecycleCondition{AgeInDays: 1, MatchesStorageClasses: []string{"NEARLINE"}}), 1, time.Time{}, false},
+		{storage.LifecycleRule{
+			Action:    storage.LifecycleAction{Type: storage.SetStorageClassAction, StorageClass: "NEARLINE"},
+			Condition: storage.LifecycleCondition{AgeInDays: 1},
+		}, 1, time.Time{}, false},
+	}
+
+	for i, test := range tests {
+		lc := Lifecycle{Lifecycle: storage.Lifecycle{Rules: []storage.LifecycleRule{test.rule}}}
+		at, _, ok := lc.ArchivedExpiry(ArchivedGeneration{
+			Created:         created,
+			NoncurrentSince: noncurrent,
+			StorageClass:    "STANDARD",
+			NewerVersions:   test.newerVersions,
+		})
+		if ok != test.ok || !at.Equal(test.expected) {
+			t.Fatalf("test %d: expected %v (%v) | got: %v (%v)", i, test.expected, test.ok, at, ok)
+		}
+	}
+}
+
+func TestArchivedRules(t *testing.T) {
+	lc := Lifecycle{storage.Lifecycle{Rules: []storage.LifecycleRule{
+		deleteRule(storage.LifecycleCondition{AgeInDays: 30, Liveness: storage.Live}),
+		deleteRule(storage.LifecycleCondition{NumNewerVersions: 3}),
+	}}}
+
+	rules := lc.ArchivedRules()
+	expected := "delete rule #2 (numNewerVersions: 3)"
+	if len(rules) != 1 || rules[0] != expected {
+		t.Fatalf("expected [%s] | got: %v", expected, rules)
+	}
+}
+
+func TestWithArchivedDeletion(t *testing.T) {
+	lc := Lifecycle{storage.Lifecycle{Rules: []storage.LifecycleRule{
+		deleteRule(storage.LifecycleCondition{AgeInDays: 30, Liveness: storage.Live}),
+	}}}
+
+	res, changed := lc.WithArchivedDeletion(90)
+	if !changed || len(res.Rules) != 2 || res.Rules[1].Condition.DaysSinceNoncurrentTime != 90 {
+		t.Fatalf("expected a rule to be added | got: %+v (%v)", res.Rules, changed)
+	}
+	if len(lc.Rules) != 1 {
+		t.Fatalf("expected the original lifecycle to be unchanged | got: %+v", lc.Rules)
+	}
+
+	if _, changed := (Lifecycle{res}).WithArchivedDeletion(90); changed {
+		t.Fatalf("expected no change when the rule already exists")
+	}
+}
@@ -0,0 +1,81 @@
+// Copyright 2021 VILT Group
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gcprestore
+
+import (
+	"strings"
+
+	"cloud.google.com/go/storage"
+)
+
+// StorageClasses are the storage classes that copies of objects can be written to.
+var StorageClasses = []string{
+	"STANDARD",
+	"NEARLINE",
+	"COLDLINE",
+	"ARCHIVE",
+	"MULTI_REGIONAL",
+	"REGIONAL",
+	"DURABLE_REDUCED_AVAILABILITY",
+}
+
+// NormalizeStorageClass converts a storage class given by the user to the name used by GCP Storage.
+// Returns false if the storage class is not one of StorageClasses.
+func NormalizeStorageClass(storageClass string) (string, bool) {
+	sc := strings.ToUpper(strings.TrimSpace(storageClass))
+	for _, c := range StorageClasses {
+		if c == sc {
+			return sc, true
+		}
+	}
+	return "", false
+}
+
+// CopyAttrs returns the attributes to give to a copy of a generation with the given attributes.
+// Copies get the bucket defaults for any attribute not set, so the ACL, content headers, user metadata,
+// custom time and storage class of the generation are set explicitly. The storage class is replaced
+// by storageClass, if given.
+func CopyAttrs(src *storage.ObjectAttrs, storageClass string) storage.ObjectAttrs {
+	attrs := storage.ObjectAttrs{
+		ContentType:        src.ContentType,
+		ContentLanguage:    src.ContentLanguage,
+		ContentEncoding:    src.ContentEncoding,
+		ContentDisposition: src.ContentDisposition,
+		CacheControl:       src.CacheControl,
+		Metadata:           src.Metadata,
+		CustomTime:         src.CustomTime,
+		StorageClass:       src.StorageClass,
+	}
+	if storageClass != "" {
+		attrs.StorageClass = storageClass
+	}
+
+	// buckets with uniform bucket-level access return no ACL and reject copies that set one
+	for _, rule := range src.ACL {
+		attrs.ACL = append(attrs.ACL, storage.ACLRule{Entity: rule.Entity, Role: rule.Role})
+	}
+
+	return attrs
+}
+
+// KMSKeyName returns the name of the Cloud KMS key of an object, given the name of the key version
+// the object is encrypted with, as returned in the object attributes. Copies can only be given a key,
+// and are encrypted with its primary version.
+func KMSKeyName(keyVersionName string) string {
+	if i := strings.Index(keyVersionName, "/cryptoKeyVersions/"); i >= 0 {
+		return keyVersionName[:i]
+	}
+	return keyVersionName
+}
@@ -0,0 +1,111 @@
+// Copyright 2021 VILT Group
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gcprestore
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"cloud.google.com/go/storage"
+)
+
+func TestCopyAttrs(t *testing.T) {
+	customTime := time.Date(2021, 2, 21, 0, 0, 0, 0, time.UTC)
+	src := &storage.ObjectAttrs{
+		Name:               "dir/file.txt",
+		ContentType:        "text/plain",
+		ContentEncoding:    "gzip",
+		ContentDisposition: "attachment",
+		CacheControl:       "no-cache",
+		Metadata:           map[string]string{"owner": "team"},
+		CustomTime:         customTime,
+		StorageClass:       "NEARLINE",
+		ACL:                []storage.ACLRule{{Entity: storage.AllUsers, Role: storage.RoleReader, Email: "ignored"}},
+		Generation:         3,
+		Size:               10,
+	}
+
+	var tests = []struct {
+		storageClass string
+		expected     storage.ObjectAttrs
+	}{
+		{"", storage.ObjectAttrs{
+			ContentType:        "text/plain",
+			ContentEncoding:    "gzip",
+			ContentDisposition: "attachment",
+			CacheControl:       "no-cache",
+			Metadata:           map[string]string{"owner": "team"},
+			CustomTime:         customTime,
+			StorageClass:       "NEARLINE",
+			ACL:                []storage.ACLRule{{Entity: storage.AllUsers, Role: storage.RoleReader}},
+		}},
+		{"COLDLINE", storage.ObjectAttrs{
+			ContentType:        "text/plain",
+			ContentEncoding:    "gzip",
+			ContentDisposition: "attachment",
+			CacheControl:       "no-cache",
+			Metadata:           map[string]string{"owner": "team"},
+			CustomTime:         customTime,
+			StorageClass:       "COLDLINE",
+			ACL:                []storage.ACLRule{{Entity: storage.AllUsers, Role: storage.RoleReader}},
+		}},
+	}
+
+	for i, test := range tests {
+		res := CopyAttrs(src, test.storageClass)
+		if !reflect.DeepEqual(res, test.expected) {
+			t.Fatalf("test %d: expected %+v | got: %+v", i, test.expected, res)
+		}
+	}
+}
+
+func TestNormalizeStorageClass(t *testing.T) {
+	var tests = []struct {
+		storageClass string
+		expected     string
+		ok           bool
+	}{
+		{"coldline", "COLDLINE", true},
+		{" ARCHIVE ", "ARCHIVE", true},
+		{"GLACIER", "", false},
+	}
+
+	for i, test := range tests {
+		res, ok := NormalizeStorageClass(test.storageClass)
+		if res != test.expected || ok != test.ok {
+			t.Fatalf("test %d: expected %q (%v) | got: %q (%v)", i, test.expected, test.ok, res, ok)
+		}
+	}
+}
+
+func TestKMSKeyName(t *testing.T) {
+	key := "projects/p/locations/europe-west1/keyRings/ring/cryptoKeys/key"
+
+	var tests = []struct {
+		name     string
+		expected string
+	}{
+		{key + "/cryptoKeyVersions/3", key},
+		{key, key},
+		{"", ""},
+	}
+
+	for i, test := range tests {
+		if res := KMSKeyName(test.name); res != test.expected {
+			t.Fatalf("test %d: expected %q | got: %q", i, test.expected, res)
+		}
+	}
+}
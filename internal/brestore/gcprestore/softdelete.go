@@ -0,0 +1,146 @@
+// Copyright 2021 VILT Group
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gcprestore
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// jsonAPIBucketURL is the url of a bucket resource in the storage JSON API.
+const jsonAPIBucketURL = "https://storage.googleapis.com/storage/v1/b/%s"
+
+// SoftDeletePolicy represents the soft delete policy of a bucket, which keeps deleted objects and
+// replaced generations restorable for a period of time.
+type SoftDeletePolicy struct {
+	// How long deleted objects are kept. Zero when soft delete is disabled
+	RetentionDurationSeconds int64 `json:"retentionDurationSeconds,string"`
+	// When the policy started applying to the bucket
+	EffectiveTime time.Time `json:"effectiveTime,omitempty"`
+}
+
+// RetentionDuration returns how long deleted objects are kept.
+func (p SoftDeletePolicy) RetentionDuration() time.Duration {
+	return time.Duration(p.RetentionDurationSeconds) * time.Second
+}
+
+// Limits of the retention duration of a soft delete policy. A duration of 0 disables soft delete.
+const (
+	MinSoftDeleteRetention = 7 * 24 * time.Hour
+	MaxSoftDeleteRetention = 90 * 24 * time.Hour
+)
+
+// CheckSoftDeleteRetention checks if a duration is a valid retention duration for a soft delete policy.
+func CheckSoftDeleteRetention(d time.Duration) error {
+	if d == 0 {
+		return nil
+	}
+	if d%time.Second != 0 {
+		return fmt.Errorf("soft delete retention must be a whole number of seconds, got %v", d)
+	}
+	if d < MinSoftDeleteRetention || d > MaxSoftDeleteRetention {
+		return fmt.Errorf("soft delete retention must be 0 or between 7 and 90 days, got %v", d)
+	}
+	return nil
+}
+
+// bucketSoftDelete represents the soft delete field of a bucket resource.
+type bucketSoftDelete struct {
+	SoftDeletePolicy *SoftDeletePolicy `json:"softDeletePolicy,omitempty"`
+}
+
+// SoftDeletePolicyOf gets the soft delete policy of a bucket. The storage client doesn't support soft delete,
+// so the policy is read with the JSON API.
+func SoftDeletePolicyOf(ctx context.Context, client *http.Client, bucketName string) (SoftDeletePolicy, error) {
+	reqURL := fmt.Sprintf(jsonAPIBucketURL, url.PathEscape(bucketName)) + "?fields=softDeletePolicy"
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, reqURL, nil)
+	if err != nil {
+		return SoftDeletePolicy{}, err
+	}
+
+	var res bucketSoftDelete
+	if err := doJSONRequest(client, req, &res); err != nil {
+		return SoftDeletePolicy{}, fmt.Errorf("getting soft delete policy of bucket '%s': %w", bucketName, err)
+	}
+
+	if res.SoftDeletePolicy == nil {
+		return SoftDeletePolicy{}, nil
+	}
+	return *res.SoftDeletePolicy, nil
+}
+
+// SetSoftDeletePolicy sets the retention duration of the soft delete policy of a bucket, if the
+// bucket wasn't changed since its given metageneration. A duration of 0 disables soft delete.
+func SetSoftDeletePolicy(ctx context.Context, client *http.Client, bucketName string, retention time.Duration,
+	metageneration int64) (SoftDeletePolicy, error) {
+
+	if err := CheckSoftDeleteRetention(retention); err != nil {
+		return SoftDeletePolicy{}, err
+	}
+
+	body, err := json.Marshal(map[string]interface{}{
+		"softDeletePolicy": map[string]string{
+			"retentionDurationSeconds": strconv.FormatInt(int64(retention/time.Second), 10),
+		},
+	})
+	if err != nil {
+		return SoftDeletePolicy{}, err
+	}
+
+	reqURL := fmt.Sprintf(jsonAPIBucketURL, url.PathEscape(bucketName)) +
+		fmt.Sprintf("?fields=softDeletePolicy&ifMetagenerationMatch=%d", metageneration)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPatch, reqURL, bytes.NewReader(body))
+	if err != nil {
+		return SoftDeletePolicy{}, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	var res bucketSoftDelete
+	if err := doJSONRequest(client, req, &res); err != nil {
+		return SoftDeletePolicy{}, fmt.Errorf("setting soft delete policy of bucket '%s': %w", bucketName, err)
+	}
+
+	if res.SoftDeletePolicy == nil {
+		return SoftDeletePolicy{}, nil
+	}
+	return *res.SoftDeletePolicy, nil
+}
+
+// doJSONRequest sends a request to the JSON API and decodes the response into v.
+func doJSONRequest(client *http.Client, req *http.Request, v interface{}) error {
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s: %s", resp.Status, body)
+	}
+
+	return json.Unmarshal(body, v)
+}
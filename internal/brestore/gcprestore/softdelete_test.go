@@ -0,0 +1,69 @@
+// Copyright 2021 VILT Group
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gcprestore
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestSoftDeletePolicyJSON(t *testing.T) {
+	var tests = []struct {
+		body     string
+		expected time.Duration
+	}{
+		{`{}`, 0},
+		{`{"softDeletePolicy": {"retentionDurationSeconds": "0"}}`, 0},
+		{`{"softDeletePolicy": {"retentionDurationSeconds": "604800", "effectiveTime": "2024-03-01T00:00:00Z"}}`,
+			7 * 24 * time.Hour},
+	}
+
+	for _, test := range tests {
+		var res bucketSoftDelete
+		if err := json.Unmarshal([]byte(test.body), &res); err != nil {
+			t.Fatalf("unmarshal %s: %v", test.body, err)
+		}
+
+		var got time.Duration
+		if res.SoftDeletePolicy != nil {
+			got = res.SoftDeletePolicy.RetentionDuration()
+		}
+		if got != test.expected {
+			t.Fatalf("%s: expected %v | got: %v", test.body, test.expected, got)
+		}
+	}
+}
+
+func TestCheckSoftDeleteRetention(t *testing.T) {
+	var tests = []struct {
+		retention time.Duration
+		valid     bool
+	}{
+		{0, true},
+		{7 * 24 * time.Hour, true},
+		{90 * 24 * time.Hour, true},
+		{24 * time.Hour, false},
+		{91 * 24 * time.Hour, false},
+		{7*24*time.Hour + time.Millisecond, false},
+	}
+
+	for _, test := range tests {
+		err := CheckSoftDeleteRetention(test.retention)
+		if (err == nil) != test.valid {
+			t.Fatalf("CheckSoftDeleteRetention(%v): expected valid: %v | got: %v", test.retention, test.valid, err)
+		}
+	}
+}
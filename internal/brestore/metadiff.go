@@ -0,0 +1,49 @@
+// Copyright 2021 VILT Group
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package brestore
+
+import "sort"
+
+// MetadataChange represents the difference of a metadata field between two versions of an object.
+// An empty Old or New value means the field was not set in that version.
+type MetadataChange struct {
+	Field string
+	Old   string
+	New   string
+}
+
+// DiffMetadata returns the fields with different values in two sets of metadata,
+// sorted by ascending order of the field name.
+func DiffMetadata(old map[string]string, new map[string]string) []MetadataChange {
+	var res []MetadataChange
+
+	for field, oldValue := range old {
+		if newValue := new[field]; newValue != oldValue {
+			res = append(res, MetadataChange{Field: field, Old: oldValue, New: newValue})
+		}
+	}
+
+	for field, newValue := range new {
+		if _, ok := old[field]; !ok && newValue != "" {
+			res = append(res, MetadataChange{Field: field, New: newValue})
+		}
+	}
+
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].Field < res[j].Field
+	})
+
+	return res
+}
@@ -0,0 +1,50 @@
+// Copyright 2021 VILT Group
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package brestore
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDiffMetadata(t *testing.T) {
+	var tests = []struct {
+		old      map[string]string
+		new      map[string]string
+		expected []MetadataChange
+	}{
+		{nil, nil, nil},
+		{nil, map[string]string{}, nil},
+		{map[string]string{"a": "1"}, map[string]string{"a": "1"}, nil},
+		// added
+		{nil, map[string]string{"a": "1"}, []MetadataChange{{Field: "a", New: "1"}}},
+		// removed
+		{map[string]string{"a": "1"}, map[string]string{}, []MetadataChange{{Field: "a", Old: "1"}}},
+		// changed
+		{map[string]string{"a": "1"}, map[string]string{"a": "2"}, []MetadataChange{{Field: "a", Old: "1", New: "2"}}},
+		// an empty value is the same as a field that is not set
+		{map[string]string{}, map[string]string{"a": ""}, nil},
+		// sorted by field name
+		{map[string]string{"c": "1", "b": "1", "x": "1"}, map[string]string{"a": "1", "c": "2", "x": "1"},
+			[]MetadataChange{{Field: "a", New: "1"}, {Field: "b", Old: "1"}, {Field: "c", Old: "1", New: "2"}}},
+	}
+
+	for i, test := range tests {
+		res := DiffMetadata(test.old, test.new)
+		if !reflect.DeepEqual(res, test.expected) {
+			t.Fatalf("test %d: expected %v | got: %v", i, test.expected, res)
+		}
+	}
+}
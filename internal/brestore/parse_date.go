@@ -16,6 +16,7 @@ package brestore
 
 import (
 	"fmt"
+	"strconv"
 	"time"
 )
 
@@ -59,3 +60,25 @@ func ParseTimestamp(timestamp string) (time.Time, error) {
 
 	return ts, fmt.Errorf("could not parse timestamp '%v' into any of the supported formats", timestamp)
 }
+
+// ParseDuration parses a duration like time.ParseDuration, but also accepts a whole number of days
+// or weeks, e.g. "30d" or "2w".
+func ParseDuration(s string) (time.Duration, error) {
+	units := map[byte]time.Duration{'d': 24 * time.Hour, 'w': 7 * 24 * time.Hour}
+
+	if s != "" {
+		if unit, ok := units[s[len(s)-1]]; ok {
+			n, err := strconv.Atoi(s[:len(s)-1])
+			if err != nil || n < 0 {
+				return 0, fmt.Errorf("could not parse duration '%s'", s)
+			}
+			return time.Duration(n) * unit, nil
+		}
+	}
+
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return 0, fmt.Errorf("could not parse duration '%s'", s)
+	}
+	return d, nil
+}
@@ -0,0 +1,45 @@
+// Copyright 2021 VILT Group
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package brestore
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseDuration(t *testing.T) {
+	var tests = []struct {
+		s        string
+		expected time.Duration
+	}{
+		{"30d", 30 * 24 * time.Hour},
+		{"2w", 14 * 24 * time.Hour},
+		{"12h", 12 * time.Hour},
+		{"1h30m", 90 * time.Minute},
+	}
+
+	for _, test := range tests {
+		res, err := ParseDuration(test.s)
+		if err != nil || res != test.expected {
+			t.Fatalf("ParseDuration(%q): expected %v | got: %v (%v)", test.s, test.expected, res, err)
+		}
+	}
+
+	for _, s := range []string{"", "d", "1.5d", "-1w", "abc"} {
+		if _, err := ParseDuration(s); err == nil {
+			t.Fatalf("ParseDuration(%q): expected error", s)
+		}
+	}
+}
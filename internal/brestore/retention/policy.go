@@ -0,0 +1,98 @@
+// Copyright 2021 VILT Group
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package retention
+
+import (
+	"fmt"
+	"time"
+)
+
+// Policy represents the rules that decide which versions of an object are kept, in the style of
+// restic's 'forget' command. A version is kept if any rule keeps it.
+type Policy struct {
+	// Keep the N newest versions
+	Last int
+	// Keep all versions created in this duration before the reference time
+	Within time.Duration
+	// Keep the newest version of each of the N newest hours, days, weeks, months and years that have versions
+	Hourly  int
+	Daily   int
+	Weekly  int
+	Monthly int
+	Yearly  int
+}
+
+// Empty checks if the policy has no rules, in which case it would keep nothing.
+func (p Policy) Empty() bool {
+	return p == Policy{}
+}
+
+// Decision represents whether a version is kept and the rules that keep it.
+type Decision struct {
+	Keep    bool
+	Reasons []string
+}
+
+// periodRule keeps the newest version of each of the count newest periods identified by key.
+type periodRule struct {
+	name  string
+	count int
+	key   func(t time.Time) string
+}
+
+// Apply decides which versions to keep, given their creation times sorted from the newest to the oldest.
+// The Within rule is relative to now. The decisions are returned in the same order as the times.
+func (p Policy) Apply(times []time.Time, now time.Time) []Decision {
+	res := make([]Decision, len(times))
+
+	rules := []*periodRule{
+		{name: "hourly", count: p.Hourly, key: func(t time.Time) string { return t.Format("2006-01-02 15") }},
+		{name: "daily", count: p.Daily, key: func(t time.Time) string { return t.Format("2006-01-02") }},
+		{name: "weekly", count: p.Weekly, key: func(t time.Time) string {
+			year, week := t.ISOWeek()
+			return fmt.Sprintf("%d-%02d", year, week)
+		}},
+		{name: "monthly", count: p.Monthly, key: func(t time.Time) string { return t.Format("2006-01") }},
+		{name: "yearly", count: p.Yearly, key: func(t time.Time) string { return t.Format("2006") }},
+	}
+	lastKeys := make([]string, len(rules))
+
+	for i, t := range times {
+		d := &res[i]
+
+		if i < p.Last {
+			d.Reasons = append(d.Reasons, "last")
+		}
+
+		if p.Within > 0 && !t.Before(now.Add(-p.Within)) {
+			d.Reasons = append(d.Reasons, "within")
+		}
+
+		for r, rule := range rules {
+			if rule.count == 0 {
+				continue
+			}
+			if key := rule.key(t); key != lastKeys[r] {
+				lastKeys[r] = key
+				rule.count--
+				d.Reasons = append(d.Reasons, rule.name)
+			}
+		}
+
+		d.Keep = len(d.Reasons) > 0
+	}
+
+	return res
+}
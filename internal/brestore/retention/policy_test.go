@@ -0,0 +1,72 @@
+// Copyright 2021 VILT Group
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package retention
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+var now = time.Date(2021, 2, 21, 12, 0, 0, 0, time.UTC)
+
+func ago(d time.Duration) time.Time {
+	return now.Add(-d)
+}
+
+func TestApply(t *testing.T) {
+	day := 24 * time.Hour
+
+	// sorted from the newest to the oldest
+	times := []time.Time{
+		ago(time.Hour),
+		ago(2 * time.Hour),
+		ago(day),
+		ago(day + time.Hour),
+		ago(3 * day),
+		ago(40 * day),
+		ago(400 * day),
+	}
+
+	var tests = []struct {
+		policy   Policy
+		expected []bool
+	}{
+		{Policy{Last: 2}, []bool{true, true, false, false, false, false, false}},
+		{Policy{Within: 2 * day}, []bool{true, true, true, true, false, false, false}},
+		{Policy{Daily: 3}, []bool{true, false, true, false, true, false, false}},
+		{Policy{Monthly: 12}, []bool{true, false, false, false, false, true, true}},
+		{Policy{Yearly: 1}, []bool{true, false, false, false, false, false, false}},
+		{Policy{Last: 1, Daily: 2}, []bool{true, false, true, false, false, false, false}},
+		{Policy{}, []bool{false, false, false, false, false, false, false}},
+	}
+
+	for _, test := range tests {
+		var res []bool
+		for _, d := range test.policy.Apply(times, now) {
+			res = append(res, d.Keep)
+		}
+		if !reflect.DeepEqual(res, test.expected) {
+			t.Fatalf("policy %+v: expected %v | got: %v", test.policy, test.expected, res)
+		}
+	}
+}
+
+func TestApplyReasons(t *testing.T) {
+	decisions := Policy{Last: 1, Daily: 1}.Apply([]time.Time{ago(time.Hour)}, now)
+	if !reflect.DeepEqual(decisions[0].Reasons, []string{"last", "daily"}) {
+		t.Fatalf("unexpected reasons: %v", decisions[0].Reasons)
+	}
+}
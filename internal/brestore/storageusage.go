@@ -0,0 +1,131 @@
+// Copyright 2021 VILT Group
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package brestore
+
+import (
+	"sort"
+	"time"
+)
+
+// StorageGroup holds the storage used by the versions/generations of the objects that share the same path prefix.
+type StorageGroup struct {
+	// Path prefix shared by the objects in this group
+	Prefix string `json:"prefix"`
+	// Number and total size of the current versions
+	CurrentCount int64 `json:"current_count"`
+	CurrentBytes int64 `json:"current_bytes"`
+	// Number and total size of the noncurrent versions
+	NoncurrentCount int64 `json:"noncurrent_count"`
+	NoncurrentBytes int64 `json:"noncurrent_bytes"`
+	// Number of delete markers
+	DeleteMarkers int64 `json:"delete_markers"`
+	// Time of the oldest version or delete marker, the earliest point in time objects can be restored to
+	OldestRestorable time.Time `json:"oldest_restorable"`
+	// Time of the newest version or delete marker
+	NewestRestorable time.Time `json:"newest_restorable"`
+}
+
+// TotalBytes returns the size of all the versions of the group.
+func (g StorageGroup) TotalBytes() int64 {
+	return g.CurrentBytes + g.NoncurrentBytes
+}
+
+func (g *StorageGroup) addTime(t time.Time) {
+	if g.OldestRestorable.IsZero() || t.Before(g.OldestRestorable) {
+		g.OldestRestorable = t
+	}
+	if t.After(g.NewestRestorable) {
+		g.NewestRestorable = t
+	}
+}
+
+func (g *StorageGroup) add(other StorageGroup) {
+	g.CurrentCount += other.CurrentCount
+	g.CurrentBytes += other.CurrentBytes
+	g.NoncurrentCount += other.NoncurrentCount
+	g.NoncurrentBytes += other.NoncurrentBytes
+	g.DeleteMarkers += other.DeleteMarkers
+	if !other.OldestRestorable.IsZero() {
+		g.addTime(other.OldestRestorable)
+		g.addTime(other.NewestRestorable)
+	}
+}
+
+// StorageUsage groups the storage used by versions/generations by the first segments of the path of the objects.
+type StorageUsage struct {
+	depth  int
+	groups map[string]*StorageGroup
+}
+
+// NewStorageUsage creates an empty StorageUsage that groups objects by the first depth segments of their path.
+func NewStorageUsage(depth int) *StorageUsage {
+	return &StorageUsage{depth: depth, groups: make(map[string]*StorageGroup)}
+}
+
+func (u *StorageUsage) group(key string) *StorageGroup {
+	prefix := PathPrefix(key, u.depth)
+	g, ok := u.groups[prefix]
+	if !ok {
+		g = &StorageGroup{Prefix: prefix}
+		u.groups[prefix] = g
+	}
+	return g
+}
+
+// AddCurrent registers the current version of an object, created at t with size bytes.
+func (u *StorageUsage) AddCurrent(key string, size int64, t time.Time) {
+	g := u.group(key)
+	g.CurrentCount++
+	g.CurrentBytes += size
+	g.addTime(t)
+}
+
+// AddNoncurrent registers a noncurrent version of an object, created at t with size bytes.
+func (u *StorageUsage) AddNoncurrent(key string, size int64, t time.Time) {
+	g := u.group(key)
+	g.NoncurrentCount++
+	g.NoncurrentBytes += size
+	g.addTime(t)
+}
+
+// AddDeleteMarker registers a delete marker created at t.
+func (u *StorageUsage) AddDeleteMarker(key string, t time.Time) {
+	g := u.group(key)
+	g.DeleteMarkers++
+	g.addTime(t)
+}
+
+// Groups returns the groups sorted by ascending order of their prefix.
+func (u *StorageUsage) Groups() []StorageGroup {
+	res := make([]StorageGroup, 0, len(u.groups))
+	for _, g := range u.groups {
+		res = append(res, *g)
+	}
+
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].Prefix < res[j].Prefix
+	})
+
+	return res
+}
+
+// Total returns the sum of all groups.
+func (u *StorageUsage) Total() StorageGroup {
+	var res StorageGroup
+	for _, g := range u.groups {
+		res.add(*g)
+	}
+	return res
+}
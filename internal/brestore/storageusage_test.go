@@ -0,0 +1,52 @@
+// Copyright 2021 VILT Group
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package brestore
+
+import (
+	"testing"
+	"time"
+)
+
+func TestStorageUsage(t *testing.T) {
+	day := time.Date(2021, 2, 21, 0, 0, 0, 0, time.UTC)
+
+	usage := NewStorageUsage(1)
+	usage.AddCurrent("a/1", 10, day.Add(3*time.Hour))
+	usage.AddNoncurrent("a/1", 30, day.Add(time.Hour))
+	usage.AddDeleteMarker("a/2", day.Add(5*time.Hour))
+	usage.AddNoncurrent("b/3", 7, day)
+
+	expected := []StorageGroup{
+		{Prefix: "a/", CurrentCount: 1, CurrentBytes: 10, NoncurrentCount: 1, NoncurrentBytes: 30, DeleteMarkers: 1,
+			OldestRestorable: day.Add(time.Hour), NewestRestorable: day.Add(5 * time.Hour)},
+		{Prefix: "b/", NoncurrentCount: 1, NoncurrentBytes: 7, OldestRestorable: day, NewestRestorable: day},
+	}
+
+	groups := usage.Groups()
+	if len(groups) != len(expected) {
+		t.Fatalf("unexpected number of groups: expected %d | got: %d", len(expected), len(groups))
+	}
+	for i, g := range groups {
+		if g != expected[i] {
+			t.Fatalf("unexpected group: expected %v | got: %v", expected[i], g)
+		}
+	}
+
+	total := usage.Total()
+	if total.TotalBytes() != 47 || total.DeleteMarkers != 1 || !total.OldestRestorable.Equal(day) ||
+		!total.NewestRestorable.Equal(day.Add(5*time.Hour)) {
+		t.Fatalf("unexpected total: %v", total)
+	}
+}
@@ -0,0 +1,136 @@
+// Copyright 2021 VILT Group
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package brestore
+
+import (
+	"sort"
+	"strings"
+)
+
+// PathPrefix returns the directory formed by the first depth segments of an object path,
+// including the trailing separator. Objects with fewer directory segments than depth are
+// grouped under their own directory. The root of the bucket is represented by the empty string.
+func PathPrefix(key string, depth int) string {
+	if depth <= 0 {
+		return ""
+	}
+
+	segments := strings.Split(key, "/")
+	// the last segment is the name of the object, not a directory
+	dirs := segments[:len(segments)-1]
+	if len(dirs) > depth {
+		dirs = dirs[:depth]
+	}
+
+	if len(dirs) == 0 {
+		return ""
+	}
+
+	return strings.Join(dirs, "/") + "/"
+}
+
+// PlanGroup holds the counters of the objects in a rollback plan that share the same path prefix.
+type PlanGroup struct {
+	// Path prefix shared by the objects in this group
+	Prefix string
+	// Number of objects that will be created
+	ToCreate int64
+	// Number of objects that will be deleted
+	ToDelete int64
+	// Number of objects that don't need any action
+	NoAction int64
+	// Number of objects left as they are because their history at the point in time expired
+	Unrecoverable int64
+	// Number of objects that can't be changed because of a hold, retention policy or object lock
+	Blocked int64
+	// Total bytes that will be copied to create objects
+	BytesToCopy int64
+}
+
+// PlanSummary groups the actions of a rollback plan by the first segments of the path of the objects.
+type PlanSummary struct {
+	depth  int
+	groups map[string]*PlanGroup
+}
+
+// NewPlanSummary creates an empty PlanSummary that groups objects by the first depth segments of their path.
+func NewPlanSummary(depth int) *PlanSummary {
+	return &PlanSummary{depth: depth, groups: make(map[string]*PlanGroup)}
+}
+
+func (s *PlanSummary) group(key string) *PlanGroup {
+	prefix := PathPrefix(key, s.depth)
+	g, ok := s.groups[prefix]
+	if !ok {
+		g = &PlanGroup{Prefix: prefix}
+		s.groups[prefix] = g
+	}
+	return g
+}
+
+// AddCreate registers an object that will be created by copying size bytes.
+func (s *PlanSummary) AddCreate(key string, size int64) {
+	g := s.group(key)
+	g.ToCreate++
+	g.BytesToCopy += size
+}
+
+// AddDelete registers an object that will be deleted.
+func (s *PlanSummary) AddDelete(key string) {
+	s.group(key).ToDelete++
+}
+
+// AddNoAction registers an object that doesn't need any action.
+func (s *PlanSummary) AddNoAction(key string) {
+	s.group(key).NoAction++
+}
+
+// AddUnrecoverable registers an object whose history at the point in time expired.
+func (s *PlanSummary) AddUnrecoverable(key string) {
+	s.group(key).Unrecoverable++
+}
+
+// AddBlocked registers an object that can't be changed because of a hold, retention policy or object lock.
+func (s *PlanSummary) AddBlocked(key string) {
+	s.group(key).Blocked++
+}
+
+// Groups returns the groups of the summary sorted by ascending order of their prefix.
+func (s *PlanSummary) Groups() []PlanGroup {
+	res := make([]PlanGroup, 0, len(s.groups))
+	for _, g := range s.groups {
+		res = append(res, *g)
+	}
+
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].Prefix < res[j].Prefix
+	})
+
+	return res
+}
+
+// Total returns the sum of the counters of all groups.
+func (s *PlanSummary) Total() PlanGroup {
+	var res PlanGroup
+	for _, g := range s.groups {
+		res.ToCreate += g.ToCreate
+		res.ToDelete += g.ToDelete
+		res.NoAction += g.NoAction
+		res.Unrecoverable += g.Unrecoverable
+		res.Blocked += g.Blocked
+		res.BytesToCopy += g.BytesToCopy
+	}
+	return res
+}
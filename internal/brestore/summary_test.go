@@ -0,0 +1,79 @@
+// Copyright 2021 VILT Group
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package brestore
+
+import (
+	"testing"
+)
+
+type PathPrefixTestCase struct {
+	Key      string
+	Depth    int
+	Expected string
+}
+
+func TestPathPrefix(t *testing.T) {
+
+	tests := []PathPrefixTestCase{
+		{Key: "file.txt", Depth: 1, Expected: ""},
+		{Key: "a/file.txt", Depth: 0, Expected: ""},
+		{Key: "a/file.txt", Depth: 1, Expected: "a/"},
+		{Key: "a/b/c/file.txt", Depth: 2, Expected: "a/b/"},
+		{Key: "a/b/file.txt", Depth: 5, Expected: "a/b/"},
+		{Key: "a/b/", Depth: 3, Expected: "a/b/"},
+	}
+
+	for _, test := range tests {
+		prefix := PathPrefix(test.Key, test.Depth)
+		if prefix != test.Expected {
+			t.Fatalf("unexpected prefix for '%v' at depth %d: expected '%v' | got: '%v'",
+				test.Key, test.Depth, test.Expected, prefix)
+		}
+	}
+}
+
+func TestPlanSummary(t *testing.T) {
+	summary := NewPlanSummary(1)
+	summary.AddCreate("a/x/1", 10)
+	summary.AddCreate("a/y/2", 5)
+	summary.AddDelete("a/3")
+	summary.AddNoAction("b/4")
+	summary.AddDelete("5")
+	summary.AddUnrecoverable("b/6")
+	summary.AddBlocked("a/7")
+
+	groups := summary.Groups()
+	if len(groups) != 3 {
+		t.Fatalf("unexpected number of groups: expected 3 | got: %d", len(groups))
+	}
+
+	expected := []PlanGroup{
+		{Prefix: "", ToDelete: 1},
+		{Prefix: "a/", ToCreate: 2, ToDelete: 1, Blocked: 1, BytesToCopy: 15},
+		{Prefix: "b/", NoAction: 1, Unrecoverable: 1},
+	}
+
+	for i, g := range groups {
+		if g != expected[i] {
+			t.Fatalf("unexpected group: expected %v | got: %v", expected[i], g)
+		}
+	}
+
+	total := summary.Total()
+	if total.ToCreate != 2 || total.ToDelete != 2 || total.NoAction != 1 || total.BytesToCopy != 15 ||
+		total.Unrecoverable != 1 || total.Blocked != 1 {
+		t.Fatalf("unexpected total: %v", total)
+	}
+}
@@ -0,0 +1,261 @@
+// Copyright 2021 VILT Group
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package textdiff
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// binarySniffLen is the number of bytes inspected to look for NUL bytes, like git does.
+const binarySniffLen = 8000
+
+// Enumeration of EditKinds.
+const (
+	// Represents a line present in both texts
+	EQUAL EditKind = iota
+	// Represents a line only present in the second text
+	INSERT
+	// Represents a line only present in the first text
+	DELETE
+)
+
+// EditKind represents how a line changes from the first to the second text.
+type EditKind int
+
+// Edit represents a line of the edit script that turns the first text into the second.
+type Edit struct {
+	Kind EditKind
+	// The line, including its terminating newline, if any
+	Line string
+}
+
+// IsBinary checks if data looks like binary content: it has NUL bytes near its start or it's not valid UTF-8.
+func IsBinary(data []byte) bool {
+	sniff := data
+	if len(sniff) > binarySniffLen {
+		sniff = sniff[:binarySniffLen]
+	}
+	return bytes.IndexByte(sniff, 0) >= 0 || !utf8.Valid(data)
+}
+
+// Lines splits text into lines, keeping the terminating newline of each line.
+// The last line has no newline if the text doesn't end with one.
+func Lines(text string) []string {
+	var res []string
+	for text != "" {
+		i := strings.IndexByte(text, '\n')
+		if i < 0 {
+			res = append(res, text)
+			break
+		}
+		res = append(res, text[:i+1])
+		text = text[i+1:]
+	}
+	return res
+}
+
+// Diff returns the shortest edit script that turns the lines a into the lines b,
+// using the algorithm described in "An O(ND) Difference Algorithm and Its Variations" by Eugene W. Myers,
+// with its linear space refinement: the script is split at the middle snake of the shortest path, and each
+// half is diffed recursively, so memory doesn't grow with the number of edits.
+func Diff(a, b []string) []Edit {
+	return diffLines(a, b, nil)
+}
+
+// diffLines appends the shortest edit script that turns the lines a into the lines b to res.
+func diffLines(a, b []string, res []Edit) []Edit {
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	for _, line := range a[:prefix] {
+		res = append(res, Edit{Kind: EQUAL, Line: line})
+	}
+	a, b = a[prefix:], b[prefix:]
+
+	suffix := 0
+	for suffix < len(a) && suffix < len(b) && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+	common := a[len(a)-suffix:]
+	a, b = a[:len(a)-suffix], b[:len(b)-suffix]
+
+	switch {
+	case len(a) == 0:
+		for _, line := range b {
+			res = append(res, Edit{Kind: INSERT, Line: line})
+		}
+	case len(b) == 0:
+		for _, line := range a {
+			res = append(res, Edit{Kind: DELETE, Line: line})
+		}
+	default:
+		// without a common prefix or suffix, at least two edits are needed, so each half has fewer edits
+		x, y, u, v := middleSnake(a, b)
+		res = diffLines(a[:x], b[:y], res)
+		for _, line := range a[x:u] {
+			res = append(res, Edit{Kind: EQUAL, Line: line})
+		}
+		res = diffLines(a[u:], b[v:], res)
+	}
+
+	for _, line := range common {
+		res = append(res, Edit{Kind: EQUAL, Line: line})
+	}
+	return res
+}
+
+// middleSnake finds the snake in the middle of a shortest edit script that turns a into b, by searching from
+// both ends at the same time until the paths overlap. Returns the start (x, y) and end (u, v) of the snake.
+func middleSnake(a, b []string) (x, y, u, v int) {
+	n, m := len(a), len(b)
+	delta := n - m
+	odd := delta%2 != 0
+	maxD := (n + m + 1) / 2
+	offset := maxD + 1
+
+	// furthest x reached on each diagonal by the forward search, and by the backward search counting from the
+	// ends of both texts
+	forward := make([]int, 2*offset+1)
+	backward := make([]int, 2*offset+1)
+
+	for d := 0; d <= maxD; d++ {
+		for k := -d; k <= d; k += 2 {
+			if k == -d || (k != d && forward[offset+k-1] < forward[offset+k+1]) {
+				x = forward[offset+k+1]
+			} else {
+				x = forward[offset+k-1] + 1
+			}
+			y = x - k
+			u, v = x, y
+			for u < n && v < m && a[u] == b[v] {
+				u++
+				v++
+			}
+			forward[offset+k] = u
+
+			// the backward diagonal delta-k was reached with d-1 edits
+			if odd && k >= delta-(d-1) && k <= delta+(d-1) && u+backward[offset+delta-k] >= n {
+				return x, y, u, v
+			}
+		}
+
+		for k := -d; k <= d; k += 2 {
+			var bx int
+			if k == -d || (k != d && backward[offset+k-1] < backward[offset+k+1]) {
+				bx = backward[offset+k+1]
+			} else {
+				bx = backward[offset+k-1] + 1
+			}
+			by := bx - k
+			ex, ey := bx, by
+			for ex < n && ey < m && a[n-1-ex] == b[m-1-ey] {
+				ex++
+				ey++
+			}
+			backward[offset+k] = ex
+
+			// the forward diagonal delta-k was reached with d edits
+			if !odd && delta-k >= -d && delta-k <= d && ex+forward[offset+delta-k] >= n {
+				return n - ex, m - ey, n - bx, m - by
+			}
+		}
+	}
+
+	// not reached: the searches overlap after at most maxD steps each
+	return 0, 0, 0, 0
+}
+
+// Unified returns the differences between the texts a and b in the unified diff format, showing the given
+// number of unchanged lines around each change. An empty string is returned if both texts are equal.
+func Unified(fromName string, toName string, a string, b string, context int) string {
+	edits := Diff(Lines(a), Lines(b))
+
+	// line of each text at the start of each edit
+	aLines, bLines := make([]int, len(edits)+1), make([]int, len(edits)+1)
+	var changes []int
+	for i, e := range edits {
+		aLines[i+1], bLines[i+1] = aLines[i], bLines[i]
+		if e.Kind != INSERT {
+			aLines[i+1]++
+		}
+		if e.Kind != DELETE {
+			bLines[i+1]++
+		}
+		if e.Kind != EQUAL {
+			changes = append(changes, i)
+		}
+	}
+
+	if len(changes) == 0 {
+		return ""
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "--- %s\n+++ %s\n", fromName, toName)
+
+	for i := 0; i < len(changes); {
+		// extend the hunk while the next change is close enough for their context to overlap
+		j := i
+		for j+1 < len(changes) && changes[j+1]-changes[j] <= 2*context {
+			j++
+		}
+
+		start, end := changes[i]-context, changes[j]+context+1
+		if start < 0 {
+			start = 0
+		}
+		if end > len(edits) {
+			end = len(edits)
+		}
+
+		fmt.Fprintf(&sb, "@@ -%s +%s @@\n",
+			hunkRange(aLines[start], aLines[end]-aLines[start]), hunkRange(bLines[start], bLines[end]-bLines[start]))
+
+		for _, e := range edits[start:end] {
+			switch e.Kind {
+			case EQUAL:
+				sb.WriteString(" ")
+			case INSERT:
+				sb.WriteString("+")
+			case DELETE:
+				sb.WriteString("-")
+			}
+			sb.WriteString(e.Line)
+			if !strings.HasSuffix(e.Line, "\n") {
+				sb.WriteString("\n\\ No newline at end of file\n")
+			}
+		}
+
+		i = j + 1
+	}
+
+	return sb.String()
+}
+
+// hunkRange formats the range of lines of a hunk, given the 0-based line where it starts and its number of lines.
+func hunkRange(start int, count int) string {
+	if count == 0 {
+		// empty ranges refer to the line before the change
+		return fmt.Sprintf("%d,0", start)
+	}
+	if count == 1 {
+		return fmt.Sprintf("%d", start+1)
+	}
+	return fmt.Sprintf("%d,%d", start+1, count)
+}
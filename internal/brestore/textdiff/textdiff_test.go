@@ -0,0 +1,169 @@
+// Copyright 2021 VILT Group
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package textdiff
+
+import (
+	"fmt"
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+func TestDiff(t *testing.T) {
+	var tests = []struct {
+		a, b     string
+		expected string
+	}{
+		{"", "", ""},
+		{"a\n", "a\n", " a"},
+		{"", "a\nb\n", "+a +b"},
+		{"a\nb\n", "", "-a -b"},
+		{"a\nb\nc\n", "a\nc\n", " a -b  c"},
+		{"a\nb\nc\na\nb\nb\na\n", "c\nb\na\nb\na\nc\n", "-a +c  b -c  a  b -b  a +c"},
+	}
+
+	for _, test := range tests {
+		var parts []string
+		for _, e := range Diff(Lines(test.a), Lines(test.b)) {
+			prefix := map[EditKind]string{EQUAL: " ", INSERT: "+", DELETE: "-"}[e.Kind]
+			parts = append(parts, prefix+strings.TrimSuffix(e.Line, "\n"))
+		}
+		if res := strings.Join(parts, " "); res != test.expected {
+			t.Fatalf("Diff(%q, %q): expected %q | got: %q", test.a, test.b, test.expected, res)
+		}
+	}
+}
+
+func TestDiffShortest(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	randomLines := func(n int) []string {
+		var res []string
+		for i := 0; i < n; i++ {
+			res = append(res, string(rune('a'+r.Intn(3)))+"\n")
+		}
+		return res
+	}
+
+	for i := 0; i < 500; i++ {
+		a, b := randomLines(r.Intn(20)), randomLines(r.Intn(20))
+		edits := Diff(a, b)
+
+		from, to, changes := applyEdits(edits)
+		if strings.Join(from, "") != strings.Join(a, "") || strings.Join(to, "") != strings.Join(b, "") {
+			t.Fatalf("test %d: the edits of Diff(%q, %q) don't turn one into the other", i, a, b)
+		}
+		if expected := len(a) + len(b) - 2*lcsLength(a, b); changes != expected {
+			t.Fatalf("test %d: expected %d changes | got: %d", i, expected, changes)
+		}
+	}
+}
+
+func TestDiffUnrelated(t *testing.T) {
+	var a, b []string
+	for i := 0; i < 10000; i++ {
+		a = append(a, fmt.Sprintf("a%d\n", i))
+		b = append(b, fmt.Sprintf("b%d\n", i))
+	}
+
+	_, _, changes := applyEdits(Diff(a, b))
+	if changes != len(a)+len(b) {
+		t.Fatalf("expected %d changes | got: %d", len(a)+len(b), changes)
+	}
+}
+
+// applyEdits returns the lines of both texts of an edit script and its number of inserts and deletes.
+func applyEdits(edits []Edit) (from []string, to []string, changes int) {
+	for _, e := range edits {
+		if e.Kind != INSERT {
+			from = append(from, e.Line)
+		}
+		if e.Kind != DELETE {
+			to = append(to, e.Line)
+		}
+		if e.Kind != EQUAL {
+			changes++
+		}
+	}
+	return from, to, changes
+}
+
+// lcsLength returns the length of the longest common subsequence of a and b.
+func lcsLength(a, b []string) int {
+	prev, cur := make([]int, len(b)+1), make([]int, len(b)+1)
+	for i := range a {
+		for j := range b {
+			if a[i] == b[j] {
+				cur[j+1] = prev[j] + 1
+			} else if prev[j+1] > cur[j] {
+				cur[j+1] = prev[j+1]
+			} else {
+				cur[j+1] = cur[j]
+			}
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(b)]
+}
+
+func TestUnified(t *testing.T) {
+	a := "1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n"
+	b := "1\n2\n3\nfour\n5\n6\n7\n8\n9\n10\n11"
+
+	expected := "" +
+		"--- a\n" +
+		"+++ b\n" +
+		"@@ -2,5 +2,5 @@\n" +
+		" 2\n" +
+		" 3\n" +
+		"-4\n" +
+		"+four\n" +
+		" 5\n" +
+		" 6\n" +
+		"@@ -9,2 +9,3 @@\n" +
+		" 9\n" +
+		" 10\n" +
+		"+11\n" +
+		"\\ No newline at end of file\n"
+
+	if res := Unified("a", "b", a, b, 2); res != expected {
+		t.Fatalf("unexpected unified diff:\n%s", res)
+	}
+
+	if res := Unified("a", "b", a, a, 3); res != "" {
+		t.Fatalf("expected no diff for equal texts, got:\n%s", res)
+	}
+
+	if res := Unified("a", "b", "", "x\n", 3); !strings.Contains(res, "@@ -0,0 +1 @@\n+x\n") {
+		t.Fatalf("unexpected diff from empty text:\n%s", res)
+	}
+}
+
+func TestIsBinary(t *testing.T) {
+	var tests = []struct {
+		data     []byte
+		expected bool
+	}{
+		{[]byte("key = value\n"), false},
+		{[]byte("çãé\n"), false},
+		{[]byte{0x1f, 0x8b, 0x08, 0x00}, true},
+		{[]byte{0xff, 0xfe}, true},
+	}
+
+	for _, test := range tests {
+		if res := IsBinary(test.data); res != test.expected {
+			t.Fatalf("IsBinary(%v): expected %v | got: %v", test.data, test.expected, res)
+		}
+	}
+}
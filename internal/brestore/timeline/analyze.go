@@ -0,0 +1,237 @@
+// Copyright 2021 VILT Group
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package timeline
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Enumeration of FindingKinds.
+const (
+	// Represents many objects deleted in the same change set
+	MASS_DELETE FindingKind = iota
+	// Represents many objects overwritten in the same change set
+	BULK_OVERWRITE
+	// Represents many objects replaced by objects with the same name but another file extension
+	EXTENSION_CHANGE
+	// Represents many objects overwritten by objects of a very different size
+	SIZE_CHANGE
+)
+
+// FindingKind represents a suspicious pattern of changes.
+type FindingKind int
+
+// String converts a FindingKind to a string representation.
+// Implements the Stringer interface.
+func (k FindingKind) String() string {
+	switch k {
+	case MASS_DELETE:
+		return "Mass delete"
+	case BULK_OVERWRITE:
+		return "Bulk overwrite"
+	case EXTENSION_CHANGE:
+		return "Extension change"
+	case SIZE_CHANGE:
+		return "Size change"
+	default:
+		return "Unknown Finding"
+	}
+}
+
+// Thresholds configures when a pattern of changes is reported as suspicious.
+type Thresholds struct {
+	// Minimum number of distinct keys showing a pattern in a change set for it to be reported
+	MinKeys int
+	// Minimum ratio between the largest and the smallest size of an overwritten object
+	// for its size change to be suspicious
+	SizeRatio float64
+	// Number of path segments used to group the affected keys
+	PrefixDepth int
+}
+
+// Finding represents a suspicious pattern of changes found in a change set.
+type Finding struct {
+	// The kind of pattern found
+	Kind FindingKind `json:"-"`
+	// Id of the change set where the pattern was found
+	ChangeSetID string `json:"changeset_id"`
+	// Time of the first and last change of the change set
+	Start time.Time `json:"start"`
+	End   time.Time `json:"end"`
+	// Number of distinct keys showing the pattern
+	Keys int `json:"keys"`
+	// Path prefixes of the keys showing the pattern, sorted by descending number of keys
+	Prefixes []PrefixCount `json:"prefixes"`
+	// Latest point in time before the change set, which is the suggested restore time
+	RestorePoint time.Time `json:"restore_point"`
+	// Human readable description of the finding
+	Detail string `json:"detail"`
+}
+
+// Analyze looks for suspicious patterns of changes in each change set, such as mass deletes,
+// bulk overwrites, objects renamed to another file extension or overwritten by objects of a very different size.
+// Findings are sorted by the start of their change set.
+func Analyze(sets []ChangeSet, th Thresholds) []Finding {
+	var res []Finding
+
+	for _, cs := range sets {
+		deleted, overwritten, resized := distinctKeys(), distinctKeys(), distinctKeys()
+		for _, e := range cs.Events {
+			switch e.Kind {
+			case DELETE:
+				deleted.add(e.Key)
+			case OVERWRITE:
+				overwritten.add(e.Key)
+				if isSizeChange(e.PreviousSize, e.Size, th.SizeRatio) {
+					resized.add(e.Key)
+				}
+			}
+		}
+
+		newFinding := func(kind FindingKind, keys []string, detail string) {
+			if len(keys) < th.MinKeys || len(keys) == 0 {
+				return
+			}
+			res = append(res, Finding{
+				Kind:         kind,
+				ChangeSetID:  cs.ID,
+				Start:        cs.Start,
+				End:          cs.End,
+				Keys:         len(keys),
+				Prefixes:     prefixCounts(keys, th.PrefixDepth),
+				RestorePoint: cs.RestorePoint(),
+				Detail:       detail,
+			})
+		}
+
+		duration := cs.End.Sub(cs.Start).Round(time.Second)
+
+		newFinding(MASS_DELETE, deleted.keys,
+			fmt.Sprintf("%d objects deleted in %v", len(deleted.keys), duration))
+		newFinding(BULK_OVERWRITE, overwritten.keys,
+			fmt.Sprintf("%d objects overwritten in %v", len(overwritten.keys), duration))
+
+		renamed, transitions := extensionChanges(cs.Events, deleted.set)
+		newFinding(EXTENSION_CHANGE, renamed,
+			fmt.Sprintf("%d objects replaced by objects with another file extension, mostly %s",
+				len(renamed), transitions))
+
+		newFinding(SIZE_CHANGE, resized.keys,
+			fmt.Sprintf("%d objects overwritten by objects %v times larger or smaller", len(resized.keys), th.SizeRatio))
+	}
+
+	return res
+}
+
+// keySet is a collection of distinct keys that keeps the order in which they were added.
+type keySet struct {
+	set  map[string]bool
+	keys []string
+}
+
+func distinctKeys() *keySet {
+	return &keySet{set: make(map[string]bool)}
+}
+
+func (s *keySet) add(key string) {
+	if !s.set[key] {
+		s.set[key] = true
+		s.keys = append(s.keys, key)
+	}
+}
+
+// isSizeChange checks if the size of an object changed by at least the given ratio.
+// Objects truncated to zero bytes are always a size change.
+func isSizeChange(previousSize int64, size int64, ratio float64) bool {
+	if previousSize == 0 || ratio <= 0 {
+		return false
+	}
+	if size == 0 {
+		return true
+	}
+
+	larger, smaller := float64(previousSize), float64(size)
+	if smaller > larger {
+		larger, smaller = smaller, larger
+	}
+
+	return larger/smaller >= ratio
+}
+
+// extensionChanges finds the keys written in place of a deleted key with the same name but another
+// file extension, either replacing its extension (report.docx -> report.locked) or appending one
+// (report.docx -> report.docx.locked). It also returns a description of the most common change of extension.
+func extensionChanges(events Events, deleted map[string]bool) ([]string, string) {
+	stems := make(map[string]string)
+	for key := range deleted {
+		stems[stemOf(key)] = key
+	}
+
+	renamed := distinctKeys()
+	transitions := make(map[string]int)
+
+	for _, e := range events {
+		if e.Kind != WRITE || deleted[e.Key] {
+			continue
+		}
+
+		stem := stemOf(e.Key)
+		original, ok := stems[stem]
+		if !ok {
+			if !deleted[stem] {
+				continue
+			}
+			original = stem
+		}
+
+		if original == e.Key {
+			continue
+		}
+
+		renamed.add(e.Key)
+		transitions[fmt.Sprintf("'%s' -> '%s'", extensionOf(original), extensionOf(e.Key))]++
+	}
+
+	var names []string
+	for t := range transitions {
+		names = append(names, t)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		if transitions[names[i]] == transitions[names[j]] {
+			return names[i] < names[j]
+		}
+		return transitions[names[i]] > transitions[names[j]]
+	})
+
+	if len(names) == 0 {
+		return nil, ""
+	}
+
+	return renamed.keys, fmt.Sprintf("%s (%d)", names[0], transitions[names[0]])
+}
+
+// extensionOf returns the file extension of the last segment of a key, including the dot.
+func extensionOf(key string) string {
+	return path.Ext(key[strings.LastIndex(key, "/")+1:])
+}
+
+// stemOf returns a key without its file extension.
+func stemOf(key string) string {
+	return strings.TrimSuffix(key, extensionOf(key))
+}
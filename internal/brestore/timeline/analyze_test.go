@@ -0,0 +1,90 @@
+// Copyright 2021 VILT Group
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package timeline
+
+import (
+	"testing"
+	"time"
+)
+
+func TestAnalyze(t *testing.T) {
+	events := Events{
+		// a normal burst of writes
+		{Kind: WRITE, Key: "logs/1.log", Time: at(0)},
+		{Kind: WRITE, Key: "logs/2.log", Time: at(1)},
+		// files encrypted into new files, deleting the originals
+		{Kind: WRITE, Key: "docs/a.docx.locked", Time: at(600)},
+		{Kind: DELETE, Key: "docs/a.docx", Time: at(600)},
+		{Kind: WRITE, Key: "docs/b.locked", Time: at(601)},
+		{Kind: DELETE, Key: "docs/b.docx", Time: at(601)},
+		{Kind: DELETE, Key: "data/c.csv", Time: at(602)},
+		// files truncated or replaced in place
+		{Kind: OVERWRITE, Key: "data/d.csv", Time: at(603), PreviousSize: 1000, Size: 0},
+		{Kind: OVERWRITE, Key: "data/e.csv", Time: at(604), PreviousSize: 1000, Size: 10},
+		{Kind: OVERWRITE, Key: "data/f.csv", Time: at(605), PreviousSize: 1000, Size: 900},
+	}
+
+	sets := ChangeSets(events, time.Minute, 1)
+	findings := Analyze(sets, Thresholds{MinKeys: 2, SizeRatio: 10, PrefixDepth: 1})
+
+	expected := []struct {
+		kind FindingKind
+		keys int
+	}{
+		{MASS_DELETE, 3},
+		{BULK_OVERWRITE, 3},
+		{EXTENSION_CHANGE, 2},
+		{SIZE_CHANGE, 2},
+	}
+
+	if len(findings) != len(expected) {
+		t.Fatalf("unexpected number of findings: expected %d | got: %+v", len(expected), findings)
+	}
+
+	for i, e := range expected {
+		f := findings[i]
+		if f.Kind != e.kind || f.Keys != e.keys {
+			t.Fatalf("unexpected finding %d: expected %v with %d keys | got: %v with %d keys",
+				i, e.kind, e.keys, f.Kind, f.Keys)
+		}
+		if f.ChangeSetID != sets[1].ID || !f.RestorePoint.Before(at(600)) {
+			t.Fatalf("unexpected change set of finding %d: %+v", i, f)
+		}
+	}
+
+	if findings[0].Prefixes[0] != (PrefixCount{Prefix: "docs/", Keys: 2}) {
+		t.Fatalf("unexpected prefixes of mass delete: %v", findings[0].Prefixes)
+	}
+}
+
+func TestIsSizeChange(t *testing.T) {
+	var tests = []struct {
+		previousSize int64
+		size         int64
+		expected     bool
+	}{
+		{0, 100, false},
+		{100, 0, true},
+		{100, 10, true},
+		{10, 100, true},
+		{100, 11, false},
+	}
+
+	for _, test := range tests {
+		if res := isSizeChange(test.previousSize, test.size, 10); res != test.expected {
+			t.Fatalf("isSizeChange(%d, %d): expected %v | got: %v", test.previousSize, test.size, test.expected, res)
+		}
+	}
+}
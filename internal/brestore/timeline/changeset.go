@@ -0,0 +1,129 @@
+// Copyright 2021 VILT Group
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package timeline
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"sort"
+	"time"
+
+	"github.com/viltgroup/bucket-restore/internal/brestore"
+)
+
+// PrefixCount represents the number of distinct keys changed inside a path prefix.
+type PrefixCount struct {
+	Prefix string `json:"prefix"`
+	Keys   int    `json:"keys"`
+}
+
+// ChangeSet represents a burst of changes, separated from the other changes by a quiet period.
+type ChangeSet struct {
+	// Identifier of the change set. It only depends on the first change of the burst,
+	// so it's the same between runs as long as the quiet period used to find the bursts is the same.
+	ID string `json:"id"`
+	// Time of the first change
+	Start time.Time `json:"start"`
+	// Time of the last change
+	End time.Time `json:"end"`
+	// Changes in the burst, sorted by time
+	Events Events `json:"-"`
+	// Number of distinct keys changed
+	Keys int `json:"keys"`
+	// Path prefixes of the changed keys, sorted by descending number of keys
+	Prefixes []PrefixCount `json:"prefixes"`
+}
+
+// RestorePoint returns the latest point in time before any change of the change set happened.
+func (cs ChangeSet) RestorePoint() time.Time {
+	return cs.Start.Add(-time.Nanosecond)
+}
+
+// ChangeSets groups events into bursts of changes. A new burst starts whenever no change happened
+// for at least gap. The prefixes of each change set are the first prefixDepth segments of the changed keys.
+func ChangeSets(events Events, gap time.Duration, prefixDepth int) []ChangeSet {
+	var res []ChangeSet
+
+	events.SortByTimeAsc()
+
+	start := 0
+	for i := 1; i <= len(events); i++ {
+		if i < len(events) && events[i].Time.Sub(events[i-1].Time) < gap {
+			continue
+		}
+		res = append(res, newChangeSet(events[start:i], prefixDepth))
+		start = i
+	}
+
+	return res
+}
+
+// FindChangeSet returns the change set with the given id.
+func FindChangeSet(sets []ChangeSet, id string) (ChangeSet, bool) {
+	for _, cs := range sets {
+		if cs.ID == id {
+			return cs, true
+		}
+	}
+	return ChangeSet{}, false
+}
+
+func newChangeSet(events Events, prefixDepth int) ChangeSet {
+	first := events[0]
+	hash := sha1.Sum([]byte(first.Time.UTC().Format(time.RFC3339Nano) + "\x00" + first.Key + "\x00" + first.Version))
+
+	cs := ChangeSet{
+		ID:     hex.EncodeToString(hash[:])[:10],
+		Start:  first.Time,
+		End:    events[len(events)-1].Time,
+		Events: events,
+	}
+
+	var keys []string
+	seen := make(map[string]bool)
+	for _, e := range events {
+		if !seen[e.Key] {
+			seen[e.Key] = true
+			keys = append(keys, e.Key)
+		}
+	}
+
+	cs.Keys = len(keys)
+	cs.Prefixes = prefixCounts(keys, prefixDepth)
+
+	return cs
+}
+
+// prefixCounts counts the given distinct keys by their first prefixDepth path segments.
+// The result is sorted by descending number of keys.
+func prefixCounts(keys []string, prefixDepth int) []PrefixCount {
+	counts := make(map[string]int)
+	for _, key := range keys {
+		counts[brestore.PathPrefix(key, prefixDepth)]++
+	}
+
+	var res []PrefixCount
+	for prefix, n := range counts {
+		res = append(res, PrefixCount{Prefix: prefix, Keys: n})
+	}
+	sort.Slice(res, func(i, j int) bool {
+		if res[i].Keys == res[j].Keys {
+			return res[i].Prefix < res[j].Prefix
+		}
+		return res[i].Keys > res[j].Keys
+	})
+
+	return res
+}
@@ -0,0 +1,65 @@
+// Copyright 2021 VILT Group
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package timeline
+
+import (
+	"testing"
+	"time"
+)
+
+func TestChangeSets(t *testing.T) {
+	events := Events{
+		{Kind: WRITE, Key: "logs/a", Time: at(0)},
+		{Kind: WRITE, Key: "logs/b", Time: at(20)},
+		{Kind: DELETE, Key: "data/x/1", Time: at(300)},
+		{Kind: DELETE, Key: "data/x/2", Time: at(301)},
+		{Kind: DELETE, Key: "data/y/3", Time: at(302)},
+		{Kind: OVERWRITE, Key: "data/x/1", Time: at(350)},
+	}
+
+	sets := ChangeSets(events, time.Minute, 1)
+	if len(sets) != 2 {
+		t.Fatalf("unexpected number of change sets: expected 2 | got: %d", len(sets))
+	}
+
+	if !sets[0].Start.Equal(at(0)) || !sets[0].End.Equal(at(20)) || sets[0].Keys != 2 {
+		t.Fatalf("unexpected first change set: %+v", sets[0])
+	}
+
+	second := sets[1]
+	if !second.Start.Equal(at(300)) || !second.End.Equal(at(350)) || len(second.Events) != 4 || second.Keys != 3 {
+		t.Fatalf("unexpected second change set: %+v", second)
+	}
+
+	if len(second.Prefixes) != 1 || second.Prefixes[0] != (PrefixCount{Prefix: "data/", Keys: 3}) {
+		t.Fatalf("unexpected prefixes: %v", second.Prefixes)
+	}
+
+	if !second.RestorePoint().Before(at(300)) || !second.RestorePoint().After(at(20)) {
+		t.Fatalf("unexpected restore point: %v", second.RestorePoint())
+	}
+
+	// the id only depends on the first change of the burst
+	again := ChangeSets(events[:3], time.Minute, 1)
+	if found, ok := FindChangeSet(again, second.ID); !ok || !found.Start.Equal(second.Start) {
+		t.Fatalf("change set '%s' not found again", second.ID)
+	}
+}
+
+func TestChangeSetsEmpty(t *testing.T) {
+	if sets := ChangeSets(Events{}, time.Minute, 1); len(sets) != 0 {
+		t.Fatalf("expected no change sets, got: %v", sets)
+	}
+}
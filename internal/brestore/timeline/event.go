@@ -0,0 +1,104 @@
+// Copyright 2021 VILT Group
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package timeline
+
+import (
+	"sort"
+	"time"
+)
+
+// Enumeration of EventKinds.
+const (
+	// Represents the creation of an object in a path that had no live object
+	WRITE EventKind = iota
+	// Represents the creation of an object that replaced a live object in the same path
+	OVERWRITE
+	// Represents the deletion of a live object
+	DELETE
+)
+
+// EventKind represents the kind of change made to a path.
+type EventKind int
+
+// String converts an EventKind to a string representation.
+// Implements the Stringer interface.
+func (k EventKind) String() string {
+	switch k {
+	case WRITE:
+		return "Write"
+	case OVERWRITE:
+		return "Overwrite"
+	case DELETE:
+		return "Delete"
+	default:
+		return "Unknown Event"
+	}
+}
+
+// Event represents a change made to a path of a bucket at a point in time.
+type Event struct {
+	// The kind of change
+	Kind EventKind
+	// Path of the object that changed
+	Key string
+	// Time of the change
+	Time time.Time
+	// Size of the object after the change. Zero for deletes
+	Size int64
+	// Size of the object replaced or deleted by the change. Zero for writes
+	PreviousSize int64
+	// Version id or generation created by the change, or of the delete marker
+	Version string
+}
+
+// Events represents a collection of events
+type Events []Event
+
+// SortByTimeAsc sorts the events by ascending order of their time.
+// Events that happened at the same time are sorted by key.
+func (es Events) SortByTimeAsc() {
+	ascOrder := func(i, j int) bool {
+		if es[i].Time.Equal(es[j].Time) {
+			return es[i].Key < es[j].Key
+		}
+		return es[i].Time.Before(es[j].Time)
+	}
+	es.SortIfNeeded(ascOrder)
+}
+
+// SortIfNeeded sorts the collection of events by the given sortFunc,
+// but checks first if the list isn't already sorted by the same sortFunc.
+// If the collection is already sorted, nothing is done.
+func (es Events) SortIfNeeded(sortFunc func(i, j int) bool) {
+	if !sort.SliceIsSorted(es, sortFunc) {
+		sort.Slice(es, sortFunc)
+	}
+}
+
+// Between returns the events that happened between since (inclusive) and until (exclusive).
+// A zero since or until means the range has no lower or upper bound.
+func (es Events) Between(since time.Time, until time.Time) Events {
+	var res Events
+	for _, e := range es {
+		if !since.IsZero() && e.Time.Before(since) {
+			continue
+		}
+		if !until.IsZero() && !e.Time.Before(until) {
+			continue
+		}
+		res = append(res, e)
+	}
+	return res
+}
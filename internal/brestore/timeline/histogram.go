@@ -0,0 +1,84 @@
+// Copyright 2021 VILT Group
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package timeline
+
+import "time"
+
+// Bin represents the number of events of each kind that happened in an interval of time.
+type Bin struct {
+	// Start of the interval (inclusive)
+	Start time.Time `json:"start"`
+	// End of the interval (exclusive)
+	End        time.Time `json:"end"`
+	Writes     int64     `json:"writes"`
+	Overwrites int64     `json:"overwrites"`
+	Deletes    int64     `json:"deletes"`
+}
+
+// Total returns the number of events in the bin.
+func (b Bin) Total() int64 {
+	return b.Writes + b.Overwrites + b.Deletes
+}
+
+// MaxBins is the maximum number of intervals of a histogram.
+const MaxBins = 100000
+
+// Histogram counts the events of each kind in consecutive intervals of the given width,
+// from since (inclusive) until (exclusive). The intervals are aligned to multiples of the width.
+// Events outside the range are ignored. A zero since gives no bins, and ranges longer than MaxBins intervals
+// are cut at the end.
+func Histogram(events Events, since time.Time, until time.Time, width time.Duration) []Bin {
+	if width <= 0 || since.IsZero() || !since.Before(until) {
+		return nil
+	}
+
+	start := since.Truncate(width)
+	nBins := BinCount(start, until, width)
+	if nBins > MaxBins {
+		nBins = MaxBins
+		until = start.Add(time.Duration(nBins) * width)
+	}
+
+	bins := make([]Bin, nBins)
+	for i := range bins {
+		bins[i].Start = start.Add(time.Duration(i) * width)
+		bins[i].End = bins[i].Start.Add(width)
+	}
+
+	for _, e := range events.Between(since, until) {
+		b := &bins[int(e.Time.Sub(start)/width)]
+		switch e.Kind {
+		case WRITE:
+			b.Writes++
+		case OVERWRITE:
+			b.Overwrites++
+		case DELETE:
+			b.Deletes++
+		}
+	}
+
+	return bins
+}
+
+// BinCount returns the number of intervals of the given width needed to cover a range, or MaxBins + 1 if there
+// are more than MaxBins of them.
+func BinCount(since time.Time, until time.Time, width time.Duration) int {
+	// durations longer than about 290 years saturate, so the count is checked before rounding up
+	d := until.Sub(since)
+	if d/width >= MaxBins {
+		return MaxBins + 1
+	}
+	return int((d + width - 1) / width)
+}
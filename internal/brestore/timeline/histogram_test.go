@@ -0,0 +1,89 @@
+// Copyright 2021 VILT Group
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package timeline
+
+import (
+	"testing"
+	"time"
+)
+
+var testStart = time.Date(2021, 2, 21, 9, 0, 0, 0, time.UTC)
+
+func at(seconds int) time.Time {
+	return testStart.Add(time.Duration(seconds) * time.Second)
+}
+
+func TestHistogram(t *testing.T) {
+	events := Events{
+		{Kind: WRITE, Key: "a", Time: at(-1)},
+		{Kind: WRITE, Key: "a", Time: at(0)},
+		{Kind: OVERWRITE, Key: "a", Time: at(30)},
+		{Kind: DELETE, Key: "b", Time: at(61)},
+		{Kind: DELETE, Key: "c", Time: at(119)},
+		{Kind: WRITE, Key: "d", Time: at(180)},
+	}
+
+	bins := Histogram(events, at(10), at(180), time.Minute)
+
+	expected := []Bin{
+		{Start: at(0), End: at(60), Overwrites: 1},
+		{Start: at(60), End: at(120), Deletes: 2},
+		{Start: at(120), End: at(180)},
+	}
+
+	if len(bins) != len(expected) {
+		t.Fatalf("unexpected number of bins: expected %d | got: %d", len(expected), len(bins))
+	}
+
+	for i, b := range bins {
+		if !b.Start.Equal(expected[i].Start) || !b.End.Equal(expected[i].End) ||
+			b.Writes != expected[i].Writes || b.Overwrites != expected[i].Overwrites || b.Deletes != expected[i].Deletes {
+			t.Fatalf("unexpected bin %d: expected %v | got: %v", i, expected[i], b)
+		}
+	}
+}
+
+func TestHistogramEmptyRange(t *testing.T) {
+	if bins := Histogram(Events{}, at(10), at(10), time.Minute); bins != nil {
+		t.Fatalf("expected no bins for an empty range, got: %v", bins)
+	}
+}
+
+func TestHistogramNoEvents(t *testing.T) {
+	// without events nor --since, the activity command has no start for the range
+	if bins := Histogram(Events{}, time.Time{}, at(10), time.Minute); bins != nil {
+		t.Fatalf("expected no bins for a zero start, got: %v", bins)
+	}
+
+	bins := Histogram(Events{}, at(0), at(120), time.Minute)
+	if len(bins) != 2 || bins[0].Total() != 0 || bins[1].Total() != 0 {
+		t.Fatalf("expected 2 empty bins, got: %v", bins)
+	}
+}
+
+func TestHistogramMaxBins(t *testing.T) {
+	events := Events{
+		{Kind: WRITE, Key: "a", Time: at(0)},
+		{Kind: WRITE, Key: "b", Time: at(10 * MaxBins)},
+	}
+
+	bins := Histogram(events, at(0), at(20*MaxBins), time.Second)
+	if len(bins) != MaxBins {
+		t.Fatalf("unexpected number of bins: expected %d | got: %d", MaxBins, len(bins))
+	}
+	if bins[0].Writes != 1 {
+		t.Fatalf("expected the event inside the bins to be counted, got: %v", bins[0])
+	}
+}